@@ -3,20 +3,133 @@ package cfg
 import (
 	"flag"
 	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 )
 
 type Config struct {
-	AppEnv           string
-	Host             string
-	Port             string
+	AppEnv string
+	Host   string
+	Port   string
+	// proxies whose X-Forwarded-For is believed, empty when clients connect directly
+	TrustedProxies   []netip.Prefix
 	DbConfig         DbConfig
 	OpenAIAPIKey     string
 	ElevenLabsAPIKey string
 	WhisperModelPath string
-	DeepgramAPIKey   string
+	// default speech-to-text provider, elevenlabs or whisper
+	STTProvider    string
+	DeepgramAPIKey string
+	TTSCacheConfig TTSCacheConfig
+	// json file of external MCP servers, see MCPServerConfig
+	MCPServersFile string
+	// yaml or json file of privilege levels applied by the seed command
+	PrivilegesFile string
+	// tool call argument keys whose values are never written to the audit log
+	ToolAuditRedactKeys []string
+	// tools whose calls must be approved by the caller first, see appMCP.RequireConfirmation
+	ToolConfirmTools   []string
+	ToolConfirmTimeout time.Duration
+	// openai or fake, the fake embedder needs no network
+	Embedder       string
+	EmbeddingModel string
+	PhoneConfig    PhoneConfig
+	SessionConfig  SessionConfig
+	MailConfig     MailConfig
+	AccountConfig  AccountConfig
+	OIDCConfig     OIDCConfig
+	LoginConfig    LoginConfig
+}
+
+// LoginConfig throttles failed logins. After the free attempts each failure
+// locks the account or ip for twice as long as the last, up to MaxLockout.
+type LoginConfig struct {
+	AccountFreeAttempts int64
+	// higher than the account's since many users can share an ip
+	IPFreeAttempts int64
+	BaseLockout    time.Duration
+	MaxLockout     time.Duration
+	// failures older than this are forgotten
+	FailureWindow time.Duration
+}
+
+// OIDCConfig enables single sign-on when IssuerURL is set
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// ID token claim listing the user's groups
+	GroupsClaim string
+	// in order, the first group the user is in picks their privilege level
+	GroupLevels []GroupLevel
+	// level of new users in none of the groups, empty for the signup level
+	DefaultLevel string
+}
+
+type GroupLevel struct {
+	Group string
+	Level string
+}
+
+type MailConfig struct {
+	// smtp, file or log
+	Provider string
+	From     string
+	// where the file mailer writes messages
+	Dir          string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type AccountConfig struct {
+	// where the app is reached from, emailed links start with it
+	PublicURL string
+	// unverified accounts can only verify their email when true
+	RequireEmailVerification bool
+	ResetTokenTTL            time.Duration
+	VerifyTokenTTL           time.Duration
+}
+
+type SessionConfig struct {
+	// a session not used for this long expires
+	IdleTimeout time.Duration
+	// a session expires this long after login however much it is used
+	AbsoluteTimeout time.Duration
+	// how often a used session's expiry is pushed back, renewing on every
+	// request would write to the database each time
+	RenewInterval time.Duration
+	// how often expired sessions are deleted
+	PurgeInterval time.Duration
+}
+
+type PhoneConfig struct {
+	// only fake until a telephony vendor is added
+	Provider string
+	// target name to the number or queue transfer_call dials
+	TransferTargets       map[string]string
+	DefaultTransferTarget string
+	// keypad digit to the menu action it triggers, "transfer", "transfer:<target>"
+	// or "hangup"
+	DTMFMenu map[string]string
+	// outcome the fake provider reports for dialed calls, empty leaves them ringing
+	FakeOutcome string
+}
+
+type TTSCacheConfig struct {
+	// optional directory to persist synthesized audio to
+	Dir      string
+	MaxBytes int64
+	// when true only cached audio is served and the provider is never called
+	Offline bool
 }
 
 type DbConfig struct {
@@ -36,14 +149,76 @@ func LoadConfig() Config {
 		dbPort = os.Getenv("DB_PORT")
 		dbHost = os.Getenv("DB_HOST")
 	}
+	publicURL := strings.TrimSuffix(envString("PUBLIC_URL", "http://localhost:"+os.Getenv("PORT")), "/")
 	return Config{
 		AppEnv:           os.Getenv("APP_ENV"),
 		Host:             os.Getenv("HOST"),
 		Port:             os.Getenv("PORT"),
+		TrustedProxies:   envPrefixes("TRUSTED_PROXIES"),
 		OpenAIAPIKey:     os.Getenv("OPENAI_API_KEY"),
 		ElevenLabsAPIKey: os.Getenv("ELEVEN_LABS_API_KEY"),
 		WhisperModelPath: os.Getenv("WHISPER_MODEL_PATH"),
+		STTProvider:      envString("STT_PROVIDER", "elevenlabs"),
 		DeepgramAPIKey:   os.Getenv("DEEPGRAM_KEY"),
+		TTSCacheConfig: TTSCacheConfig{
+			Dir:      os.Getenv("TTS_CACHE_DIR"),
+			MaxBytes: envInt64("TTS_CACHE_MAX_BYTES", 64*1024*1024),
+			Offline:  envBool("TTS_CACHE_OFFLINE"),
+		},
+		MCPServersFile: os.Getenv("MCP_SERVERS_FILE"),
+		PrivilegesFile: envString("PRIVILEGES_FILE", "privileges.yaml"),
+		ToolAuditRedactKeys: envList("TOOL_AUDIT_REDACT_KEYS", []string{
+			"password", "token", "secret", "api_key", "authorization", "ssn", "card_number",
+		}),
+		ToolConfirmTools:   envList("TOOL_CONFIRM_TOOLS", nil),
+		ToolConfirmTimeout: time.Duration(envInt64("TOOL_CONFIRM_TIMEOUT_SECONDS", 30)) * time.Second,
+		Embedder:           envString("EMBEDDER", "openai"),
+		EmbeddingModel:     envString("EMBEDDING_MODEL", "text-embedding-3-small"),
+		PhoneConfig: PhoneConfig{
+			Provider:              envString("PHONE_PROVIDER", "fake"),
+			TransferTargets:       envMap("PHONE_TRANSFER_TARGETS"),
+			DefaultTransferTarget: os.Getenv("PHONE_DEFAULT_TRANSFER_TARGET"),
+			DTMFMenu:              envMap("PHONE_DTMF_MENU"),
+			FakeOutcome:           envString("PHONE_FAKE_OUTCOME", "completed"),
+		},
+		SessionConfig: SessionConfig{
+			IdleTimeout:     time.Duration(envInt64("SESSION_IDLE_TIMEOUT_MINUTES", 12*60)) * time.Minute,
+			AbsoluteTimeout: time.Duration(envInt64("SESSION_ABSOLUTE_TIMEOUT_HOURS", 30*24)) * time.Hour,
+			RenewInterval:   time.Duration(envInt64("SESSION_RENEW_INTERVAL_SECONDS", 60)) * time.Second,
+			PurgeInterval:   time.Duration(envInt64("SESSION_PURGE_INTERVAL_MINUTES", 60)) * time.Minute,
+		},
+		MailConfig: MailConfig{
+			Provider:     envString("MAIL_PROVIDER", "log"),
+			From:         envString("MAIL_FROM", "no-reply@localhost"),
+			Dir:          envString("MAIL_DIR", "mail"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     envString("SMTP_PORT", "587"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
+		AccountConfig: AccountConfig{
+			PublicURL:                publicURL,
+			RequireEmailVerification: envBool("REQUIRE_EMAIL_VERIFICATION"),
+			ResetTokenTTL:            time.Duration(envInt64("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute,
+			VerifyTokenTTL:           time.Duration(envInt64("EMAIL_VERIFY_TTL_HOURS", 48)) * time.Hour,
+		},
+		OIDCConfig: OIDCConfig{
+			IssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  envString("OIDC_REDIRECT_URL", publicURL+"/sso/callback"),
+			Scopes:       envList("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			GroupsClaim:  envString("OIDC_GROUPS_CLAIM", "groups"),
+			GroupLevels:  envGroupLevels("OIDC_GROUP_LEVELS"),
+			DefaultLevel: os.Getenv("OIDC_DEFAULT_LEVEL"),
+		},
+		LoginConfig: LoginConfig{
+			AccountFreeAttempts: envInt64("LOGIN_ACCOUNT_FREE_ATTEMPTS", 5),
+			IPFreeAttempts:      envInt64("LOGIN_IP_FREE_ATTEMPTS", 20),
+			BaseLockout:         time.Duration(envInt64("LOGIN_BASE_LOCKOUT_SECONDS", 30)) * time.Second,
+			MaxLockout:          time.Duration(envInt64("LOGIN_MAX_LOCKOUT_MINUTES", 15)) * time.Minute,
+			FailureWindow:       time.Duration(envInt64("LOGIN_FAILURE_WINDOW_MINUTES", 60)) * time.Minute,
+		},
 		DbConfig: DbConfig{
 			user:     os.Getenv("DB_USER"),
 			password: os.Getenv("DB_PASSWORD"),
@@ -63,3 +238,76 @@ func (cfg *Config) DbUrl() string {
 		cfg.DbConfig.Name,
 	)
 }
+
+func envInt64(key string, def int64) int64 {
+	v, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func envBool(key string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(key))
+	return v
+}
+
+func envString(key string, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// comma separated list
+func envList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// comma separated name=value pairs
+func envMap(key string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range envList(key, nil) {
+		name, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(name) != "" {
+			m[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return m
+}
+
+// comma separated group=level pairs, kept in order
+func envGroupLevels(key string) []GroupLevel {
+	var levels []GroupLevel
+	for _, pair := range envList(key, nil) {
+		group, level, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(group) != "" && strings.TrimSpace(level) != "" {
+			levels = append(levels, GroupLevel{Group: strings.TrimSpace(group), Level: strings.TrimSpace(level)})
+		}
+	}
+	return levels
+}
+
+// comma separated addresses or CIDR ranges, invalid entries are skipped
+func envPrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range envList(key, nil) {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(item); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
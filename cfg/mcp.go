@@ -0,0 +1,90 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportSSE   = "sse"
+	MCPTransportHTTP  = "http"
+)
+
+// MCPServerConfig declares an external MCP server whose tools are proxied to the
+// agents. Its tools are exposed as <name>__<tool>.
+type MCPServerConfig struct {
+	Name string `json:"name"`
+	// stdio, sse or http (streamable http)
+	Transport string `json:"transport"`
+
+	// stdio
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Env     []string `json:"env"`
+
+	// sse and http
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+
+	// tools, by their upstream name, that the caller must approve before they run
+	ConfirmTools []string `json:"confirm_tools"`
+
+	HealthIntervalSeconds int `json:"health_interval_seconds"`
+	CallTimeoutSeconds    int `json:"call_timeout_seconds"`
+}
+
+func (c MCPServerConfig) HealthInterval() time.Duration {
+	if c.HealthIntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.HealthIntervalSeconds) * time.Second
+}
+
+func (c MCPServerConfig) CallTimeout() time.Duration {
+	if c.CallTimeoutSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.CallTimeoutSeconds) * time.Second
+}
+
+// LoadMCPServers reads a json array of MCPServerConfig. An empty path means no
+// external servers.
+func LoadMCPServers(path string) ([]MCPServerConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var servers []MCPServerConfig
+	if err := json.Unmarshal(data, &servers); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for i, s := range servers {
+		if s.Name == "" {
+			return nil, fmt.Errorf("mcp server %d: name is required", i)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("mcp server %s: duplicate name", s.Name)
+		}
+		names[s.Name] = true
+		switch s.Transport {
+		case MCPTransportStdio:
+			if s.Command == "" {
+				return nil, fmt.Errorf("mcp server %s: command is required for stdio", s.Name)
+			}
+		case MCPTransportSSE, MCPTransportHTTP:
+			if s.URL == "" {
+				return nil, fmt.Errorf("mcp server %s: url is required for %s", s.Name, s.Transport)
+			}
+		default:
+			return nil, fmt.Errorf("mcp server %s: unknown transport %q", s.Name, s.Transport)
+		}
+	}
+	return servers, nil
+}
@@ -2,8 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 )
 
+var commands = []string{"web", "seed", "genDAO", "genController", "genService", "tts-batch"}
+
+// Exists reports whether name is a known command
+func Exists(name string) bool {
+	return slices.Contains(commands, name)
+}
+
 func Execute(cmd string) {
 	switch cmd {
 	case "web":
@@ -16,6 +24,8 @@ func Execute(cmd string) {
 		generateController()
 	case "genService":
 		generateService()
+	case "tts-batch":
+		ttsBatch()
 	default:
 		panic(fmt.Sprintf("Invalid cmd: %s", cmd))
 	}
@@ -3,15 +3,19 @@ package cmd
 import (
 	"database/sql"
 	"flag"
+	"fmt"
 
 	"github.com/carsonkrueger/main/cfg"
 	"github.com/carsonkrueger/main/logger"
 	"github.com/carsonkrueger/main/seeders"
 	_ "github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 func seed() {
 	undo := flag.Bool("undo", false, "-undo=true")
+	file := flag.String("file", "", "-file=privileges.yaml, defaults to PRIVILEGES_FILE")
+	dryRun := flag.Bool("dry-run", false, "-dry-run=true prints the changes without applying them")
 	flag.Parse()
 	cfg := cfg.LoadConfig()
 
@@ -24,13 +28,33 @@ func seed() {
 
 	if *undo {
 		lgr.Info("Starting undo...")
-		err = seeders.UndoPermissions(db)
-	} else {
-		lgr.Info("Starting seeds...")
-		err = seeders.SeedPermissions(db)
+		if err := seeders.UndoPermissions(db); err != nil {
+			panic(err)
+		}
+		lgr.Info("Finished")
+		return
 	}
+
+	path := cfg.PrivilegesFile
+	if *file != "" {
+		path = *file
+	}
+	lgr.Info("Starting seeds...", zap.String("file", path))
+	privileges, err := seeders.LoadPrivilegesFile(path)
+	if err != nil {
+		panic(err)
+	}
+	diff, err := seeders.DiffPrivileges(db, privileges)
 	if err != nil {
 		panic(err)
 	}
+	fmt.Print(diff)
+	if *dryRun {
+		lgr.Info("Dry run, nothing applied")
+		return
+	}
+	if err := diff.Apply(db, path); err != nil {
+		panic(err)
+	}
 	lgr.Info("Finished")
 }
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	gctx "context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/elevenlabs-go"
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/logger"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/openai/openai-go"
+	"go.uber.org/zap"
+)
+
+const ttsBatchManifest = "manifest.json"
+
+type ttsBatchPrompt struct {
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+	Voice string `json:"voice"`
+	Model string `json:"model"`
+	// pcm_<sample rate>, like pcm_24000, wav files can't hold compressed formats
+	Format string `json:"format"`
+}
+
+type ttsBatchManifestEntry struct {
+	File       string    `json:"file"`
+	Text       string    `json:"text"`
+	Voice      string    `json:"voice"`
+	Model      string    `json:"model"`
+	Format     string    `json:"format"`
+	Hash       string    `json:"hash"`
+	DurationMs int64     `json:"duration_ms"`
+	RenderedAt time.Time `json:"rendered_at"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_\-.]+`)
+
+// ttsBatch renders a jsonl library of prompts to wav files, only re-rendering
+// prompts whose text or voice changed since the last run.
+func ttsBatch() {
+	in := flag.String("in", "", "jsonl file of {id, text, voice, model, format} prompts")
+	out := flag.String("out", "", "directory to write wav files and the manifest to")
+	concurrency := flag.Int("concurrency", 4, "max prompts synthesized at once")
+	retries := flag.Int("retries", 3, "attempts per prompt before giving up")
+	force := flag.Bool("force", false, "re-render every prompt")
+	flag.Parse()
+	cfg := cfg.LoadConfig()
+	lgr := logger.NewLogger(&cfg)
+	defer lgr.Sync()
+
+	if *in == "" || *out == "" {
+		fmt.Println("Usage: go run . tts-batch -in prompts.jsonl -out dir/")
+		os.Exit(1)
+	}
+
+	prompts, err := readTTSBatchPrompts(*in)
+	if err != nil {
+		lgr.Fatal("failed to read prompts", zap.Error(err))
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		lgr.Fatal("failed to create output directory", zap.Error(err))
+	}
+	manifest, err := readTTSBatchManifest(filepath.Join(*out, ttsBatchManifest))
+	if err != nil {
+		lgr.Fatal("failed to read manifest", zap.Error(err))
+	}
+
+	httpClient := http.Client{}
+	elevenLabsClient := elevenlabs.NewClient(&httpClient, gctx.Background(), cfg.ElevenLabsAPIKey, 30*time.Second)
+	svcManagerCtx := context.NewServiceManagerContext(nil, openai.Client{}, elevenLabsClient, cfg)
+	sm := services.NewServiceManager(nil, svcManagerCtx)
+	appCtx := context.NewAppContext(lgr, sm, nil, nil)
+	sm.SetAppContext(appCtx)
+	tts := sm.ElevenLabsService()
+	cache := sm.TTSCache()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failed   int
+		rendered int
+		sem      = make(chan struct{}, max(*concurrency, 1))
+		next     = make(map[string]ttsBatchManifestEntry, len(prompts))
+	)
+	for _, p := range prompts {
+		req := tts.DefaultTTSRequest(p.Text)
+		if p.Voice != "" {
+			req.Voice = p.Voice
+		}
+		if p.Model != "" {
+			req.Model = p.Model
+		}
+		if p.Format != "" {
+			req.Format = p.Format
+		}
+		sampleRate, ok := tools.PCMSampleRate(req.Format)
+		if !ok {
+			lgr.Fatal("unsupported format, only pcm_<rate> can be written to wav", zap.String("id", p.ID), zap.String("format", req.Format))
+		}
+		hash := cache.Key("elevenlabs", req)
+		file := ttsBatchFile(p.ID)
+
+		prev, ok := manifest[p.ID]
+		if _, statErr := os.Stat(filepath.Join(*out, prev.File)); !*force && ok && prev.Hash == hash && statErr == nil {
+			lgr.Debug("unchanged", zap.String("id", p.ID))
+			next[p.ID] = prev
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			plgr := lgr.With(zap.String("id", p.ID))
+
+			pcm, err := synthesizeWithRetry(tts, req, *retries, plgr)
+			if err == nil {
+				err = writeFileAtomic(filepath.Join(*out, file), tools.Int16ToWAV(tools.BytesToInt16Slice(pcm), sampleRate))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				plgr.Error("failed to render prompt", zap.Error(err))
+				failed++
+				// keep the previous render around rather than dropping it from the manifest
+				if ok {
+					next[p.ID] = prev
+				}
+				return
+			}
+			plgr.Info("rendered", zap.String("file", file))
+			rendered++
+			next[p.ID] = ttsBatchManifestEntry{
+				File:       file,
+				Text:       p.Text,
+				Voice:      req.Voice,
+				Model:      req.Model,
+				Format:     req.Format,
+				Hash:       hash,
+				DurationMs: tools.PCMDuration(len(pcm), sampleRate, 1, 16).Milliseconds(),
+				RenderedAt: time.Now().UTC(),
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		lgr.Fatal("failed to encode manifest", zap.Error(err))
+	}
+	if err := writeFileAtomic(filepath.Join(*out, ttsBatchManifest), data); err != nil {
+		lgr.Fatal("failed to write manifest", zap.Error(err))
+	}
+
+	lgr.Info("Finished",
+		zap.Int("rendered", rendered),
+		zap.Int("skipped", len(prompts)-rendered-failed),
+		zap.Int("failed", failed),
+	)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func readTTSBatchPrompts(path string) ([]ttsBatchPrompt, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prompts []ttsBatchPrompt
+	seen := make(map[string]bool)
+	// file names to the id rendered there, lower cased for case insensitive filesystems
+	files := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var p ttsBatchPrompt
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if p.ID == "" || p.Text == "" {
+			return nil, fmt.Errorf("line %d: id and text are required", line)
+		}
+		if seen[p.ID] {
+			return nil, fmt.Errorf("line %d: duplicate id %q", line, p.ID)
+		}
+		seen[p.ID] = true
+		file := strings.ToLower(ttsBatchFile(p.ID))
+		if other, ok := files[file]; ok {
+			return nil, fmt.Errorf("line %d: id %q and %q would both be written to %s", line, other, p.ID, ttsBatchFile(p.ID))
+		}
+		files[file] = p.ID
+		prompts = append(prompts, p)
+	}
+	return prompts, scanner.Err()
+}
+
+// ttsBatchFile is the wav file a prompt is rendered to
+func ttsBatchFile(id string) string {
+	return unsafeFileChars.ReplaceAllString(id, "_") + ".wav"
+}
+
+func readTTSBatchManifest(path string) (map[string]ttsBatchManifestEntry, error) {
+	manifest := make(map[string]ttsBatchManifestEntry)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func synthesizeWithRetry(tts context.ElevenLabsService, req models.TTSRequest, attempts int, lgr *zap.Logger) ([]byte, error) {
+	var err error
+	backoff := time.Second
+	for attempt := 1; attempt <= max(attempts, 1); attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		// req is not paced, so prompts already in the cache are copied at once
+		var buf bytes.Buffer
+		if err = tts.Synthesize(req, &buf); err == nil {
+			pcm := buf.Bytes()
+			if len(pcm) < 2 {
+				return nil, errors.New("no audio returned")
+			}
+			return pcm[:len(pcm)-len(pcm)%2], nil
+		}
+		if errors.Is(err, services.ErrTTSCacheMiss) {
+			return nil, err
+		}
+		lgr.Warn("synthesis failed", zap.Int("attempt", attempt), zap.Error(err))
+	}
+	return nil, err
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -47,7 +47,7 @@ func web() {
 
 	httpClient := http.Client{}
 	elevenLabsClient := elevenlabs.NewClient(&httpClient, ctx, cfg.ElevenLabsAPIKey, 10*time.Second)
-	svcManagerCtx := context.NewServiceManagerContext(open4oMini, openClient, elevenLabsClient, cfg.WhisperModelPath)
+	svcManagerCtx := context.NewServiceManagerContext(open4oMini, openClient, elevenLabsClient, cfg)
 
 	dm := DAO.NewDAOManager(db)
 	sm := services.NewServiceManager(nil, svcManagerCtx)
@@ -60,6 +60,13 @@ func web() {
 	sm.SetAppContext(appCtx)
 	defer appCtx.CleanUp()
 
+	// panics on an unknown phone provider before anything is served
+	sm.PhoneService()
+	go sm.CampaignsService().Run(ctx)
+	go sm.UsersService().RunSessionPurge(ctx)
+	go sm.SecurityService().RunThrottlePurge(ctx)
+	go sm.PrivilegesService().ListenForChanges(ctx, cfg.DbUrl())
+
 	appRouter := router.NewAppRouter(appCtx, cfg)
 	appRouter.BuildRouter()
 	if err := appRouter.Start(cfg); err != nil {
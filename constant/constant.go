@@ -1,7 +1,7 @@
 package constant
 
 const (
-	ADMIN_LEVEL_NAME = "admin"
-	BASIC_LEVEL_NAME = "basic"
-	AUTH_TOKEN_KEY   = "ghx_auth_token"
+	AUTH_TOKEN_KEY = "ghx_auth_token"
+	// postgres channel notified when level privileges change
+	PRIVILEGES_CHANGED_CHANNEL = "privileges_changed"
 )
@@ -24,6 +24,11 @@ func GetUserId(ctx gctx.Context) int64 {
 	return ctx.Value(USER_ID_KEY).(int64)
 }
 
+func LookupUserId(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(USER_ID_KEY).(int64)
+	return id, ok
+}
+
 var PRIVILEGE_LEVEL_ID_KEY = "PRIVILEGE_LEVEL_ID"
 
 func WithPrivilegeLevelID(ctx gctx.Context, id int64) gctx.Context {
@@ -34,6 +39,12 @@ func GetPrivilegeLevelID(ctx gctx.Context) int64 {
 	return ctx.Value(PRIVILEGE_LEVEL_ID_KEY).(int64)
 }
 
+// LookupPrivilegeLevelID is GetPrivilegeLevelID for contexts that may not belong to a session
+func LookupPrivilegeLevelID(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(PRIVILEGE_LEVEL_ID_KEY).(int64)
+	return id, ok
+}
+
 var CANCEL_ID_KEY = "CANCEL"
 
 func WithCancel(ctx gctx.Context, cancel gctx.CancelFunc) gctx.Context {
@@ -43,3 +54,102 @@ func WithCancel(ctx gctx.Context, cancel gctx.CancelFunc) gctx.Context {
 func GetCancel(ctx gctx.Context) gctx.CancelFunc {
 	return ctx.Value(CANCEL_ID_KEY).(gctx.CancelFunc)
 }
+
+var CONVERSATION_ID_KEY = "CONVERSATION_ID"
+
+func WithConversationID(ctx gctx.Context, id int64) gctx.Context {
+	return gctx.WithValue(ctx, CONVERSATION_ID_KEY, id)
+}
+
+func LookupConversationID(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(CONVERSATION_ID_KEY).(int64)
+	return id, ok
+}
+
+var TOOL_CONFIRMER_KEY = "TOOL_CONFIRMER"
+
+// WithToolConfirmer sets who is asked before tools flagged as requiring confirmation run
+func WithToolConfirmer(ctx gctx.Context, confirmer ToolConfirmer) gctx.Context {
+	return gctx.WithValue(ctx, TOOL_CONFIRMER_KEY, confirmer)
+}
+
+func LookupToolConfirmer(ctx gctx.Context) (ToolConfirmer, bool) {
+	confirmer, ok := ctx.Value(TOOL_CONFIRMER_KEY).(ToolConfirmer)
+	return confirmer, ok
+}
+
+var AGENT_PROFILE_ID_KEY = "AGENT_PROFILE_ID"
+
+// WithAgentProfileID scopes tools such as the knowledge base to an agent profile
+func WithAgentProfileID(ctx gctx.Context, id int64) gctx.Context {
+	return gctx.WithValue(ctx, AGENT_PROFILE_ID_KEY, id)
+}
+
+func LookupAgentProfileID(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(AGENT_PROFILE_ID_KEY).(int64)
+	return id, ok
+}
+
+var CALL_ID_KEY = "CALL_ID"
+
+// WithCallID sets the phone provider's id for the call a conversation is on
+func WithCallID(ctx gctx.Context, id string) gctx.Context {
+	return gctx.WithValue(ctx, CALL_ID_KEY, id)
+}
+
+func LookupCallID(ctx gctx.Context) (string, bool) {
+	id, ok := ctx.Value(CALL_ID_KEY).(string)
+	return id, ok
+}
+
+var AGENT_SPEAKER_KEY = "AGENT_SPEAKER"
+
+func WithAgentSpeaker(ctx gctx.Context, speaker AgentSpeaker) gctx.Context {
+	return gctx.WithValue(ctx, AGENT_SPEAKER_KEY, speaker)
+}
+
+func LookupAgentSpeaker(ctx gctx.Context) (AgentSpeaker, bool) {
+	speaker, ok := ctx.Value(AGENT_SPEAKER_KEY).(AgentSpeaker)
+	return speaker, ok
+}
+
+var ORGANIZATION_ID_KEY = "ORGANIZATION_ID"
+
+// WithOrgID sets the organization the session is working in, data queries are
+// limited to it
+func WithOrgID(ctx gctx.Context, id int64) gctx.Context {
+	return gctx.WithValue(ctx, ORGANIZATION_ID_KEY, id)
+}
+
+func GetOrgID(ctx gctx.Context) int64 {
+	return ctx.Value(ORGANIZATION_ID_KEY).(int64)
+}
+
+// LookupOrgID is GetOrgID for contexts that may not belong to a session
+func LookupOrgID(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(ORGANIZATION_ID_KEY).(int64)
+	return id, ok
+}
+
+var ORGANIZATION_ROLE_KEY = "ORGANIZATION_ROLE"
+
+// WithOrgRole sets the user's role in the organization of WithOrgID
+func WithOrgRole(ctx gctx.Context, role string) gctx.Context {
+	return gctx.WithValue(ctx, ORGANIZATION_ROLE_KEY, role)
+}
+
+func GetOrgRole(ctx gctx.Context) string {
+	return ctx.Value(ORGANIZATION_ROLE_KEY).(string)
+}
+
+var API_KEY_ID_KEY = "API_KEY_ID"
+
+// WithAPIKeyID marks the request as authenticated by an api key rather than a session
+func WithAPIKeyID(ctx gctx.Context, id int64) gctx.Context {
+	return gctx.WithValue(ctx, API_KEY_ID_KEY, id)
+}
+
+func LookupAPIKeyID(ctx gctx.Context) (int64, bool) {
+	id, ok := ctx.Value(API_KEY_ID_KEY).(int64)
+	return id, ok
+}
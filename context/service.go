@@ -1,8 +1,8 @@
 package context
 
 import (
-	// "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 	"github.com/carsonkrueger/elevenlabs-go"
+	"github.com/carsonkrueger/main/cfg"
 	"github.com/openai/openai-go"
 	"github.com/tmc/langchaingo/llms"
 )
@@ -11,13 +11,15 @@ type serviceManagerContext struct {
 	primaryModel     llms.Model
 	openaiClient     openai.Client
 	elevenLabsClient *elevenlabs.Client
+	config           cfg.Config
 }
 
-func NewServiceManagerContext(primaryModel llms.Model, openaiClient openai.Client, elevenLabsClient *elevenlabs.Client, whisperCPPModelPath string) *serviceManagerContext {
+func NewServiceManagerContext(primaryModel llms.Model, openaiClient openai.Client, elevenLabsClient *elevenlabs.Client, config cfg.Config) *serviceManagerContext {
 	return &serviceManagerContext{
 		primaryModel,
 		openaiClient,
 		elevenLabsClient,
+		config,
 	}
 }
 
@@ -32,3 +34,7 @@ func (c *serviceManagerContext) OpenaiClient() *openai.Client {
 func (c *serviceManagerContext) ElevenLabsClient() *elevenlabs.Client {
 	return c.elevenLabsClient
 }
+
+func (c *serviceManagerContext) Config() *cfg.Config {
+	return &c.config
+}
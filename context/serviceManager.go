@@ -5,10 +5,14 @@ import (
 	"database/sql"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/carsonkrueger/main/cfg"
 	"github.com/carsonkrueger/main/database/DAO"
+	agentModel "github.com/carsonkrueger/main/gen/go_db/agent/model"
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/agentModels"
 	"github.com/carsonkrueger/main/models/authModels"
 	"github.com/carsonkrueger/main/templates/datadisplay"
 	"github.com/gorilla/websocket"
@@ -16,7 +20,6 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/openai/openai-go"
 
-	// "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 	"github.com/carsonkrueger/elevenlabs-go"
 	"github.com/tmc/langchaingo/llms"
 	"go.uber.org/zap"
@@ -26,8 +29,7 @@ type ServiceManagerContext interface {
 	PrimaryModel() llms.Model
 	ElevenLabsClient() *elevenlabs.Client
 	OpenaiClient() *openai.Client
-
-	// WhisperCPPModel() whisper.Model
+	Config() *cfg.Config
 }
 
 type ServiceContext interface {
@@ -45,28 +47,163 @@ type ServiceManager interface {
 	WebSocketService() WebSocketService
 	MCPService() AppMCPService
 	ElevenLabsService() ElevenLabsService
+	TTSCache() TTSCache
+	STTService() STTService
+	ConversationsService() ConversationsService
+	ToolCallsService() ToolCallsService
+	KnowledgeBaseService() KnowledgeBaseService
+	CampaignsService() CampaignsService
+	OrganizationsService() OrganizationsService
+	APIKeysService() APIKeysService
+	Mailer() Mailer
+	AccountsService() AccountsService
+	OIDCService() OIDCService
+	SecurityService() SecurityService
+	AuditService() AuditService
 }
 
 type ElevenLabsService interface {
 	TextToSpeechStream(msg string, w io.Writer) error
+	Synthesize(req models.TTSRequest, w io.Writer) error
+	DefaultTTSRequest(msg string) models.TTSRequest
+}
+
+type TTSCache interface {
+	Key(provider string, req models.TTSRequest) string
+	Get(key string) ([]byte, bool)
+	Put(key string, audio []byte) error
+	Offline() bool
+}
+
+// STTProvider transcribes a complete clip of 16 kHz mono PCM
+type STTProvider interface {
+	Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error)
+}
+
+type STTService interface {
+	STTProvider
+	// Provider looks up a provider by name such as "whisper" or "elevenlabs"
+	Provider(name string) (STTProvider, bool)
+	ProviderNames() []string
+	DefaultProvider() string
+	// TranscribeStream splits streamed 16 kHz 16 bit mono PCM into utterances and
+	// emits partial transcripts while each is spoken and a final one when it ends.
+	TranscribeStream(ctx gctx.Context, r models.StreamingReader, emit func(models.Transcript)) error
 }
 
 type UsersService interface {
 	Login(email string, password string, req *http.Request) (*string, error)
+	StartSession(userID int64, orgID *int64, req *http.Request) (*string, error)
+	// CheckSession returns an error for expired sessions and renews active ones
+	CheckSession(session *model.Sessions) error
+	ActiveSessions(userID int64) ([]*model.Sessions, error)
+	// RevokeSession and RevokeAllSessions are audited as the user of ctx
+	RevokeSession(ctx gctx.Context, userID int64, sessionID int64) error
+	RevokeAllSessions(ctx gctx.Context, userID int64) error
+	// RunSessionPurge deletes expired sessions until ctx is done
+	RunSessionPurge(ctx gctx.Context)
+	SessionsAsRowData(sessions []*model.Sessions, currentToken string) []datadisplay.RowData
 	Logout(id int64, token string) error
 	LogoutRequest(req *http.Request) error
 	GetAuthParts(req *http.Request) (string, int64, error)
 }
 
+// OrganizationsService manages organizations and their members. Methods taking
+// ctx act on the organization of the session, see WithOrgID.
+type OrganizationsService interface {
+	Create(name string, ownerID int64, tx *sql.Tx) (*model.Organizations, error)
+	SessionOrganization(session *model.Sessions) (int64, string, error)
+	Switch(ctx gctx.Context, orgID int64) error
+	AddMember(ctx gctx.Context, email string, role string) error
+	SetMemberRole(ctx gctx.Context, userID int64, role string) error
+	RemoveMember(ctx gctx.Context, userID int64) error
+	AddPhoneNumber(ctx gctx.Context, number string, profileID *int64) (*agentModel.PhoneNumbers, error)
+	RemovePhoneNumber(ctx gctx.Context, id int64) error
+	MembersAsRowData(members []authModels.OrganizationMemberJoin, canManage bool) []datadisplay.RowData
+	PhoneNumbersAsRowData(numbers []*agentModel.PhoneNumbers, profiles []*agentModel.Profiles, canManage bool) []datadisplay.RowData
+}
+
+// APIKeysService manages personal api keys, which act as their user in the
+// key's organization limited to the key's scopes
+type APIKeysService interface {
+	Create(ctx gctx.Context, name string, expiresIn time.Duration, privilegeIDs []int64) (string, *model.APIKeys, error)
+	Authenticate(key string) (*model.APIKeys, error)
+	HasScope(keyID int64, privilegeID int64) bool
+	Revoke(userID int64, id int64) error
+	AvailableScopes(levelID int64) ([]authModels.JoinedPrivilegesRaw, error)
+	APIKeysAsRowData(keys []*model.APIKeys, scopes []authModels.APIKeyScope) []datadisplay.RowData
+}
+
+// Mailer sends email, see cfg.MailConfig for the implementations
+type Mailer interface {
+	Send(ctx gctx.Context, mail models.Mail) error
+}
+
+// AccountsService handles the emailed links for password resets and email
+// verification. Their tokens are single use and stored hashed.
+type AccountsService interface {
+	RequestPasswordReset(ctx gctx.Context, email string, req *http.Request) error
+	ResetPassword(token string, password string) error
+	SendVerification(ctx gctx.Context, user *model.Users) error
+	ResendVerification(ctx gctx.Context, email string) error
+	Verify(token string) error
+	CheckVerified(user *model.Users) error
+}
+
+// OIDCService signs users in with an OpenID Connect provider, creating
+// accounts for new users
+type OIDCService interface {
+	Enabled() bool
+	Begin(ctx gctx.Context) (string, string, error)
+	Finish(ctx gctx.Context, state string, browserState string, code string) (*model.Users, error)
+}
+
+// SecurityService throttles failed logins and keeps the security events admins
+// review. Its state is in the database so lockouts hold across replicas.
+type SecurityService interface {
+	CheckLogin(email string, req *http.Request) error
+	LoginFailed(email string, userID *int64, req *http.Request)
+	LoginSucceeded(email string, userID int64, req *http.Request)
+	ResetRequested(email string, req *http.Request)
+	Record(kind string, userID *int64, email string, req *http.Request, detail string)
+	Unlock(ctx gctx.Context, kind string, key string) error
+	// RunThrottlePurge deletes stale throttles until ctx is done
+	RunThrottlePurge(ctx gctx.Context)
+	EventsAsRowData(events []*model.SecurityEvents) []datadisplay.RowData
+	ThrottlesAsRowData(throttles []*model.LoginThrottles) []datadisplay.RowData
+}
+
+// AuditService records who changed users and privileges. Changes are attributed
+// to the user, and api key if any, of ctx.
+type AuditService interface {
+	Record(ctx gctx.Context, action string, targetType string, targetID int64, before any, after any)
+	EventsAsRowData(events []authModels.AuditEventJoin) []datadisplay.RowData
+}
+
+// PrivilegesService changes are audited as the user of ctx, see AuditService
 type PrivilegesService interface {
-	CreatePrivilegeAssociation(levelID int64, privID int64) error
-	DeletePrivilegeAssociation(levelID int64, privID int64) error
-	CreateLevel(name string) error
+	CreatePrivilegeAssociation(ctx gctx.Context, levelID int64, privID int64) error
+	DeletePrivilegeAssociation(ctx gctx.Context, levelID int64, privID int64) error
+	CreateLevel(ctx gctx.Context, name string) error
+	// HasPermissionByID is answered from an in-memory copy of the privileges
+	// of every level, see InvalidateCache
 	HasPermissionByID(levelID int64, permissionID int64) bool
-	SetUserPrivilegeLevel(levelID int64, userID int64) error
+	// EffectivePrivileges resolves each level's own privileges, the ones
+	// matching its patterns and those of the levels it inherits from
+	EffectivePrivileges() ([]authModels.EffectivePrivilege, error)
+	InvalidateCache()
+	// ListenForChanges invalidates the cache when another replica changes
+	// privileges until ctx is done
+	ListenForChanges(ctx gctx.Context, dbURL string)
+	SetUserPrivilegeLevel(ctx gctx.Context, levelID int64, userID int64) error
+	SetLevelParent(ctx gctx.Context, levelID int64, parentID *int64) error
+	CreateLevelPattern(ctx gctx.Context, levelID int64, pattern string) error
+	DeleteLevelPattern(ctx gctx.Context, levelID int64, pattern string) error
 	UserPrivilegeLevelJoinAsRowData(upl []authModels.UserPrivilegeLevelJoin, allLevels []*model.PrivilegeLevels) []datadisplay.RowData
 	JoinedPrivilegeLevelAsRowData(jpl []authModels.JoinedPrivilegeLevel) []datadisplay.RowData
-	JoinedPrivilegesAsRowData(jpl []authModels.JoinedPrivilegesRaw) []datadisplay.RowData
+	EffectivePrivilegesAsRowData(eps []authModels.EffectivePrivilege) []datadisplay.RowData
+	LevelsAsRowData(levels []*model.PrivilegeLevels) []datadisplay.RowData
+	PatternsAsRowData(patterns []*model.PrivilegeLevelsPatterns, levels []*model.PrivilegeLevels) []datadisplay.RowData
 }
 
 type LLMService interface {
@@ -77,16 +214,100 @@ type LLMService interface {
 type AppMCPService interface {
 	Server() *server.MCPServer
 	Client() *client.Client
+	CanUseTool(ctx gctx.Context, name string) bool
+	RequireConfirmation(names ...string)
+	RequiresConfirmation(name string) bool
+}
+
+// ToolConfirmer asks the person on the other end of a conversation to approve a
+// tool call. It returns false when declined or when ctx is done first.
+type ToolConfirmer interface {
+	ConfirmTool(ctx gctx.Context, req agentModels.ToolConfirmationRequest) (bool, error)
+}
+
+type ConversationsService interface {
+	Start(ctx gctx.Context, channel string) (int64, error)
+	End(id int64) error
+	Escalate(id int64, reason string, transferredTo string) error
 }
 
+type ToolCallsService interface {
+	Record(ctx gctx.Context, call agentModels.ToolCall) error
+	Redact(args map[string]any) map[string]any
+	Metrics(ctx gctx.Context) []agentModels.ToolMetrics
+	ToolCallsAsRowData(calls []*agentModel.ToolCalls) []datadisplay.RowData
+	ToolMetricsAsRowData(metrics []agentModels.ToolMetrics) []datadisplay.RowData
+}
+
+// Embedder turns texts into vectors for similarity search
+type Embedder interface {
+	// Model names the vector space, vectors from different models are not comparable
+	Model() string
+	Embed(ctx gctx.Context, texts []string) ([][]float32, error)
+}
+
+type KnowledgeBaseService interface {
+	AddDocument(ctx gctx.Context, profileID int64, title string, docType string, body []byte) (*agentModel.KnowledgeDocuments, int, error)
+	Search(ctx gctx.Context, profileID int64, query string, limit int) ([]agentModels.KnowledgeSearchResult, error)
+	DocumentsAsRowData(docs []*agentModel.KnowledgeDocuments) []datadisplay.RowData
+}
+
+type CampaignsService interface {
+	Create(c *agentModel.Campaigns) error
+	SetStatus(orgID int64, id int64, status string) (*agentModel.Campaigns, error)
+	ImportContacts(campaignID int64, r io.Reader) (int, int, error)
+	RecordOutcome(callID string, outcome models.CallOutcome) error
+	// Run dials due contacts of running campaigns until ctx is done
+	Run(ctx gctx.Context)
+	OutcomeCounts(contacts []*agentModel.CampaignContacts) agentModels.CampaignOutcomeCounts
+	ExportCSV(campaignID int64, w io.Writer) error
+	CampaignsAsRowData(campaigns []*agentModel.Campaigns) []datadisplay.RowData
+	ContactsAsRowData(contacts []*agentModel.CampaignContacts) []datadisplay.RowData
+}
+
+// PhoneService acts on the call whose id is in ctx, see WithCallID
 type PhoneService interface {
 	StartCall(ctx gctx.Context) error
 	EndCall(ctx gctx.Context) error
+	TransferCall(ctx gctx.Context, target string, reason string) (models.CallTransfer, error)
+	// HandOff tells the caller they are being transferred, then transfers the
+	// call and records the escalation
+	HandOff(ctx gctx.Context, target string, reason string, message string) (models.CallTransfer, error)
+	TransferTargets() []string
+	// Dial places an outbound call, its outcome is reported to OnOutcome handlers
+	Dial(ctx gctx.Context, call models.OutboundCall) error
+	OnOutcome(handler func(callID string, outcome models.CallOutcome))
+	// RecordOutcome reports an outcome found by the app itself, such as an
+	// answering machine, for the call in ctx
+	RecordOutcome(ctx gctx.Context, outcome models.CallOutcome) error
+	// OnDTMF receives keypad presses the provider reports for a call, until the
+	// returned func is called
+	OnDTMF(callID string, handler func(digit rune)) func()
+	// MenuAction returns the menu action configured for a keypad digit
+	MenuAction(digit rune) (models.KeypadAction, bool)
+}
+
+// PhoneProvider is the telephony vendor behind PhoneService
+type PhoneProvider interface {
+	Name() string
+	StartCall(ctx gctx.Context, callID string) error
+	EndCall(ctx gctx.Context, callID string) error
+	TransferCall(ctx gctx.Context, transfer models.CallTransfer) error
+	Dial(ctx gctx.Context, call models.OutboundCall) error
+	// SetOutcomeHandler receives how calls ended, it may be called from any goroutine
+	SetOutcomeHandler(handler func(callID string, outcome models.CallOutcome))
+	// SetDTMFHandler receives keypad presses sent out of band, such as RFC 2833 events
+	SetDTMFHandler(handler func(callID string, digit rune))
+}
+
+// AgentSpeaker makes the agent of the conversation in ctx say something
+type AgentSpeaker interface {
+	Say(ctx gctx.Context, text string) error
 }
 
 type WebSocketService interface {
 	// StartSocket(conn *websocket.Conn, handler SocketHandler)
-	StartStreamingResponseSocket(conn *websocket.Conn, handler StreamingSocketHandler)
+	StartStreamingResponseSocket(ctx gctx.Context, conn *websocket.Conn, handler StreamingSocketHandler)
 }
 
 type WebSocketHandler interface {
@@ -97,3 +318,9 @@ type StreamingSocketHandler interface {
 	WebSocketHandler
 	HandleRequestWithStreaming(ctx gctx.Context, r models.StreamingReader, w models.StreamingWriter[models.StreamingResponseBody])
 }
+
+// TextMessageHandler is optionally implemented by streaming handlers to receive
+// text frames, such as control messages, separately from the binary stream
+type TextMessageHandler interface {
+	HandleText(ctx gctx.Context, msg []byte)
+}
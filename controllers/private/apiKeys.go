@@ -0,0 +1,147 @@
+package private
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	APIKeysGet   = "APIKeysGet"
+	APIKeysPost  = "APIKeysPost"
+	APIKeyDelete = "APIKeyDelete"
+)
+
+type apiKeys struct {
+	context.AppContext
+}
+
+func NewAPIKeys(ctx context.AppContext) *apiKeys {
+	return &apiKeys{
+		AppContext: ctx,
+	}
+}
+
+func (r apiKeys) Path() string {
+	return "/api-keys"
+}
+
+func (r *apiKeys) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.apiKeysGet).SetPermissionName(APIKeysGet).Build()
+	b.NewHandle().Register(builders.POST, "/", r.apiKeysPost).SetPermissionName(APIKeysPost).Build()
+	b.NewHandle().Register(builders.DELETE, "/{id}", r.apiKeyDelete).SetPermissionName(APIKeyDelete).Build()
+}
+
+func (r *apiKeys) apiKeysGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("apiKeysGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	aks := r.SM().APIKeysService()
+	scopes, err := aks.AvailableScopes(context.GetPrivilegeLevelID(ctx))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching privileges")
+		return
+	}
+	rows, ok := r.keyRows(res, req)
+	if !ok {
+		return
+	}
+	page := pageLayouts.Index(pages.APIKeys(scopes, rows))
+	page.Render(ctx, res)
+}
+
+func (r *apiKeys) apiKeysPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("apiKeysPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	days, err := strconv.ParseInt(req.FormValue("expires_in_days"), 10, 64)
+	if err != nil || days < 0 {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid expiry")
+		return
+	}
+	var privilegeIDs []int64
+	for _, v := range req.Form["scopes"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid scope")
+			return
+		}
+		privilegeIDs = append(privilegeIDs, id)
+	}
+
+	key, _, err := r.SM().APIKeysService().Create(ctx, req.FormValue("name"), time.Duration(days)*24*time.Hour, privilegeIDs)
+	if errors.Is(err, services.ErrInvalidAPIKeyName) || errors.Is(err, services.ErrNoAPIKeyScopes) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if errors.Is(err, services.ErrAPIKeyScopeForbidden) {
+		tools.HandleError(req, res, lgr, err, 403, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating API key")
+		return
+	}
+	rows, ok := r.keyRows(res, req)
+	if !ok {
+		return
+	}
+	pages.APIKeysResults(key, rows).Render(ctx, res)
+	datadisplay.AddTextToast(datadisplay.Success, "API key created", 3).Render(ctx, res)
+}
+
+func (r *apiKeys) apiKeyDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("apiKeyDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid API key")
+		return
+	}
+	err = r.SM().APIKeysService().Revoke(context.GetUserId(ctx), id)
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		tools.HandleError(req, res, lgr, err, 404, "API key not found")
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error revoking API key")
+		return
+	}
+}
+
+// keyRows renders the keys of the user in the session's organization, writing
+// an error when it can't
+func (r *apiKeys) keyRows(res http.ResponseWriter, req *http.Request) ([]datadisplay.RowData, bool) {
+	lgr := r.Lgr("keyRows")
+	ctx := req.Context()
+	keys, err := r.DM().APIKeysDAO().GetByUser(context.GetUserId(ctx), context.GetOrgID(ctx))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching API keys")
+		return nil, false
+	}
+	ids := make([]int64, len(keys))
+	for i, k := range keys {
+		ids[i] = k.ID
+	}
+	scopes, err := r.DM().APIKeysPrivilegesDAO().GetScopes(ids)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching API key scopes")
+		return nil, false
+	}
+	return r.SM().APIKeysService().APIKeysAsRowData(keys, scopes), true
+}
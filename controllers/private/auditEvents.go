@@ -0,0 +1,71 @@
+package private
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+)
+
+const (
+	AuditEventsGet = "AuditEventsGet"
+)
+
+type auditEvents struct {
+	context.AppContext
+}
+
+func NewAuditEvents(ctx context.AppContext) *auditEvents {
+	return &auditEvents{
+		AppContext: ctx,
+	}
+}
+
+func (r auditEvents) Path() string {
+	return "/audit-events"
+}
+
+func (r *auditEvents) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.auditEventsGet).SetPermissionName(AuditEventsGet).Build()
+}
+
+func (r *auditEvents) auditEventsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("auditEventsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	q := req.URL.Query()
+	filter := authModels.AuditEventFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		ActorEmail: strings.ToLower(strings.TrimSpace(q.Get("actor"))),
+	}
+	if target := strings.TrimSpace(q.Get("target_id")); target != "" {
+		id, err := strconv.ParseInt(target, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid target id")
+			return
+		}
+		filter.TargetID = &id
+	}
+	events, err := r.DM().AuditEventsDAO().Search(filter)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching audit events")
+		return
+	}
+	rows := r.SM().AuditService().EventsAsRowData(events)
+
+	// the filter form only swaps the results
+	if req.Header.Get("HX-Target") == pages.AuditEventsResultsID {
+		pages.AuditEventsTable(rows).Render(ctx, res)
+		return
+	}
+	page := pageLayouts.Index(pages.AuditEvents(filter, rows))
+	page.Render(ctx, res)
+}
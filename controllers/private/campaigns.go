@@ -0,0 +1,247 @@
+package private
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	CampaignsGet         = "CampaignsGet"
+	CampaignsPost        = "CampaignsPost"
+	CampaignGet          = "CampaignGet"
+	CampaignContactsPost = "CampaignContactsPost"
+	CampaignStatusPut    = "CampaignStatusPut"
+	CampaignExportGet    = "CampaignExportGet"
+)
+
+const maxContactsUpload = 5 << 20
+
+type campaigns struct {
+	context.AppContext
+}
+
+func NewCampaigns(ctx context.AppContext) *campaigns {
+	return &campaigns{
+		AppContext: ctx,
+	}
+}
+
+func (r campaigns) Path() string {
+	return "/campaigns"
+}
+
+func (r *campaigns) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.campaignsGet).SetPermissionName(CampaignsGet).Build()
+	b.NewHandle().Register(builders.POST, "/", r.campaignsPost).SetPermissionName(CampaignsPost).Build()
+	b.NewHandle().Register(builders.GET, "/{id}", r.campaignGet).SetPermissionName(CampaignGet).Build()
+	b.NewHandle().Register(builders.POST, "/{id}/contacts", r.campaignContactsPost).SetPermissionName(CampaignContactsPost).Build()
+	b.NewHandle().Register(builders.PUT, "/{id}/status", r.campaignStatusPut).SetPermissionName(CampaignStatusPut).Build()
+	b.NewHandle().Register(builders.GET, "/{id}/export.csv", r.campaignExportGet).SetPermissionName(CampaignExportGet).Build()
+}
+
+func (r *campaigns) campaignsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+	orgID := context.GetOrgID(ctx)
+
+	rows, err := r.DM().CampaignsDAO().IndexInOrg(orgID, nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching campaigns")
+		return
+	}
+	profiles, err := r.DM().ProfilesDAO().IndexInOrg(orgID, nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching agent profiles")
+		return
+	}
+	page := pageLayouts.Index(pages.Campaigns(profiles, r.SM().CampaignsService().CampaignsAsRowData(rows)))
+	page.Render(ctx, res)
+}
+
+func (r *campaigns) campaignsPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignsPost")
+	lgr.Info("Called")
+	orgID := context.GetOrgID(req.Context())
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	profileID, err := strconv.ParseInt(req.FormValue("profile_id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid profile")
+		return
+	}
+	if _, err := r.DM().ProfilesDAO().GetOneInOrg(orgID, profileID, r.DB()); err != nil {
+		tools.HandleError(req, res, lgr, err, 404, "Profile not found")
+		return
+	}
+	windowStart, err := services.ParseMinutes(req.FormValue("window_start"))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid window start")
+		return
+	}
+	windowEnd, err := services.ParseMinutes(req.FormValue("window_end"))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid window end")
+		return
+	}
+	var limits [3]int32
+	for i, name := range []string{"max_concurrent", "max_attempts", "retry_delay_minutes"} {
+		v, err := strconv.ParseInt(req.FormValue(name), 10, 32)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid "+strings.ReplaceAll(name, "_", " "))
+			return
+		}
+		limits[i] = int32(v)
+	}
+
+	campaign := model.Campaigns{
+		OrganizationID:    orgID,
+		Name:              req.FormValue("name"),
+		ProfileID:         profileID,
+		WindowStart:       windowStart,
+		WindowEnd:         windowEnd,
+		Timezone:          strings.TrimSpace(req.FormValue("timezone")),
+		MaxConcurrent:     limits[0],
+		MaxAttempts:       limits[1],
+		RetryDelayMinutes: limits[2],
+		RetryVoicemail:    req.FormValue("retry_voicemail") == "on",
+	}
+	err = r.SM().CampaignsService().Create(&campaign)
+	if errors.Is(err, services.ErrInvalidCampaign) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating campaign")
+		return
+	}
+	res.Header().Set("Hx-Redirect", fmt.Sprintf("/campaigns/%d", campaign.ID))
+}
+
+func (r *campaigns) campaignGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	campaign, ok := r.campaign(res, req)
+	if !ok {
+		return
+	}
+	contacts, err := r.DM().CampaignContactsDAO().GetByCampaign(campaign.ID)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching contacts")
+		return
+	}
+	cs := r.SM().CampaignsService()
+	page := pageLayouts.Index(pages.Campaign(campaign, cs.OutcomeCounts(contacts), cs.ContactsAsRowData(contacts)))
+	page.Render(ctx, res)
+}
+
+func (r *campaigns) campaignContactsPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignContactsPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	campaign, ok := r.campaign(res, req)
+	if !ok {
+		return
+	}
+	req.Body = http.MaxBytesReader(res, req.Body, maxContactsUpload)
+	if err := req.ParseMultipartForm(maxContactsUpload); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing upload")
+		return
+	}
+	file, _, err := req.FormFile("contacts")
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Missing contacts file")
+		return
+	}
+	defer file.Close()
+
+	cs := r.SM().CampaignsService()
+	imported, skipped, err := cs.ImportContacts(campaign.ID, file)
+	if errors.Is(err, services.ErrNoPhoneColumn) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error importing contacts")
+		return
+	}
+	contacts, err := r.DM().CampaignContactsDAO().GetByCampaign(campaign.ID)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching contacts")
+		return
+	}
+	pages.CampaignResults(campaign, cs.OutcomeCounts(contacts), cs.ContactsAsRowData(contacts)).Render(ctx, res)
+	datadisplay.AddTextToast(datadisplay.Success, fmt.Sprintf("Imported %d contacts, skipped %d invalid", imported, skipped), 5).Render(ctx, res)
+}
+
+func (r *campaigns) campaignStatusPut(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignStatusPut")
+	lgr.Info("Called")
+
+	campaign, ok := r.campaign(res, req)
+	if !ok {
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	_, err := r.SM().CampaignsService().SetStatus(campaign.OrganizationID, campaign.ID, req.FormValue("status"))
+	if errors.Is(err, services.ErrInvalidStatusChange) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error updating campaign")
+		return
+	}
+	res.Header().Set("Hx-Redirect", fmt.Sprintf("/campaigns/%d", campaign.ID))
+}
+
+func (r *campaigns) campaignExportGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("campaignExportGet")
+	lgr.Info("Called")
+
+	campaign, ok := r.campaign(res, req)
+	if !ok {
+		return
+	}
+	res.Header().Set("Content-Type", "text/csv")
+	res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"campaign-%d.csv\"", campaign.ID))
+	if err := r.SM().CampaignsService().ExportCSV(campaign.ID, res); err != nil {
+		lgr.Error("failed to export campaign")
+	}
+}
+
+// campaign fetches the campaign in the url from the session's organization,
+// writing an error when it can't
+func (r *campaigns) campaign(res http.ResponseWriter, req *http.Request) (*model.Campaigns, bool) {
+	lgr := r.Lgr("campaign")
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid campaign")
+		return nil, false
+	}
+	campaign, err := r.DM().CampaignsDAO().GetOneInOrg(context.GetOrgID(req.Context()), id, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 404, "Campaign not found")
+		return nil, false
+	}
+	return campaign, true
+}
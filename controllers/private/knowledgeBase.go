@@ -0,0 +1,195 @@
+package private
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+)
+
+const (
+	KnowledgeBaseGet           = "KnowledgeBaseGet"
+	KnowledgeBaseProfilesPost  = "KnowledgeBaseProfilesPost"
+	KnowledgeBaseDocumentsPost = "KnowledgeBaseDocumentsPost"
+)
+
+const maxKnowledgeUpload = 10 << 20
+
+type knowledgeBase struct {
+	context.AppContext
+}
+
+func NewKnowledgeBase(ctx context.AppContext) *knowledgeBase {
+	return &knowledgeBase{
+		AppContext: ctx,
+	}
+}
+
+func (r knowledgeBase) Path() string {
+	return "/knowledge_base"
+}
+
+func (r *knowledgeBase) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.knowledgeBaseGet).SetPermissionName(KnowledgeBaseGet).Build()
+	b.NewHandle().Register(builders.POST, "/profiles", r.knowledgeBaseProfilesPost).SetPermissionName(KnowledgeBaseProfilesPost).Build()
+	b.NewHandle().Register(builders.POST, "/documents", r.knowledgeBaseDocumentsPost).SetPermissionName(KnowledgeBaseDocumentsPost).Build()
+}
+
+func (r *knowledgeBase) knowledgeBaseGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("knowledgeBaseGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+	orgID := context.GetOrgID(ctx)
+
+	profiles, err := r.DM().ProfilesDAO().IndexInOrg(orgID, nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching agent profiles")
+		return
+	}
+	var selected *model.Profiles
+	if len(profiles) > 0 {
+		selected = profiles[0]
+	}
+	if v := req.URL.Query().Get("profile_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid profile")
+			return
+		}
+		for _, p := range profiles {
+			if p.ID == id {
+				selected = p
+			}
+		}
+	}
+
+	var rows []datadisplay.RowData
+	if selected != nil {
+		docs, err := r.DM().KnowledgeDocumentsDAO().GetByProfile(orgID, selected.ID)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 500, "Error fetching documents")
+			return
+		}
+		rows = r.SM().KnowledgeBaseService().DocumentsAsRowData(docs)
+	}
+	page := pageLayouts.Index(pages.KnowledgeBase(profiles, selected, rows))
+	page.Render(ctx, res)
+}
+
+func (r *knowledgeBase) knowledgeBaseProfilesPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("knowledgeBaseProfilesPost")
+	lgr.Info("Called")
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	name := strings.TrimSpace(req.FormValue("name"))
+	if name == "" {
+		tools.HandleError(req, res, lgr, nil, 400, "Profile name is required")
+		return
+	}
+	voicemailAction := req.FormValue("voicemail_action")
+	switch voicemailAction {
+	case "":
+		voicemailAction = models.VoicemailLeaveMessage
+	case models.VoicemailLeaveMessage, models.VoicemailHangUp:
+	default:
+		tools.HandleError(req, res, lgr, nil, 400, "Invalid voicemail action")
+		return
+	}
+	profile := model.Profiles{
+		OrganizationID:  context.GetOrgID(req.Context()),
+		Name:            name,
+		Prompt:          req.FormValue("prompt"),
+		VoicemailScript: strings.TrimSpace(req.FormValue("voicemail_script")),
+		VoicemailAction: voicemailAction,
+	}
+	if err := r.DM().ProfilesDAO().Insert(&profile, r.DB()); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error creating profile")
+		return
+	}
+	res.Header().Set("Hx-Redirect", fmt.Sprintf("/knowledge_base?profile_id=%d", profile.ID))
+}
+
+func (r *knowledgeBase) knowledgeBaseDocumentsPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("knowledgeBaseDocumentsPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+	orgID := context.GetOrgID(ctx)
+
+	req.Body = http.MaxBytesReader(res, req.Body, maxKnowledgeUpload)
+	if err := req.ParseMultipartForm(maxKnowledgeUpload); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing upload")
+		return
+	}
+	profileID, err := strconv.ParseInt(req.FormValue("profile_id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid profile")
+		return
+	}
+	if _, err := r.DM().ProfilesDAO().GetOneInOrg(orgID, profileID, r.DB()); err != nil {
+		tools.HandleError(req, res, lgr, err, 404, "Profile not found")
+		return
+	}
+	file, header, err := req.FormFile("document")
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Missing document")
+		return
+	}
+	defer file.Close()
+	docType, err := services.DocumentType(header.Filename, header.Header.Get("Content-Type"))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	}
+	body, err := io.ReadAll(file)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error reading document")
+		return
+	}
+	title := strings.TrimSpace(req.FormValue("title"))
+	if title == "" && docType != services.DocumentHTML {
+		title = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+
+	kb := r.SM().KnowledgeBaseService()
+	doc, chunks, err := kb.AddDocument(ctx, profileID, title, docType, body)
+	if errors.Is(err, services.ErrEmptyDocument) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error adding document")
+		return
+	}
+
+	if wantsJSON(req) {
+		res.Header().Set("Content-Type", "application/json")
+		out := map[string]any{"id": doc.ID, "title": doc.Title, "content_type": doc.ContentType, "chunks": chunks}
+		if err := json.NewEncoder(res).Encode(out); err != nil {
+			lgr.Warn("failed to write document")
+		}
+		return
+	}
+	docs, err := r.DM().KnowledgeDocumentsDAO().GetByProfile(orgID, profileID)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching documents")
+		return
+	}
+	pages.KnowledgeDocuments(kb.DocumentsAsRowData(docs)).Render(ctx, res)
+	datadisplay.AddTextToast(datadisplay.Success, fmt.Sprintf("Added %s in %d chunks", doc.Title, chunks), 5).Render(ctx, res)
+}
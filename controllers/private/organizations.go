@@ -0,0 +1,255 @@
+package private
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+const (
+	OrganizationsGet         = "OrganizationsGet"
+	OrganizationsPost        = "OrganizationsPost"
+	OrganizationsSwitchPut   = "OrganizationsSwitchPut"
+	OrganizationMembersPost  = "OrganizationMembersPost"
+	OrganizationMemberPut    = "OrganizationMemberPut"
+	OrganizationMemberDelete = "OrganizationMemberDelete"
+	PhoneNumbersPost         = "PhoneNumbersPost"
+	PhoneNumberDelete        = "PhoneNumberDelete"
+)
+
+type organizations struct {
+	context.AppContext
+}
+
+func NewOrganizations(ctx context.AppContext) *organizations {
+	return &organizations{
+		AppContext: ctx,
+	}
+}
+
+func (r organizations) Path() string {
+	return "/organizations"
+}
+
+func (r *organizations) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.organizationsGet).SetPermissionName(OrganizationsGet).Build()
+	b.NewHandle().Register(builders.POST, "/", r.organizationsPost).SetPermissionName(OrganizationsPost).Build()
+	b.NewHandle().Register(builders.PUT, "/switch", r.organizationsSwitchPut).SetPermissionName(OrganizationsSwitchPut).Build()
+	b.NewHandle().Register(builders.POST, "/members", r.organizationMembersPost).SetPermissionName(OrganizationMembersPost).Build()
+	b.NewHandle().Register(builders.PUT, "/members/{userID}", r.organizationMemberPut).SetPermissionName(OrganizationMemberPut).Build()
+	b.NewHandle().Register(builders.DELETE, "/members/{userID}", r.organizationMemberDelete).SetPermissionName(OrganizationMemberDelete).Build()
+	b.NewHandle().Register(builders.POST, "/phone-numbers", r.phoneNumbersPost).SetPermissionName(PhoneNumbersPost).Build()
+	b.NewHandle().Register(builders.DELETE, "/phone-numbers/{id}", r.phoneNumberDelete).SetPermissionName(PhoneNumberDelete).Build()
+}
+
+func (r *organizations) organizationsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+	orgID := context.GetOrgID(ctx)
+	canManage := authModels.CanManageOrg(context.GetOrgRole(ctx))
+
+	orgs, err := r.DM().OrganizationsDAO().GetByUser(context.GetUserId(ctx))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching organizations")
+		return
+	}
+	members, err := r.DM().OrganizationMembersDAO().GetByOrganization(orgID)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching members")
+		return
+	}
+	profiles, err := r.DM().ProfilesDAO().IndexInOrg(orgID, nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching agent profiles")
+		return
+	}
+	numbers, err := r.DM().PhoneNumbersDAO().IndexInOrg(orgID, nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching phone numbers")
+		return
+	}
+
+	orgService := r.SM().OrganizationsService()
+	memberRows := orgService.MembersAsRowData(members, canManage)
+	numberRows := orgService.PhoneNumbersAsRowData(numbers, profiles, canManage)
+	page := pageLayouts.Index(pages.Organizations(orgs, orgID, canManage, memberRows, profiles, numberRows))
+	page.Render(ctx, res)
+}
+
+func (r *organizations) organizationsPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationsPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	tx, err := r.DB().Begin()
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating organization")
+		return
+	}
+	defer tx.Rollback()
+	orgService := r.SM().OrganizationsService()
+	org, err := orgService.Create(req.FormValue("name"), context.GetUserId(ctx), tx)
+	if errors.Is(err, services.ErrInvalidOrgName) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating organization")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating organization")
+		return
+	}
+	if err := orgService.Switch(ctx, org.ID); err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error switching organization")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/organizations")
+}
+
+func (r *organizations) organizationsSwitchPut(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationsSwitchPut")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	orgID, err := strconv.ParseInt(req.FormValue("organization_id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid organization")
+		return
+	}
+	if err := r.SM().OrganizationsService().Switch(ctx, orgID); err != nil {
+		r.handleOrgError(res, req, err, "Error switching organization")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/organizations")
+}
+
+func (r *organizations) organizationMembersPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationMembersPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	if err := r.SM().OrganizationsService().AddMember(ctx, req.FormValue("email"), req.FormValue("role")); err != nil {
+		r.handleOrgError(res, req, err, "Error adding member")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/organizations")
+}
+
+func (r *organizations) organizationMemberPut(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationMemberPut")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	userID, err := strconv.ParseInt(chi.URLParam(req, "userID"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid user")
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	if err := r.SM().OrganizationsService().SetMemberRole(ctx, userID, req.FormValue("role")); err != nil {
+		r.handleOrgError(res, req, err, "Error changing role")
+		return
+	}
+	datadisplay.AddTextToast(datadisplay.Success, "Role updated", 3).Render(ctx, res)
+}
+
+func (r *organizations) organizationMemberDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("organizationMemberDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	userID, err := strconv.ParseInt(chi.URLParam(req, "userID"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid user")
+		return
+	}
+	if err := r.SM().OrganizationsService().RemoveMember(ctx, userID); err != nil {
+		r.handleOrgError(res, req, err, "Error removing member")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/organizations")
+}
+
+func (r *organizations) phoneNumbersPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("phoneNumbersPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	var profileID *int64
+	if v := req.FormValue("profile_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid profile")
+			return
+		}
+		profileID = &id
+	}
+	if _, err := r.SM().OrganizationsService().AddPhoneNumber(ctx, req.FormValue("number"), profileID); err != nil {
+		r.handleOrgError(res, req, err, "Error adding phone number")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/organizations")
+}
+
+func (r *organizations) phoneNumberDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("phoneNumberDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid phone number")
+		return
+	}
+	if err := r.SM().OrganizationsService().RemovePhoneNumber(ctx, id); err != nil {
+		r.handleOrgError(res, req, err, "Error removing phone number")
+		return
+	}
+}
+
+// handleOrgError writes the status matching an organizations service error
+func (r *organizations) handleOrgError(res http.ResponseWriter, req *http.Request, err error, msg string) {
+	lgr := r.Lgr("handleOrgError")
+	switch {
+	case errors.Is(err, services.ErrOrgForbidden), errors.Is(err, services.ErrNotOrgMember):
+		tools.HandleError(req, res, lgr, err, 403, err.Error())
+	case errors.Is(err, services.ErrInvalidOrgRole), errors.Is(err, services.ErrLastOwner), errors.Is(err, services.ErrAlreadyOrgMember), errors.Is(err, services.ErrInvalidPhoneNumber):
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+	case errors.Is(err, qrm.ErrNoRows):
+		tools.HandleError(req, res, lgr, err, 404, "Not found")
+	default:
+		tools.HandleError(req, res, lgr, err, 500, msg)
+	}
+}
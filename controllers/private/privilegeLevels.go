@@ -1,20 +1,27 @@
 package private
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/carsonkrueger/main/builders"
 	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
 	"github.com/carsonkrueger/main/templates/datadisplay"
 	"github.com/carsonkrueger/main/templates/datainput"
+	"github.com/carsonkrueger/main/templates/pages"
 	"github.com/carsonkrueger/main/tools"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-jet/jet/v2/qrm"
 )
 
 const (
 	PrivilegeLevelsSelectGet = "PrivilegeLevelsSelectGet"
 	SetUserLevelPut          = "SetUserLevelPut"
+	SetLevelParentPut        = "SetLevelParentPut"
+	LevelPatternPost         = "LevelPatternPost"
+	LevelPatternDelete       = "LevelPatternDelete"
 )
 
 type privilegeLevels struct {
@@ -34,6 +41,9 @@ func (um privilegeLevels) Path() string {
 func (um *privilegeLevels) PrivateRoute(b *builders.PrivateRouteBuilder) {
 	b.NewHandle().Register(builders.GET, "/select", um.privilegeLevelsSelectGet).SetPermissionName(PrivilegeLevelsSelectGet).Build()
 	b.NewHandle().Register(builders.PUT, "/user/{user}", um.setUserLevelPut).SetPermissionName(SetUserLevelPut).Build()
+	b.NewHandle().Register(builders.PUT, "/{level}/parent", um.setLevelParentPut).SetPermissionName(SetLevelParentPut).Build()
+	b.NewHandle().Register(builders.POST, "/patterns", um.levelPatternPost).SetPermissionName(LevelPatternPost).Build()
+	b.NewHandle().Register(builders.DELETE, "/{level}/patterns", um.levelPatternDelete).SetPermissionName(LevelPatternDelete).Build()
 }
 
 func (r *privilegeLevels) privilegeLevelsSelectGet(res http.ResponseWriter, req *http.Request) {
@@ -85,7 +95,7 @@ func (r *privilegeLevels) setUserLevelPut(res http.ResponseWriter, req *http.Req
 		return
 	}
 
-	if err := r.SM().PrivilegesService().SetUserPrivilegeLevel(levelID, userIDInt); err != nil {
+	if err := r.SM().PrivilegesService().SetUserPrivilegeLevel(ctx, levelID, userIDInt); err != nil {
 		tools.HandleError(req, res, lgr, err, 500, "Error setting user privilege level")
 		return
 	}
@@ -94,3 +104,100 @@ func (r *privilegeLevels) setUserLevelPut(res http.ResponseWriter, req *http.Req
 		datadisplay.AddTextToast(datadisplay.Success, "User Level Updated", 3).Render(ctx, res)
 	}
 }
+
+func (r *privilegeLevels) setLevelParentPut(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("setLevelParentPut")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	levelID, err := strconv.ParseInt(chi.URLParam(req, "level"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid privilege level id")
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid form data")
+		return
+	}
+	var parentID *int64
+	if v := req.Form.Get("parent"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Invalid parent level id")
+			return
+		}
+		parentID = &id
+	}
+
+	err = r.SM().PrivilegesService().SetLevelParent(ctx, levelID, parentID)
+	if errors.Is(err, services.ErrLevelCycle) {
+		tools.HandleError(req, res, lgr, err, 400, "A level can't inherit from itself or its descendants")
+		return
+	} else if errors.Is(err, qrm.ErrNoRows) {
+		tools.HandleError(req, res, lgr, err, 404, "Privilege level not found")
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error setting parent level")
+		return
+	}
+
+	if tools.IsHxRequest(req) {
+		res.Header().Set("HX-Trigger", pages.PrivilegesChangedEvent)
+		datadisplay.AddTextToast(datadisplay.Success, "Parent Level Updated", 3).Render(ctx, res)
+	}
+}
+
+func (r *privilegeLevels) levelPatternPost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("levelPatternPost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid form data")
+		return
+	}
+	levelID, err := strconv.ParseInt(req.Form.Get("privilege-levels"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid privilege level id")
+		return
+	}
+	if _, err := r.DM().PrivilegeLevelsDAO().GetOne(levelID, r.DB()); err != nil {
+		tools.HandleError(req, res, lgr, err, 404, "Privilege level not found")
+		return
+	}
+
+	err = r.SM().PrivilegesService().CreateLevelPattern(ctx, levelID, req.Form.Get("pattern"))
+	if errors.Is(err, services.ErrInvalidPattern) {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid pattern")
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error adding pattern")
+		return
+	}
+
+	if tools.IsHxRequest(req) {
+		res.Header().Set("HX-Trigger", pages.PrivilegesChangedEvent)
+		datadisplay.AddTextToast(datadisplay.Success, "Added pattern", 3).Render(ctx, res)
+	}
+}
+
+func (r *privilegeLevels) levelPatternDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("levelPatternDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	levelID, err := strconv.ParseInt(chi.URLParam(req, "level"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid privilege level id")
+		return
+	}
+	if err := r.SM().PrivilegesService().DeleteLevelPattern(ctx, levelID, req.URL.Query().Get("pattern")); err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error removing pattern")
+		return
+	}
+
+	if tools.IsHxRequest(req) {
+		res.Header().Set("HX-Trigger", pages.PrivilegesChangedEvent)
+		datadisplay.AddTextToast(datadisplay.Success, "Removed pattern", 3).Render(ctx, res)
+	}
+}
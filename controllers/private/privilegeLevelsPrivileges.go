@@ -4,11 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/a-h/templ"
 	"github.com/carsonkrueger/main/builders"
 	"github.com/carsonkrueger/main/context"
-	"github.com/carsonkrueger/main/models/authModels"
 	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pages"
 	"github.com/carsonkrueger/main/tools"
 	"github.com/go-chi/chi/v5"
 )
@@ -78,25 +77,14 @@ func (r *privilegeLevelsPrivileges) privilegeLevelsPrivilegesPost(res http.Respo
 		return
 	}
 
-	if err = r.SM().PrivilegesService().CreatePrivilegeAssociation(levelInt, priv.ID); err != nil {
+	if err = r.SM().PrivilegesService().CreatePrivilegeAssociation(ctx, levelInt, priv.ID); err != nil {
 		tools.HandleError(req, res, lgr, err, 500, "Failed to add permission")
 		return
 	}
 
 	if tools.IsHxRequest(req) {
-		jpl := []authModels.JoinedPrivilegesRaw{
-			{
-				LevelID:            levelInt,
-				LevelName:          lvl.Name,
-				PrivilegeID:        priv.ID,
-				PrivilegeName:      priv.Name,
-				PrivilegeCreatedAt: priv.CreatedAt,
-			},
-		}
-		rows := r.SM().PrivilegesService().JoinedPrivilegesAsRowData(jpl)
-		tr := datadisplay.BasicTR(rows[0])
-		toast := datadisplay.AddTextToast(datadisplay.Success, "Added privilege level", 3)
-		templ.Join(tr, toast).Render(ctx, res)
+		res.Header().Set("HX-Trigger", pages.PrivilegesChangedEvent)
+		datadisplay.AddTextToast(datadisplay.Success, "Added privilege level", 3).Render(ctx, res)
 	}
 }
 
@@ -119,12 +107,13 @@ func (r *privilegeLevelsPrivileges) privilegeLevelsPrivilegesDelete(res http.Res
 		return
 	}
 
-	if err := r.SM().PrivilegesService().DeletePrivilegeAssociation(levelInt, privilegeInt); err != nil {
+	if err := r.SM().PrivilegesService().DeletePrivilegeAssociation(ctx, levelInt, privilegeInt); err != nil {
 		tools.HandleError(req, res, lgr, err, 500, "Failed to remove permission")
 		return
 	}
 
 	if tools.IsHxRequest(req) {
+		res.Header().Set("HX-Trigger", pages.PrivilegesChangedEvent)
 		datadisplay.AddTextToast(datadisplay.Success, "Deleted privilege level", 3).Render(ctx, res)
 	}
 }
@@ -0,0 +1,88 @@
+package private
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+)
+
+const (
+	SecurityEventsGet = "SecurityEventsGet"
+	LockoutDelete     = "LockoutDelete"
+)
+
+type securityEvents struct {
+	context.AppContext
+}
+
+func NewSecurityEvents(ctx context.AppContext) *securityEvents {
+	return &securityEvents{
+		AppContext: ctx,
+	}
+}
+
+func (r securityEvents) Path() string {
+	return "/security-events"
+}
+
+func (r *securityEvents) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.securityEventsGet).SetPermissionName(SecurityEventsGet).Build()
+	b.NewHandle().Register(builders.DELETE, "/lockouts", r.lockoutDelete).SetPermissionName(LockoutDelete).Build()
+}
+
+func (r *securityEvents) securityEventsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("securityEventsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	q := req.URL.Query()
+	filter := authModels.SecurityEventFilter{
+		Kind:  q.Get("kind"),
+		Email: strings.ToLower(strings.TrimSpace(q.Get("email"))),
+	}
+	events, err := r.DM().SecurityEventsDAO().Search(filter)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching security events")
+		return
+	}
+	security := r.SM().SecurityService()
+	rows := security.EventsAsRowData(events)
+
+	// the filter form only swaps the results
+	if req.Header.Get("HX-Target") == pages.SecurityEventsResultsID {
+		pages.SecurityEventsTable(rows).Render(ctx, res)
+		return
+	}
+	locked, err := r.DM().LoginThrottlesDAO().GetLocked(time.Now())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching lockouts")
+		return
+	}
+	page := pageLayouts.Index(pages.SecurityEvents(filter, security.ThrottlesAsRowData(locked), rows))
+	page.Render(ctx, res)
+}
+
+func (r *securityEvents) lockoutDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("lockoutDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	q := req.URL.Query()
+	err := r.SM().SecurityService().Unlock(ctx, q.Get("kind"), q.Get("key"))
+	if errors.Is(err, services.ErrUnknownThrottle) {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid lockout")
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error unlocking")
+		return
+	}
+}
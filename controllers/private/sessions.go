@@ -0,0 +1,89 @@
+package private
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	SessionsGet       = "SessionsGet"
+	SessionDelete     = "SessionDelete"
+	SessionsDeleteAll = "SessionsDeleteAll"
+)
+
+type sessions struct {
+	context.AppContext
+}
+
+func NewSessions(ctx context.AppContext) *sessions {
+	return &sessions{
+		AppContext: ctx,
+	}
+}
+
+func (r sessions) Path() string {
+	return "/sessions"
+}
+
+func (r *sessions) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.sessionsGet).SetPermissionName(SessionsGet).Build()
+	b.NewHandle().Register(builders.DELETE, "/", r.sessionsDeleteAll).SetPermissionName(SessionsDeleteAll).Build()
+	b.NewHandle().Register(builders.DELETE, "/{id}", r.sessionDelete).SetPermissionName(SessionDelete).Build()
+}
+
+func (r *sessions) sessionsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("sessionsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	us := r.SM().UsersService()
+	active, err := us.ActiveSessions(context.GetUserId(ctx))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching sessions")
+		return
+	}
+	page := pageLayouts.Index(pages.Sessions(us.SessionsAsRowData(active, context.GetToken(ctx))))
+	page.Render(ctx, res)
+}
+
+func (r *sessions) sessionDelete(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("sessionDelete")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid session")
+		return
+	}
+	err = r.SM().UsersService().RevokeSession(ctx, context.GetUserId(ctx), id)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		tools.HandleError(req, res, lgr, err, 404, "Session not found")
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error revoking session")
+		return
+	}
+}
+
+// sessionsDeleteAll signs out everywhere, the current session included
+func (r *sessions) sessionsDeleteAll(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("sessionsDeleteAll")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := r.SM().UsersService().RevokeAllSessions(ctx, context.GetUserId(ctx)); err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error signing out")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/login")
+}
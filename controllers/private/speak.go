@@ -9,6 +9,7 @@ import (
 
 	"github.com/carsonkrueger/main/builders"
 	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
 	"github.com/carsonkrueger/main/services"
 	"github.com/carsonkrueger/main/templates/pageLayouts"
 	"github.com/carsonkrueger/main/templates/pages"
@@ -16,6 +17,7 @@ import (
 	"github.com/deepgram/deepgram-go-sdk/v3/pkg/client/agent"
 	"github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces"
 	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 const (
@@ -31,6 +33,8 @@ type speak struct {
 	deepgramKey string
 	// in memory cache for agent settings
 	settings map[string]*interfaces.SettingsOptions
+	// agent profile chosen by each user, scopes the knowledge base
+	profiles map[string]int64
 }
 
 func NewSpeak(ctx context.AppContext, deepgramKey string) *speak {
@@ -38,6 +42,7 @@ func NewSpeak(ctx context.AppContext, deepgramKey string) *speak {
 		AppContext:  ctx,
 		deepgramKey: deepgramKey,
 		settings:    make(map[string]*interfaces.SettingsOptions),
+		profiles:    make(map[string]int64),
 	}
 }
 
@@ -55,7 +60,13 @@ func (r *speak) speakGet(res http.ResponseWriter, req *http.Request) {
 	lgr := r.Lgr("speakGet")
 	lgr.Info("Called")
 	ctx := req.Context()
-	page := pageLayouts.Index(pages.Speak(r.GetOptions(ctx)))
+	profiles, err := r.DM().ProfilesDAO().IndexInOrg(context.GetOrgID(ctx), nil, r.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching agent profiles")
+		return
+	}
+	profileID, _ := r.GetProfileID(ctx)
+	page := pageLayouts.Index(pages.Speak(r.GetOptions(ctx), profiles, profileID))
 	page.Render(ctx, res)
 }
 
@@ -77,6 +88,12 @@ func (r *speak) speakWebSocket(res http.ResponseWriter, req *http.Request) {
 	}
 	defer conn.Close()
 
+	conversations := r.SM().ConversationsService()
+	if conversationID, err := conversations.Start(ctx, "web"); err == nil {
+		ctx = context.WithConversationID(ctx, conversationID)
+		defer conversations.End(conversationID)
+	}
+
 	clientOptions := interfaces.ClientOptions{
 		EnableKeepAlive: true,
 	}
@@ -87,12 +104,60 @@ func (r *speak) speakWebSocket(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 	defer logFile.Close()
-	handler := services.NewDeepgramHandler(logFile)
+	handler := services.NewDeepgramHandler(logFile, r.SM().MCPService().Server())
 
-	tOptions := r.GetOptions(ctx)
+	// copy so per call changes are not saved as the user's own options
+	tOptions := *r.GetOptions(ctx)
+	var answer *models.AnswerOptions
+	if req.URL.Query().Get("outbound") == "true" {
+		// an outbound call answered in the browser, the greeting waits for
+		// answering machine detection
+		answer = &models.AnswerOptions{Greeting: tOptions.Agent.Greeting}
+		tOptions.Agent.Greeting = ""
+		callID := req.URL.Query().Get("call_id")
+		if callID == "" {
+			token, err := tools.GenerateToken(8)
+			if err != nil {
+				tools.HandleError(req, res, lgr, err, 500, "Error creating call")
+				return
+			}
+			callID = "web-" + token
+		}
+		ctx = context.WithCallID(ctx, callID)
+	}
+	if profileID, ok := r.GetProfileID(ctx); ok {
+		profile, err := r.DM().ProfilesDAO().GetOneInOrg(context.GetOrgID(ctx), profileID, r.DB())
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 500, "Error fetching agent profile")
+			return
+		}
+		ctx = context.WithAgentProfileID(ctx, profile.ID)
+		if profile.Prompt != "" {
+			tOptions.Agent.Think.Prompt = profile.Prompt
+		}
+		if answer != nil {
+			answer.VoicemailScript = profile.VoicemailScript
+			answer.VoicemailAction = profile.VoicemailAction
+		}
+	}
+	// only the tools this session may use, the rest are filtered out of the list
+	listed, err := r.SM().MCPService().Client().ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error listing agent tools")
+		return
+	}
+	functions := services.AgentFunctions(listed.Tools)
+	tOptions.Agent.Think.Functions = &functions
 	fmt.Printf("----%v\n", tOptions)
-	voiceHandler, err := services.NewVoiceV2(ctx, r.AppContext, r.deepgramKey, &clientOptions, tOptions, handler)
-	r.SM().WebSocketService().StartStreamingResponseSocket(conn, voiceHandler)
+	voiceHandler, err := services.NewVoiceV2(ctx, r.AppContext, r.deepgramKey, &clientOptions, &tOptions, handler)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error connecting to the agent")
+		return
+	}
+	if answer != nil {
+		voiceHandler.DetectAnsweringMachine(*answer)
+	}
+	r.SM().WebSocketService().StartStreamingResponseSocket(ctx, conn, voiceHandler)
 
 	lgr.Info("Leaving...")
 }
@@ -127,6 +192,20 @@ func (r *speak) speakOptionsPost(res http.ResponseWriter, req *http.Request) {
 	fmt.Printf("%v\n", opts.Agent.Speak.Provider["model"])
 	opts.Agent.Greeting = req.FormValue("greeting")
 	fmt.Printf("%v\n", opts.Agent.Greeting)
+	if v := req.FormValue("agent-profile"); v != "" {
+		profileID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			tools.HandleError(req, res, lgr, err, 400, "Error parsing agent profile")
+			return
+		}
+		if _, err := r.DM().ProfilesDAO().GetOneInOrg(context.GetOrgID(ctx), profileID, r.DB()); err != nil {
+			tools.HandleError(req, res, lgr, err, 404, "Agent profile not found")
+			return
+		}
+		r.SetProfileID(ctx, profileID)
+	} else {
+		r.SetProfileID(ctx, 0)
+	}
 
 	r.SetOptions(ctx, opts)
 }
@@ -160,3 +239,24 @@ func (r *speak) SetOptions(ctx gctx.Context, opts *interfaces.SettingsOptions) {
 	key := fmt.Sprintf("as:%d", userID)
 	r.settings[key] = opts
 }
+
+// GetProfileID returns the agent profile the user selected in the session's organization
+func (r *speak) GetProfileID(ctx gctx.Context) (int64, bool) {
+	id, ok := r.profiles[profileKey(ctx)]
+	return id, ok
+}
+
+// SetProfileID selects the user's agent profile, 0 clears it
+func (r *speak) SetProfileID(ctx gctx.Context, id int64) {
+	key := profileKey(ctx)
+	if id == 0 {
+		delete(r.profiles, key)
+		return
+	}
+	r.profiles[key] = id
+}
+
+// profileKey keeps a selection per organization, profiles belong to one
+func profileKey(ctx gctx.Context) string {
+	return fmt.Sprintf("ap:%d:%d", context.GetUserId(ctx), context.GetOrgID(ctx))
+}
@@ -0,0 +1,103 @@
+package private
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/carsonkrueger/main/tools/render"
+)
+
+const (
+	ToolCallsGet        = "ToolCallsGet"
+	ToolCallsMetricsGet = "ToolCallsMetricsGet"
+)
+
+var ToolCallsTabModels = []pageLayouts.TabModel{
+	{Title: "Calls", PushUrl: true, HxGet: "/tool_calls/calls"},
+	{Title: "Metrics", PushUrl: true, HxGet: "/tool_calls/metrics"},
+}
+
+type toolCalls struct {
+	context.AppContext
+}
+
+func NewToolCalls(ctx context.AppContext) *toolCalls {
+	return &toolCalls{
+		AppContext: ctx,
+	}
+}
+
+func (r toolCalls) Path() string {
+	return "/tool_calls"
+}
+
+func (r *toolCalls) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/calls", r.toolCallsGet).SetPermissionName(ToolCallsGet).Build()
+	b.NewHandle().Register(builders.GET, "/metrics", r.toolCallsMetricsGet).SetPermissionName(ToolCallsMetricsGet).Build()
+}
+
+func (r *toolCalls) toolCallsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("toolCallsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	filter, err := parseToolCallFilter(req)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Invalid filter")
+		return
+	}
+	calls, err := r.DM().ToolCallsDAO().Search(context.GetOrgID(ctx), filter)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching tool calls")
+		return
+	}
+	rows := r.SM().ToolCallsService().ToolCallsAsRowData(calls)
+
+	// the filter form only swaps the results
+	if req.Header.Get("HX-Target") == pages.ToolCallsResultsID {
+		pages.ToolCallsTable(rows).Render(ctx, res)
+		return
+	}
+	page := pages.ToolCalls(filter, rows)
+	render.Tab(req, ToolCallsTabModels, 0, page).Render(ctx, res)
+}
+
+func (r *toolCalls) toolCallsMetricsGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("toolCallsMetricsGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	svc := r.SM().ToolCallsService()
+	rows := svc.ToolMetricsAsRowData(svc.Metrics(ctx))
+	page := pages.ToolMetrics(rows)
+	render.Tab(req, ToolCallsTabModels, 1, page).Render(ctx, res)
+}
+
+func parseToolCallFilter(req *http.Request) (agentModels.ToolCallFilter, error) {
+	q := req.URL.Query()
+	filter := agentModels.ToolCallFilter{
+		ToolName:   q.Get("tool"),
+		ErrorsOnly: q.Get("errors") == "true",
+	}
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = &id
+	}
+	if v := q.Get("conversation_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, err
+		}
+		filter.ConversationID = &id
+	}
+	return filter, nil
+}
@@ -0,0 +1,169 @@
+package private
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/carsonkrueger/main/builders"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/templates/pageLayouts"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+)
+
+const (
+	TranscribeGet  = "TranscribeGet"
+	TranscribePost = "TranscribePost"
+)
+
+const (
+	maxTranscribeUpload = 50 << 20
+	// every provider is given 16 kHz mono audio
+	transcribeSampleRate = 16000
+)
+
+type transcribe struct {
+	context.AppContext
+}
+
+func NewTranscribe(ctx context.AppContext) *transcribe {
+	return &transcribe{
+		AppContext: ctx,
+	}
+}
+
+func (r transcribe) Path() string {
+	return "/transcribe"
+}
+
+func (r *transcribe) PrivateRoute(b *builders.PrivateRouteBuilder) {
+	b.NewHandle().Register(builders.GET, "/", r.transcribeGet).SetPermissionName(TranscribeGet).Build()
+	b.NewHandle().Register(builders.POST, "/", r.transcribePost).SetPermissionName(TranscribePost).Build()
+}
+
+func (r *transcribe) transcribeGet(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("transcribeGet")
+	lgr.Info("Called")
+	ctx := req.Context()
+	page := pageLayouts.Index(pages.Transcribe(r.SM().STTService().ProviderNames()))
+	page.Render(ctx, res)
+}
+
+func (r *transcribe) transcribePost(res http.ResponseWriter, req *http.Request) {
+	lgr := r.Lgr("transcribePost")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	req.Body = http.MaxBytesReader(res, req.Body, maxTranscribeUpload)
+	if err := req.ParseMultipartForm(maxTranscribeUpload); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing upload")
+		return
+	}
+	file, _, err := req.FormFile("audio")
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Missing audio file")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error reading audio file")
+		return
+	}
+
+	samples, sampleRate, err := decodeUpload(data, req.FormValue("sample_rate"))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	}
+	samples = tools.Resample(samples, sampleRate, transcribeSampleRate)
+
+	stt := r.SM().STTService()
+	providerName := req.FormValue("provider")
+	if providerName == "" {
+		providerName = stt.DefaultProvider()
+	}
+	provider, ok := stt.Provider(providerName)
+	if !ok {
+		tools.HandleError(req, res, lgr, nil, 400, "Unknown provider")
+		return
+	}
+
+	transcript, err := provider.Transcribe(ctx, samples)
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error transcribing audio")
+		return
+	}
+
+	if wantsJSON(req) {
+		res.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(res).Encode(newTranscriptResponse(providerName, transcript)); err != nil {
+			lgr.Warn("failed to write transcript")
+		}
+		return
+	}
+	page := pages.TranscriptResult(providerName, transcript)
+	if !tools.IsHxRequest(req) {
+		page = pageLayouts.Index(page)
+	}
+	page.Render(ctx, res)
+}
+
+// decodeUpload decodes wav files, anything else is treated as raw 16 bit little
+// endian mono PCM at the given sample rate
+func decodeUpload(data []byte, sampleRate string) ([]int16, int, error) {
+	if len(data) >= 4 && string(data[:4]) == "RIFF" {
+		return tools.DecodeWAV(data)
+	}
+	rate := transcribeSampleRate
+	if sampleRate != "" {
+		var err error
+		if rate, err = strconv.Atoi(sampleRate); err != nil || rate <= 0 {
+			return nil, 0, errors.New("Invalid sample rate")
+		}
+	}
+	if len(data) < 2 {
+		return nil, 0, errors.New("Audio file is empty")
+	}
+	pcm := make([]int16, len(data)/2)
+	copy(pcm, tools.BytesToInt16Slice(data[:len(pcm)*2]))
+	return pcm, rate, nil
+}
+
+func wantsJSON(req *http.Request) bool {
+	return req.FormValue("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json")
+}
+
+type transcriptWordResponse struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+type transcriptResponse struct {
+	Provider string                   `json:"provider"`
+	Text     string                   `json:"text"`
+	Start    float64                  `json:"start"`
+	End      float64                  `json:"end"`
+	Words    []transcriptWordResponse `json:"words"`
+}
+
+// times are in seconds
+func newTranscriptResponse(provider string, t models.Transcript) transcriptResponse {
+	out := transcriptResponse{
+		Provider: provider,
+		Text:     t.Text,
+		Start:    t.Start.Seconds(),
+		End:      t.End.Seconds(),
+		Words:    make([]transcriptWordResponse, len(t.Words)),
+	}
+	for i, w := range t.Words {
+		out.Words[i] = transcriptWordResponse{Text: w.Text, Start: w.Start.Seconds(), End: w.End.Seconds()}
+	}
+	return out
+}
@@ -76,13 +76,27 @@ func (um *userManagement) userManagementLevelsGet(res http.ResponseWriter, req *
 	lgr.Info("Called")
 	ctx := req.Context()
 
-	privileges, err := um.DM().PrivilegeDAO().GetAllJoined()
+	ps := um.SM().PrivilegesService()
+	privileges, err := ps.EffectivePrivileges()
 	if err != nil {
 		tools.HandleError(req, res, lgr, err, 500, "Error fetching privileges")
 		return
 	}
-	rows := um.SM().PrivilegesService().JoinedPrivilegesAsRowData(privileges)
+	levels, err := um.DM().PrivilegeLevelsDAO().Index(nil, um.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching privilege levels")
+		return
+	}
+	patterns, err := um.DM().PrivilegeLevelsPatternsDAO().Index(nil, um.DB())
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error fetching privilege patterns")
+		return
+	}
 
-	page := pages.UserManagementLevels(rows)
+	page := pages.UserManagementLevels(
+		ps.EffectivePrivilegesAsRowData(privileges),
+		ps.LevelsAsRowData(levels),
+		ps.PatternsAsRowData(patterns, levels),
+	)
 	render.Tab(req, UserManagementTabModels, 1, page).Render(ctx, res)
 }
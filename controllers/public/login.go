@@ -1,10 +1,12 @@
 package public
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/carsonkrueger/main/context"
 	"github.com/carsonkrueger/main/controllers/private"
+	"github.com/carsonkrueger/main/services"
 	"github.com/carsonkrueger/main/templates/datadisplay"
 	"github.com/carsonkrueger/main/templates/pages"
 	"github.com/carsonkrueger/main/tools"
@@ -62,8 +64,14 @@ func (l *login) postLogin(res http.ResponseWriter, req *http.Request) {
 
 	usersService := l.SM().UsersService()
 	authToken, err := usersService.Login(email, password, req)
-	if err != nil {
-		lgr.Warn("Could not login")
+	if errors.Is(err, services.ErrLoginLocked) {
+		lgr.Warn("Login locked out")
+		res.WriteHeader(http.StatusTooManyRequests)
+		noti := datadisplay.AddTextToast(datadisplay.Error, "Too many attempts, try again later", 5)
+		noti.Render(ctx, res)
+		return
+	} else if err != nil {
+		lgr.Warn("Could not login", zap.Error(err))
 		res.WriteHeader(422)
 		noti := datadisplay.AddTextToast(datadisplay.Error, "Invalid username or password", 5)
 		noti.Render(ctx, res)
@@ -102,6 +110,6 @@ func (l *login) getLogin(res http.ResponseWriter, req *http.Request) {
 	lgr := l.Lgr("getLogin")
 	lgr.Info("Called")
 	ctx := req.Context()
-	page := pages.Login()
+	page := pages.Login(l.SM().OIDCService().Enabled())
 	render.PageMainLayout(req, page).Render(ctx, res)
 }
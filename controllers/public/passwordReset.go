@@ -0,0 +1,98 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/carsonkrueger/main/tools/render"
+	"github.com/carsonkrueger/main/tools/validate"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type passwordReset struct {
+	context.AppContext
+}
+
+func NewPasswordReset(ctx context.AppContext) *passwordReset {
+	return &passwordReset{
+		AppContext: ctx,
+	}
+}
+
+func (p *passwordReset) Path() string {
+	return "/reset"
+}
+
+func (p *passwordReset) PublicRoute(r chi.Router) {
+	r.Get("/", p.getReset)
+	r.Post("/", p.postReset)
+	r.Get("/confirm", p.getResetConfirm)
+	r.Post("/confirm", p.postResetConfirm)
+}
+
+func (p *passwordReset) getReset(res http.ResponseWriter, req *http.Request) {
+	lgr := p.Lgr("getReset")
+	lgr.Info("Called")
+	render.PageMainLayout(req, pages.ResetRequest()).Render(req.Context(), res)
+}
+
+func (p *passwordReset) postReset(res http.ResponseWriter, req *http.Request) {
+	lgr := p.Lgr("postReset")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	err := p.SM().AccountsService().RequestPasswordReset(ctx, req.FormValue("email"), req)
+	if errors.Is(err, services.ErrLoginLocked) {
+		// locked the same whether or not the email has an account
+		lgr.Warn("Password reset locked out")
+		res.WriteHeader(http.StatusTooManyRequests)
+		datadisplay.AddTextToast(datadisplay.Error, "Too many attempts, try again later", 5).Render(ctx, res)
+		return
+	} else if err != nil {
+		// the response is the same either way, it must not tell whether the email has an account
+		lgr.Error("Failed to request password reset", zap.Error(err))
+	}
+	datadisplay.AddTextToast(datadisplay.Success, "If that email has an account, a reset link is on its way", 0).Render(ctx, res)
+}
+
+func (p *passwordReset) getResetConfirm(res http.ResponseWriter, req *http.Request) {
+	lgr := p.Lgr("getResetConfirm")
+	lgr.Info("Called")
+	page := pages.ResetConfirm(req.URL.Query().Get("token"))
+	render.PageMainLayout(req, page).Render(req.Context(), res)
+}
+
+func (p *passwordReset) postResetConfirm(res http.ResponseWriter, req *http.Request) {
+	lgr := p.Lgr("postResetConfirm")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	if errs := validate.ValidatePasswordReset(req.Form); len(errs) > 0 {
+		lgr.Warn("Validation errors", zap.Errors("Reset Form", errs))
+		datadisplay.AddToastErrors(0, errs...).Render(ctx, res)
+		return
+	}
+	err := p.SM().AccountsService().ResetPassword(req.FormValue("token"), req.FormValue("password"))
+	if errors.Is(err, services.ErrInvalidAccountToken) {
+		tools.HandleError(req, res, lgr, err, 400, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error resetting password")
+		return
+	}
+	res.Header().Set("Hx-Redirect", "/login")
+}
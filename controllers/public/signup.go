@@ -1,6 +1,7 @@
 package public
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/carsonkrueger/main/context"
@@ -51,19 +52,34 @@ func (s *signUp) postSignup(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	salt, _ := tools.GenerateSalt()
-	authToken, _ := tools.GenerateSalt()
-	hash := tools.HashPassword(form.Get("password"), salt)
+	level, err := s.DM().PrivilegeLevelsDAO().GetSignupDefault()
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating account")
+		return
+	}
+
+	hash, err := tools.HashPassword(form.Get("password"))
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating account")
+		return
+	}
 	user := model.Users{
 		FirstName:        form.Get("first_name"),
 		LastName:         form.Get("last_name"),
 		Email:            form.Get("email"),
 		Password:         hash,
-		PrivilegeLevelID: 1000,
+		PrivilegeLevelID: level.ID,
+	}
+
+	tx, err := s.DB().Begin()
+	if err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating account")
+		return
 	}
+	defer tx.Rollback()
 
 	usersDAO := s.DM().UsersDAO()
-	if err := usersDAO.Insert(&user, s.DB()); err != nil {
+	if err := usersDAO.Insert(&user, tx); err != nil {
 		lgr.Warn("Could not insert user", zap.Error(err))
 		res.WriteHeader(422)
 		noti := datadisplay.AddTextToast(datadisplay.Warning, "Email taken", 0)
@@ -71,12 +87,27 @@ func (s *signUp) postSignup(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	session := &model.Sessions{
-		UserID: user.ID,
-		Token:  authToken,
+	// every user starts in an organization of their own and can be added to others
+	orgName := fmt.Sprintf("%s %s's Team", user.FirstName, user.LastName)
+	org, err := s.SM().OrganizationsService().Create(orgName, user.ID, tx)
+	if err != nil {
+		lgr.Error("Could not create organization", zap.Error(err))
+		res.WriteHeader(500)
+		noti := datadisplay.AddTextToast(datadisplay.Error, "Error creating organization", 0)
+		noti.Render(ctx, res)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		tools.HandleError(req, res, lgr, err, 500, "Error creating account")
+		return
+	}
+
+	if err := s.SM().AccountsService().SendVerification(ctx, &user); err != nil {
+		lgr.Error("Could not send verification email", zap.Error(err))
 	}
-	sessionDAO := s.DM().SessionsDAO()
-	if err := sessionDAO.Insert(session, s.DB()); err != nil {
+
+	authToken, err := s.SM().UsersService().StartSession(user.ID, &org.ID, req)
+	if err != nil {
 		lgr.Error("Could not insert session", zap.Error(err))
 		res.WriteHeader(500)
 		noti := datadisplay.AddTextToast(datadisplay.Error, "Error creating session", 0)
@@ -84,10 +115,11 @@ func (s *signUp) postSignup(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	tools.SetAuthCookie(res, &authToken)
+	tools.SetAuthCookie(res, authToken)
+	datadisplay.AddTextToast(datadisplay.Info, "Check your email to verify your account", 0).Render(ctx, res)
 
 	hxRequest := tools.IsHxRequest(req)
-	page := pages.Login()
+	page := pages.Login(s.SM().OIDCService().Enabled())
 	// If not hx request then user just arrived. Give them the index.html
 	if !hxRequest {
 		page = pageLayouts.Index(pageLayouts.MainPageLayout(page))
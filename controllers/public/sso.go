@@ -0,0 +1,115 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/carsonkrueger/main/tools/render"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// keeps the state of a login in the browser that started it
+const ssoStateCookie = "ghx_sso_state"
+
+type sso struct {
+	context.AppContext
+}
+
+func NewSSO(ctx context.AppContext) *sso {
+	return &sso{
+		AppContext: ctx,
+	}
+}
+
+func (s *sso) Path() string {
+	return "/sso"
+}
+
+func (s *sso) PublicRoute(r chi.Router) {
+	r.Get("/", s.getSSO)
+	r.Get("/callback", s.getSSOCallback)
+}
+
+func (s *sso) getSSO(res http.ResponseWriter, req *http.Request) {
+	lgr := s.Lgr("getSSO")
+	lgr.Info("Called")
+
+	authURL, state, err := s.SM().OIDCService().Begin(req.Context())
+	if errors.Is(err, services.ErrOIDCDisabled) {
+		tools.HandleError(req, res, lgr, err, 404, err.Error())
+		return
+	} else if errors.Is(err, services.ErrOIDCBusy) {
+		tools.HandleError(req, res, lgr, err, 503, err.Error())
+		return
+	} else if err != nil {
+		tools.HandleError(req, res, lgr, err, 502, "Error reaching the identity provider")
+		return
+	}
+	http.SetCookie(res, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     s.Path(),
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		// the provider redirects back with a top level GET, which Lax allows
+		SameSite: http.SameSiteLaxMode,
+	})
+	if tools.IsHxRequest(req) {
+		res.Header().Set("Hx-Redirect", authURL)
+		return
+	}
+	http.Redirect(res, req, authURL, http.StatusFound)
+}
+
+func (s *sso) getSSOCallback(res http.ResponseWriter, req *http.Request) {
+	lgr := s.Lgr("getSSOCallback")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	http.SetCookie(res, &http.Cookie{Name: ssoStateCookie, Path: s.Path(), MaxAge: -1})
+	q := req.URL.Query()
+	if e := q.Get("error"); e != "" {
+		lgr.Warn("Identity provider returned an error", zap.String("error", e), zap.String("description", q.Get("error_description")))
+		s.renderLoginError(res, req, "Single sign-on was cancelled or refused")
+		return
+	}
+	var browserState string
+	if cookie, err := req.Cookie(ssoStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+
+	user, err := s.SM().OIDCService().Finish(ctx, q.Get("state"), browserState, q.Get("code"))
+	if errors.Is(err, services.ErrOIDCState) || errors.Is(err, services.ErrOIDCEmail) {
+		lgr.Warn("Single sign-on failed", zap.Error(err))
+		s.renderLoginError(res, req, err.Error())
+		return
+	} else if err != nil {
+		lgr.Error("Single sign-on failed", zap.Error(err))
+		s.renderLoginError(res, req, "Single sign-on failed")
+		return
+	}
+
+	authToken, err := s.SM().UsersService().StartSession(user.ID, nil, req)
+	if err != nil {
+		lgr.Error("Could not insert session", zap.Error(err))
+		s.renderLoginError(res, req, "Error creating session")
+		return
+	}
+	tools.SetAuthCookie(res, authToken)
+	http.Redirect(res, req, "/", http.StatusFound)
+}
+
+// renderLoginError shows the login page with msg
+func (s *sso) renderLoginError(res http.ResponseWriter, req *http.Request, msg string) {
+	ctx := req.Context()
+	res.WriteHeader(http.StatusUnauthorized)
+	render.PageMainLayout(req, pages.Login(s.SM().OIDCService().Enabled())).Render(ctx, res)
+	datadisplay.AddTextToast(datadisplay.Error, msg, 0).Render(ctx, res)
+}
@@ -0,0 +1,72 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/services"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/pages"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/carsonkrueger/main/tools/render"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type verify struct {
+	context.AppContext
+}
+
+func NewVerify(ctx context.AppContext) *verify {
+	return &verify{
+		AppContext: ctx,
+	}
+}
+
+func (v *verify) Path() string {
+	return "/verify"
+}
+
+func (v *verify) PublicRoute(r chi.Router) {
+	r.Get("/", v.getVerify)
+	r.Post("/", v.postVerify)
+}
+
+func (v *verify) getVerify(res http.ResponseWriter, req *http.Request) {
+	lgr := v.Lgr("getVerify")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	token := req.URL.Query().Get("token")
+	if token == "" {
+		page := pages.Verify("Check your email for a verification link.", false)
+		render.PageMainLayout(req, page).Render(ctx, res)
+		return
+	}
+	err := v.SM().AccountsService().Verify(token)
+	page := pages.Verify("Your email is verified.", true)
+	if errors.Is(err, services.ErrInvalidAccountToken) {
+		lgr.Warn("Invalid verification token")
+		page = pages.Verify(err.Error(), false)
+	} else if err != nil {
+		lgr.Error("Failed to verify email", zap.Error(err))
+		page = pages.Verify("Something went wrong, please try again.", false)
+	}
+	render.PageMainLayout(req, page).Render(ctx, res)
+}
+
+func (v *verify) postVerify(res http.ResponseWriter, req *http.Request) {
+	lgr := v.Lgr("postVerify")
+	lgr.Info("Called")
+	ctx := req.Context()
+
+	if err := req.ParseForm(); err != nil {
+		tools.HandleError(req, res, lgr, err, 400, "Error parsing form")
+		return
+	}
+	if err := v.SM().AccountsService().ResendVerification(ctx, req.FormValue("email")); err != nil {
+		lgr.Error("Failed to resend verification", zap.Error(err))
+	}
+	datadisplay.AddTextToast(datadisplay.Success, "If that email needs verifying, a new link is on its way", 0).Render(ctx, res)
+}
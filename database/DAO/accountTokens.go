@@ -0,0 +1,95 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type accountTokensDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.AccountTokens]
+}
+
+func newAccountTokensDAO(db *sql.DB) *accountTokensDAO {
+	dao := &accountTokensDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.AccountTokens](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *accountTokensDAO) Table() PostgresTable {
+	return table.AccountTokens
+}
+
+func (dao *accountTokensDAO) InsertCols() postgres.ColumnList {
+	return table.AccountTokens.AllColumns.Except(
+		table.AccountTokens.ID,
+		table.AccountTokens.CreatedAt,
+	)
+}
+
+func (dao *accountTokensDAO) UpdateCols() postgres.ColumnList {
+	return table.AccountTokens.AllColumns.Except(
+		table.AccountTokens.ID,
+		table.AccountTokens.CreatedAt,
+	)
+}
+
+func (dao *accountTokensDAO) AllCols() postgres.ColumnList {
+	return table.AccountTokens.AllColumns
+}
+
+func (dao *accountTokensDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *accountTokensDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *accountTokensDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.AccountTokens.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *accountTokensDAO) GetUpdatedAt(row *model.AccountTokens) *time.Time {
+	return nil
+}
+
+// Consume marks the unused, unexpired token with hash as used and returns it, so
+// a token works once even when redeemed twice at the same time
+func (dao *accountTokensDAO) Consume(hash string, purpose string, now time.Time) (*model.AccountTokens, error) {
+	var row model.AccountTokens
+	err := table.AccountTokens.
+		UPDATE(table.AccountTokens.UsedAt).
+		SET(postgres.TimestampT(now)).
+		WHERE(
+			table.AccountTokens.TokenHash.EQ(postgres.String(hash)).
+				AND(table.AccountTokens.Purpose.EQ(postgres.String(purpose))).
+				AND(table.AccountTokens.UsedAt.IS_NULL()).
+				AND(table.AccountTokens.ExpiresAt.GT(postgres.TimestampT(now))),
+		).
+		RETURNING(table.AccountTokens.AllColumns).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (dao *accountTokensDAO) DeleteByUser(userID int64, purpose string) error {
+	_, err := table.AccountTokens.
+		DELETE().
+		WHERE(
+			table.AccountTokens.UserID.EQ(postgres.Int(userID)).
+				AND(table.AccountTokens.Purpose.EQ(postgres.String(purpose))),
+		).
+		Exec(dao.db)
+	return err
+}
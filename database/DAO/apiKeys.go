@@ -0,0 +1,118 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type apiKeysDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.APIKeys]
+}
+
+func newAPIKeysDAO(db *sql.DB) *apiKeysDAO {
+	dao := &apiKeysDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.APIKeys](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *apiKeysDAO) Table() PostgresTable {
+	return table.APIKeys
+}
+
+func (dao *apiKeysDAO) InsertCols() postgres.ColumnList {
+	return table.APIKeys.AllColumns.Except(
+		table.APIKeys.ID,
+		table.APIKeys.CreatedAt,
+		table.APIKeys.UpdatedAt,
+	)
+}
+
+func (dao *apiKeysDAO) UpdateCols() postgres.ColumnList {
+	return table.APIKeys.AllColumns.Except(
+		table.APIKeys.ID,
+		table.APIKeys.CreatedAt,
+	)
+}
+
+func (dao *apiKeysDAO) AllCols() postgres.ColumnList {
+	return table.APIKeys.AllColumns
+}
+
+func (dao *apiKeysDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *apiKeysDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *apiKeysDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.APIKeys.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *apiKeysDAO) GetUpdatedAt(row *model.APIKeys) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *apiKeysDAO) GetByHash(hash string) (*model.APIKeys, error) {
+	var row model.APIKeys
+	err := table.APIKeys.
+		SELECT(table.APIKeys.AllColumns).
+		WHERE(table.APIKeys.KeyHash.EQ(postgres.String(hash))).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetByUser returns the user's keys in the organization, newest first
+func (dao *apiKeysDAO) GetByUser(userID int64, orgID int64) ([]*model.APIKeys, error) {
+	var rows []*model.APIKeys
+	err := table.APIKeys.
+		SELECT(table.APIKeys.AllColumns).
+		WHERE(
+			table.APIKeys.UserID.EQ(postgres.Int(userID)).
+				AND(table.APIKeys.OrganizationID.EQ(postgres.Int(orgID))),
+		).
+		ORDER_BY(table.APIKeys.CreatedAt.DESC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Touch records that the key was used at now
+func (dao *apiKeysDAO) Touch(id int64, now time.Time) error {
+	_, err := table.APIKeys.
+		UPDATE(table.APIKeys.LastUsedAt).
+		SET(postgres.TimestampT(now)).
+		WHERE(table.APIKeys.ID.EQ(postgres.Int(id))).
+		Exec(dao.db)
+	return err
+}
+
+func (dao *apiKeysDAO) DeleteByUser(userID int64, id int64) (int64, error) {
+	res, err := table.APIKeys.
+		DELETE().
+		WHERE(
+			table.APIKeys.UserID.EQ(postgres.Int(userID)).
+				AND(table.APIKeys.ID.EQ(postgres.Int(id))),
+		).
+		Exec(dao.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,96 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type apiKeysPrivilegesDAO struct {
+	db *sql.DB
+	DAOBaseQueries[authModels.APIKeysPrivilegesPrimaryKey, model.APIKeysPrivileges]
+}
+
+func newAPIKeysPrivilegesDAO(db *sql.DB) *apiKeysPrivilegesDAO {
+	dao := &apiKeysPrivilegesDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[authModels.APIKeysPrivilegesPrimaryKey, model.APIKeysPrivileges](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *apiKeysPrivilegesDAO) Table() PostgresTable {
+	return table.APIKeysPrivileges
+}
+
+func (dao *apiKeysPrivilegesDAO) InsertCols() postgres.ColumnList {
+	return table.APIKeysPrivileges.AllColumns.Except(
+		table.APIKeysPrivileges.CreatedAt,
+	)
+}
+
+func (dao *apiKeysPrivilegesDAO) UpdateCols() postgres.ColumnList {
+	return table.APIKeysPrivileges.AllColumns.Except(
+		table.APIKeysPrivileges.CreatedAt,
+	)
+}
+
+func (dao *apiKeysPrivilegesDAO) AllCols() postgres.ColumnList {
+	return table.APIKeysPrivileges.AllColumns
+}
+
+func (dao *apiKeysPrivilegesDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{
+		table.APIKeysPrivileges.APIKeyID,
+		table.APIKeysPrivileges.PrivilegeID,
+	}
+}
+
+func (dao *apiKeysPrivilegesDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{
+		table.APIKeysPrivileges.APIKeyID.SET(table.APIKeysPrivileges.APIKeyID),
+		table.APIKeysPrivileges.PrivilegeID.SET(table.APIKeysPrivileges.PrivilegeID),
+	}
+}
+
+func (dao *apiKeysPrivilegesDAO) PKMatch(pk authModels.APIKeysPrivilegesPrimaryKey) postgres.BoolExpression {
+	return table.APIKeysPrivileges.
+		APIKeyID.EQ(postgres.Int(pk.APIKeyID)).
+		AND(table.APIKeysPrivileges.PrivilegeID.EQ(postgres.Int(pk.PrivilegeID)))
+}
+
+func (dao *apiKeysPrivilegesDAO) GetUpdatedAt(row *model.APIKeysPrivileges) *time.Time {
+	return nil
+}
+
+// GetScopes returns the names of the privileges granted to each of the keys
+func (dao *apiKeysPrivilegesDAO) GetScopes(keyIDs []int64) ([]authModels.APIKeyScope, error) {
+	var rows []authModels.APIKeyScope
+	if len(keyIDs) == 0 {
+		return rows, nil
+	}
+	ids := make([]postgres.Expression, len(keyIDs))
+	for i, id := range keyIDs {
+		ids[i] = postgres.Int(id)
+	}
+	err := table.APIKeysPrivileges.
+		INNER_JOIN(table.Privileges, table.Privileges.ID.EQ(table.APIKeysPrivileges.PrivilegeID)).
+		SELECT(
+			table.APIKeysPrivileges.APIKeyID.AS("APIKeyScope.APIKeyID"),
+			table.Privileges.ID.AS("APIKeyScope.PrivilegeID"),
+			table.Privileges.Name.AS("APIKeyScope.PrivilegeName"),
+		).
+		WHERE(table.APIKeysPrivileges.APIKeyID.IN(ids...)).
+		ORDER_BY(table.Privileges.Name.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,101 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type auditEventsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.AuditEvents]
+}
+
+func newAuditEventsDAO(db *sql.DB) *auditEventsDAO {
+	dao := &auditEventsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.AuditEvents](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *auditEventsDAO) Table() PostgresTable {
+	return table.AuditEvents
+}
+
+func (dao *auditEventsDAO) InsertCols() postgres.ColumnList {
+	return table.AuditEvents.AllColumns.Except(
+		table.AuditEvents.ID,
+		table.AuditEvents.CreatedAt,
+	)
+}
+
+func (dao *auditEventsDAO) UpdateCols() postgres.ColumnList {
+	return table.AuditEvents.AllColumns.Except(
+		table.AuditEvents.ID,
+		table.AuditEvents.CreatedAt,
+	)
+}
+
+func (dao *auditEventsDAO) AllCols() postgres.ColumnList {
+	return table.AuditEvents.AllColumns
+}
+
+func (dao *auditEventsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *auditEventsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *auditEventsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.AuditEvents.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *auditEventsDAO) GetUpdatedAt(row *model.AuditEvents) *time.Time {
+	return nil
+}
+
+// Search returns the newest events matching filter with their actor's email
+func (dao *auditEventsDAO) Search(filter authModels.AuditEventFilter) ([]authModels.AuditEventJoin, error) {
+	where := postgres.Bool(true)
+	if filter.Action != "" {
+		where = where.AND(table.AuditEvents.Action.EQ(postgres.String(filter.Action)))
+	}
+	if filter.TargetType != "" {
+		where = where.AND(table.AuditEvents.TargetType.EQ(postgres.String(filter.TargetType)))
+	}
+	if filter.TargetID != nil {
+		where = where.AND(table.AuditEvents.TargetID.EQ(postgres.Int(*filter.TargetID)))
+	}
+	if filter.ActorEmail != "" {
+		where = where.AND(postgres.LOWER(table.Users.Email).EQ(postgres.String(filter.ActorEmail)))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var rows []authModels.AuditEventJoin
+	err := table.AuditEvents.
+		LEFT_JOIN(table.Users, table.Users.ID.EQ(table.AuditEvents.ActorID)).
+		SELECT(
+			table.AuditEvents.AllColumns,
+			table.Users.Email.AS("AuditEventJoin.ActorEmail"),
+		).
+		WHERE(where).
+		ORDER_BY(table.AuditEvents.CreatedAt.DESC(), table.AuditEvents.ID.DESC()).
+		LIMIT(limit).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -178,3 +178,79 @@ func (q *baseDAOQueryable[PK, R]) Update(model *R, pk PK, db qrm.Queryable) erro
 func (q *baseDAOQueryable[PK, R]) Delete(pk PK, db qrm.Executable) error {
 	return delete(q.Dao, pk, db)
 }
+
+func orgWhere[PK PrimaryKey, R any](DAO OrgDAO[PK, R], orgID int64, params *models.SearchParams) *models.SearchParams {
+	scoped := models.SearchParams{}
+	if params != nil {
+		scoped = *params
+	}
+	where := DAO.OrgMatch(orgID)
+	if scoped.Where != nil {
+		where = where.AND(scoped.Where)
+	}
+	scoped.Where = where
+	return &scoped
+}
+
+func indexInOrg[PK PrimaryKey, R any](DAO OrgDAO[PK, R], orgID int64, params *models.SearchParams, db qrm.Queryable) ([]*R, error) {
+	return index(DAO, orgWhere(DAO, orgID, params), db)
+}
+
+func getOneInOrg[PK PrimaryKey, R any](DAO OrgDAO[PK, R], orgID int64, pk PK, db qrm.Queryable) (*R, error) {
+	var model R
+	if err := DAO.Table().
+		SELECT(DAO.AllCols()).
+		WHERE(DAO.PKMatch(pk).AND(DAO.OrgMatch(orgID))).
+		LIMIT(1).
+		Query(db, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func updateInOrg[PK PrimaryKey, R any](DAO OrgDAO[PK, R], orgID int64, model *R, pk PK, db qrm.Queryable) error {
+	up := DAO.GetUpdatedAt(model)
+	if up != nil {
+		*up = time.Now()
+	}
+	return DAO.Table().
+		UPDATE(DAO.UpdateCols()).
+		MODEL(model).
+		WHERE(DAO.PKMatch(pk).AND(DAO.OrgMatch(orgID))).
+		RETURNING(DAO.AllCols()).
+		Query(db, model)
+}
+
+func deleteInOrg[PK PrimaryKey, R any](DAO OrgDAO[PK, R], orgID int64, pk PK, db qrm.Executable) error {
+	_, err := DAO.Table().
+		DELETE().
+		WHERE(DAO.PKMatch(pk).AND(DAO.OrgMatch(orgID))).
+		Exec(db)
+	return err
+}
+
+type orgDAOQueryable[PK PrimaryKey, R any] struct {
+	Dao OrgDAO[PK, R]
+}
+
+func newOrgDAOQueryable[PK PrimaryKey, R any](dao OrgDAO[PK, R]) orgDAOQueryable[PK, R] {
+	return orgDAOQueryable[PK, R]{
+		dao,
+	}
+}
+
+func (q *orgDAOQueryable[PK, R]) IndexInOrg(orgID int64, params *models.SearchParams, db qrm.Queryable) ([]*R, error) {
+	return indexInOrg(q.Dao, orgID, params, db)
+}
+
+func (q *orgDAOQueryable[PK, R]) GetOneInOrg(orgID int64, pk PK, db qrm.Queryable) (*R, error) {
+	return getOneInOrg(q.Dao, orgID, pk, db)
+}
+
+func (q *orgDAOQueryable[PK, R]) UpdateInOrg(orgID int64, model *R, pk PK, db qrm.Queryable) error {
+	return updateInOrg(q.Dao, orgID, model, pk, db)
+}
+
+func (q *orgDAOQueryable[PK, R]) DeleteInOrg(orgID int64, pk PK, db qrm.Executable) error {
+	return deleteInOrg(q.Dao, orgID, pk, db)
+}
@@ -0,0 +1,154 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type campaignContactsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.CampaignContacts]
+}
+
+func newCampaignContactsDAO(db *sql.DB) *campaignContactsDAO {
+	dao := &campaignContactsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.CampaignContacts](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *campaignContactsDAO) Table() PostgresTable {
+	return table.CampaignContacts
+}
+
+func (dao *campaignContactsDAO) InsertCols() postgres.ColumnList {
+	return table.CampaignContacts.AllColumns.Except(
+		table.CampaignContacts.ID,
+		table.CampaignContacts.CreatedAt,
+		table.CampaignContacts.UpdatedAt,
+	)
+}
+
+func (dao *campaignContactsDAO) UpdateCols() postgres.ColumnList {
+	return table.CampaignContacts.AllColumns.Except(
+		table.CampaignContacts.ID,
+		table.CampaignContacts.CreatedAt,
+	)
+}
+
+func (dao *campaignContactsDAO) AllCols() postgres.ColumnList {
+	return table.CampaignContacts.AllColumns
+}
+
+func (dao *campaignContactsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{table.CampaignContacts.CampaignID, table.CampaignContacts.Phone}
+}
+
+// uploading a contact again only updates its name
+func (dao *campaignContactsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{
+		table.CampaignContacts.Name.SET(table.CampaignContacts.EXCLUDED.Name),
+	}
+}
+
+func (dao *campaignContactsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.CampaignContacts.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *campaignContactsDAO) GetUpdatedAt(row *model.CampaignContacts) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *campaignContactsDAO) GetByCampaign(campaignID int64) ([]*model.CampaignContacts, error) {
+	var rows []*model.CampaignContacts
+	err := table.CampaignContacts.
+		SELECT(table.CampaignContacts.AllColumns).
+		WHERE(table.CampaignContacts.CampaignID.EQ(postgres.Int(campaignID))).
+		ORDER_BY(table.CampaignContacts.ID.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetDue returns pending contacts whose next attempt is not in the future. The
+// rows are locked FOR UPDATE, skipping any another scheduler already locked, so
+// db should be a transaction that claims them before committing.
+func (dao *campaignContactsDAO) GetDue(campaignID int64, now time.Time, limit int64, db qrm.Queryable) ([]*model.CampaignContacts, error) {
+	var rows []*model.CampaignContacts
+	err := table.CampaignContacts.
+		SELECT(table.CampaignContacts.AllColumns).
+		WHERE(
+			table.CampaignContacts.CampaignID.EQ(postgres.Int(campaignID)).
+				AND(table.CampaignContacts.Status.EQ(postgres.String(agentModels.ContactPending))).
+				AND(table.CampaignContacts.NextAttemptAt.IS_NULL().
+					OR(table.CampaignContacts.NextAttemptAt.LT_EQ(postgres.TimestampT(now)))),
+		).
+		ORDER_BY(table.CampaignContacts.NextAttemptAt.ASC().NULLS_FIRST(), table.CampaignContacts.ID.ASC()).
+		LIMIT(limit).
+		FOR(postgres.UPDATE().SKIP_LOCKED()).
+		Query(db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// CountByStatus counts the campaign's contacts in any of statuses
+func (dao *campaignContactsDAO) CountByStatus(campaignID int64, statuses ...string) (int64, error) {
+	var res struct {
+		Count int64
+	}
+	exprs := make([]postgres.Expression, len(statuses))
+	for i, status := range statuses {
+		exprs[i] = postgres.String(status)
+	}
+	err := table.CampaignContacts.
+		SELECT(postgres.COUNT(table.CampaignContacts.ID).AS("Count")).
+		WHERE(
+			table.CampaignContacts.CampaignID.EQ(postgres.Int(campaignID)).
+				AND(table.CampaignContacts.Status.IN(exprs...)),
+		).
+		Query(dao.db, &res)
+	return res.Count, err
+}
+
+func (dao *campaignContactsDAO) GetByCallID(callID string) (*model.CampaignContacts, error) {
+	var row model.CampaignContacts
+	err := table.CampaignContacts.
+		SELECT(table.CampaignContacts.AllColumns).
+		WHERE(table.CampaignContacts.CallID.EQ(postgres.String(callID))).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetStaleCalling returns contacts still ringing since before, whose outcome
+// never arrived. Connected contacts are on a call and are left alone.
+func (dao *campaignContactsDAO) GetStaleCalling(before time.Time) ([]*model.CampaignContacts, error) {
+	var rows []*model.CampaignContacts
+	err := table.CampaignContacts.
+		SELECT(table.CampaignContacts.AllColumns).
+		WHERE(
+			table.CampaignContacts.Status.EQ(postgres.String(agentModels.ContactCalling)).
+				AND(table.CampaignContacts.LastAttemptAt.LT(postgres.TimestampT(before))),
+		).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,85 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type campaignsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.Campaigns]
+	OrgDAOBaseQueries[int64, model.Campaigns]
+}
+
+func newCampaignsDAO(db *sql.DB) *campaignsDAO {
+	dao := &campaignsDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.Campaigns](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.Campaigns](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *campaignsDAO) Table() PostgresTable {
+	return table.Campaigns
+}
+
+func (dao *campaignsDAO) InsertCols() postgres.ColumnList {
+	return table.Campaigns.AllColumns.Except(
+		table.Campaigns.ID,
+		table.Campaigns.CreatedAt,
+		table.Campaigns.UpdatedAt,
+	)
+}
+
+func (dao *campaignsDAO) UpdateCols() postgres.ColumnList {
+	return table.Campaigns.AllColumns.Except(
+		table.Campaigns.ID,
+		table.Campaigns.CreatedAt,
+	)
+}
+
+func (dao *campaignsDAO) AllCols() postgres.ColumnList {
+	return table.Campaigns.AllColumns
+}
+
+func (dao *campaignsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *campaignsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *campaignsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.Campaigns.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *campaignsDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.Campaigns.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *campaignsDAO) GetUpdatedAt(row *model.Campaigns) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *campaignsDAO) GetByStatus(status string) ([]*model.Campaigns, error) {
+	var rows []*model.Campaigns
+	err := table.Campaigns.
+		SELECT(table.Campaigns.AllColumns).
+		WHERE(table.Campaigns.Status.EQ(postgres.String(status))).
+		ORDER_BY(table.Campaigns.ID.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,72 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type conversationsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.Conversations]
+	OrgDAOBaseQueries[int64, model.Conversations]
+}
+
+func newConversationsDAO(db *sql.DB) *conversationsDAO {
+	dao := &conversationsDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.Conversations](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.Conversations](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *conversationsDAO) Table() PostgresTable {
+	return table.Conversations
+}
+
+func (dao *conversationsDAO) InsertCols() postgres.ColumnList {
+	return table.Conversations.AllColumns.Except(
+		table.Conversations.ID,
+		table.Conversations.CreatedAt,
+		table.Conversations.UpdatedAt,
+	)
+}
+
+func (dao *conversationsDAO) UpdateCols() postgres.ColumnList {
+	return table.Conversations.AllColumns.Except(
+		table.Conversations.ID,
+		table.Conversations.CreatedAt,
+	)
+}
+
+func (dao *conversationsDAO) AllCols() postgres.ColumnList {
+	return table.Conversations.AllColumns
+}
+
+func (dao *conversationsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *conversationsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *conversationsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.Conversations.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *conversationsDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.Conversations.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *conversationsDAO) GetUpdatedAt(row *model.Conversations) *time.Time {
+	return row.UpdatedAt
+}
@@ -3,8 +3,10 @@ package DAO
 import (
 	"time"
 
+	agentModel "github.com/carsonkrueger/main/gen/go_db/agent/model"
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/agentModels"
 	"github.com/carsonkrueger/main/models/authModels"
 	"github.com/go-jet/jet/v2/postgres"
 	"github.com/go-jet/jet/v2/qrm"
@@ -59,12 +61,51 @@ type DAO[PK any, R any] interface {
 	DAOBaseQueries[PK, R]
 }
 
+// OrgMatcher is implemented by the DAOs of tables whose rows belong to an organization
+type OrgMatcher interface {
+	OrgMatch(orgID int64) postgres.BoolExpression
+}
+
+// OrgDAOBaseQueries are the base queries limited to the rows of one organization.
+// A row of another organization is not found rather than forbidden.
+type OrgDAOBaseQueries[PK PrimaryKey, R any] interface {
+	IndexInOrg(orgID int64, params *models.SearchParams, db qrm.Queryable) ([]*R, error)
+	GetOneInOrg(orgID int64, pk PK, db qrm.Queryable) (*R, error)
+	UpdateInOrg(orgID int64, model *R, pk PK, db qrm.Queryable) error
+	DeleteInOrg(orgID int64, pk PK, db qrm.Executable) error
+}
+
+type OrgDAO[PK any, R any] interface {
+	DAO[PK, R]
+	OrgMatcher
+	OrgDAOBaseQueries[PK, R]
+}
+
 type DAOManager interface {
 	UsersDAO() UsersDAO
 	PrivilegeDAO() PrivilegeDAO
 	PrivilegeLevelsDAO() PrivilegeLevelsDAO
 	SessionsDAO() SessionsDAO
 	PrivilegeLevelsPrivilegesDAO() PrivilegeLevelsPrivilegesDAO
+	PrivilegeLevelsPatternsDAO() PrivilegeLevelsPatternsDAO
+	ConversationsDAO() ConversationsDAO
+	ToolCallsDAO() ToolCallsDAO
+	ProfilesDAO() ProfilesDAO
+	KnowledgeDocumentsDAO() KnowledgeDocumentsDAO
+	KnowledgeChunksDAO() KnowledgeChunksDAO
+	CampaignsDAO() CampaignsDAO
+	CampaignContactsDAO() CampaignContactsDAO
+	OrganizationsDAO() OrganizationsDAO
+	OrganizationMembersDAO() OrganizationMembersDAO
+	PhoneNumbersDAO() PhoneNumbersDAO
+	APIKeysDAO() APIKeysDAO
+	APIKeysPrivilegesDAO() APIKeysPrivilegesDAO
+	AccountTokensDAO() AccountTokensDAO
+	UserIdentitiesDAO() UserIdentitiesDAO
+	OIDCLoginsDAO() OIDCLoginsDAO
+	LoginThrottlesDAO() LoginThrottlesDAO
+	SecurityEventsDAO() SecurityEventsDAO
+	AuditEventsDAO() AuditEventsDAO
 }
 
 type UsersDAO interface {
@@ -72,6 +113,7 @@ type UsersDAO interface {
 	GetByEmail(email string) (*model.Users, error)
 	GetPrivilegeLevelID(id int64) (*int64, error)
 	GetUserPrivilegeJoinAll() (*[]authModels.UserPrivilegeLevelJoin, error)
+	SetPassword(userID int64, hash string) error
 }
 
 type PrivilegeDAO interface {
@@ -82,22 +124,156 @@ type PrivilegeDAO interface {
 
 type SessionsDAO interface {
 	DAO[authModels.SessionsPrimaryKey, model.Sessions]
+	GetActiveByUser(userID int64, now time.Time) ([]*model.Sessions, error)
+	DeleteByID(userID int64, id int64) (int64, error)
+	DeleteByUser(userID int64) (int64, error)
+	DeleteExpired(now time.Time) (int64, error)
 }
 
 type PrivilegeLevelsDAO interface {
 	DAO[int64, model.PrivilegeLevels]
+	GetByName(name string) (*model.PrivilegeLevels, error)
+	GetSignupDefault() (*model.PrivilegeLevels, error)
+	LockAll(db qrm.Queryable) ([]*model.PrivilegeLevels, error)
 }
 
 type PrivilegeLevelsPrivilegesDAO interface {
 	DAO[authModels.PrivilegeLevelsPrivilegesPrimaryKey, model.PrivilegeLevelsPrivileges]
 }
 
+type PrivilegeLevelsPatternsDAO interface {
+	DAO[authModels.PrivilegeLevelsPatternsPrimaryKey, model.PrivilegeLevelsPatterns]
+}
+
+type OrganizationsDAO interface {
+	DAO[int64, model.Organizations]
+	GetBySlug(slug string) (*model.Organizations, error)
+	GetByUser(userID int64) ([]authModels.UserOrganizationJoin, error)
+}
+
+type OrganizationMembersDAO interface {
+	DAO[authModels.OrganizationMembersPrimaryKey, model.OrganizationMembers]
+	GetByOrganization(orgID int64) ([]authModels.OrganizationMemberJoin, error)
+	LockRole(orgID int64, role string, db qrm.Queryable) (int64, error)
+}
+
+type APIKeysDAO interface {
+	DAO[int64, model.APIKeys]
+	GetByHash(hash string) (*model.APIKeys, error)
+	GetByUser(userID int64, orgID int64) ([]*model.APIKeys, error)
+	Touch(id int64, now time.Time) error
+	DeleteByUser(userID int64, id int64) (int64, error)
+}
+
+type APIKeysPrivilegesDAO interface {
+	DAO[authModels.APIKeysPrivilegesPrimaryKey, model.APIKeysPrivileges]
+	GetScopes(keyIDs []int64) ([]authModels.APIKeyScope, error)
+}
+
+type AccountTokensDAO interface {
+	DAO[int64, model.AccountTokens]
+	Consume(hash string, purpose string, now time.Time) (*model.AccountTokens, error)
+	DeleteByUser(userID int64, purpose string) error
+}
+
+type UserIdentitiesDAO interface {
+	DAO[authModels.UserIdentitiesPrimaryKey, model.UserIdentities]
+}
+
+type OIDCLoginsDAO interface {
+	DAO[string, model.OidcLogins]
+	Take(state string, now time.Time) (*model.OidcLogins, error)
+	CountPending(now time.Time) (int64, error)
+	DeleteExpired(now time.Time) error
+}
+
+type LoginThrottlesDAO interface {
+	DAO[authModels.LoginThrottlesPrimaryKey, model.LoginThrottles]
+	RecordFailure(pk authModels.LoginThrottlesPrimaryKey, now time.Time, windowStart time.Time) (*model.LoginThrottles, error)
+	Lock(pk authModels.LoginThrottlesPrimaryKey, until time.Time) error
+	GetLocked(now time.Time) ([]*model.LoginThrottles, error)
+	DeleteStale(before time.Time) (int64, error)
+}
+
+type SecurityEventsDAO interface {
+	DAO[int64, model.SecurityEvents]
+	Search(filter authModels.SecurityEventFilter) ([]*model.SecurityEvents, error)
+}
+
+type AuditEventsDAO interface {
+	DAO[int64, model.AuditEvents]
+	Search(filter authModels.AuditEventFilter) ([]authModels.AuditEventJoin, error)
+}
+
+type ConversationsDAO interface {
+	OrgDAO[int64, agentModel.Conversations]
+}
+
+type ToolCallsDAO interface {
+	OrgDAO[int64, agentModel.ToolCalls]
+	Search(orgID int64, filter agentModels.ToolCallFilter) ([]*agentModel.ToolCalls, error)
+}
+
+type ProfilesDAO interface {
+	OrgDAO[int64, agentModel.Profiles]
+}
+
+type KnowledgeDocumentsDAO interface {
+	OrgDAO[int64, agentModel.KnowledgeDocuments]
+	GetByProfile(orgID int64, profileID int64) ([]*agentModel.KnowledgeDocuments, error)
+}
+
+type CampaignsDAO interface {
+	OrgDAO[int64, agentModel.Campaigns]
+	// GetByStatus is not limited to an organization, the scheduler dials for all of them
+	GetByStatus(status string) ([]*agentModel.Campaigns, error)
+}
+
+type PhoneNumbersDAO interface {
+	OrgDAO[int64, agentModel.PhoneNumbers]
+	// GetByNumber is not limited to an organization, numbers are unique across them
+	GetByNumber(number string) (*agentModel.PhoneNumbers, error)
+}
+
+type CampaignContactsDAO interface {
+	DAO[int64, agentModel.CampaignContacts]
+	GetByCampaign(campaignID int64) ([]*agentModel.CampaignContacts, error)
+	GetDue(campaignID int64, now time.Time, limit int64, db qrm.Queryable) ([]*agentModel.CampaignContacts, error)
+	CountByStatus(campaignID int64, statuses ...string) (int64, error)
+	GetByCallID(callID string) (*agentModel.CampaignContacts, error)
+	GetStaleCalling(before time.Time) ([]*agentModel.CampaignContacts, error)
+}
+
+type KnowledgeChunksDAO interface {
+	DAO[int64, agentModel.KnowledgeChunks]
+	Search(orgID int64, profileID int64, embeddingModel string, embedding string, limit int64) ([]agentModels.KnowledgeSearchResult, error)
+}
+
 type daoManager struct {
 	usersDAO                      UsersDAO
 	privilegesDAO                 PrivilegeDAO
 	privilegesLevelsDAO           PrivilegeLevelsDAO
 	sessionsDAO                   SessionsDAO
 	privilegesLevelsPrivilegesDAO PrivilegeLevelsPrivilegesDAO
+	privilegeLevelsPatternsDAO    PrivilegeLevelsPatternsDAO
+	conversationsDAO              ConversationsDAO
+	toolCallsDAO                  ToolCallsDAO
+	profilesDAO                   ProfilesDAO
+	knowledgeDocumentsDAO         KnowledgeDocumentsDAO
+	knowledgeChunksDAO            KnowledgeChunksDAO
+	campaignsDAO                  CampaignsDAO
+	campaignContactsDAO           CampaignContactsDAO
+	organizationsDAO              OrganizationsDAO
+	organizationMembersDAO        OrganizationMembersDAO
+	phoneNumbersDAO               PhoneNumbersDAO
+	apiKeysDAO                    APIKeysDAO
+	apiKeysPrivilegesDAO          APIKeysPrivilegesDAO
+	accountTokensDAO              AccountTokensDAO
+	userIdentitiesDAO             UserIdentitiesDAO
+	oidcLoginsDAO                 OIDCLoginsDAO
+	loginThrottlesDAO             LoginThrottlesDAO
+	securityEventsDAO             SecurityEventsDAO
+	auditEventsDAO                AuditEventsDAO
 	db                            *sql.DB
 }
 
@@ -141,3 +317,136 @@ func (dm *daoManager) PrivilegeLevelsPrivilegesDAO() PrivilegeLevelsPrivilegesDA
 	}
 	return dm.privilegesLevelsPrivilegesDAO
 }
+
+func (dm *daoManager) ConversationsDAO() ConversationsDAO {
+	if dm.conversationsDAO == nil {
+		dm.conversationsDAO = newConversationsDAO(dm.db)
+	}
+	return dm.conversationsDAO
+}
+
+func (dm *daoManager) ToolCallsDAO() ToolCallsDAO {
+	if dm.toolCallsDAO == nil {
+		dm.toolCallsDAO = newToolCallsDAO(dm.db)
+	}
+	return dm.toolCallsDAO
+}
+
+func (dm *daoManager) ProfilesDAO() ProfilesDAO {
+	if dm.profilesDAO == nil {
+		dm.profilesDAO = newProfilesDAO(dm.db)
+	}
+	return dm.profilesDAO
+}
+
+func (dm *daoManager) KnowledgeDocumentsDAO() KnowledgeDocumentsDAO {
+	if dm.knowledgeDocumentsDAO == nil {
+		dm.knowledgeDocumentsDAO = newKnowledgeDocumentsDAO(dm.db)
+	}
+	return dm.knowledgeDocumentsDAO
+}
+
+func (dm *daoManager) KnowledgeChunksDAO() KnowledgeChunksDAO {
+	if dm.knowledgeChunksDAO == nil {
+		dm.knowledgeChunksDAO = newKnowledgeChunksDAO(dm.db)
+	}
+	return dm.knowledgeChunksDAO
+}
+
+func (dm *daoManager) CampaignsDAO() CampaignsDAO {
+	if dm.campaignsDAO == nil {
+		dm.campaignsDAO = newCampaignsDAO(dm.db)
+	}
+	return dm.campaignsDAO
+}
+
+func (dm *daoManager) CampaignContactsDAO() CampaignContactsDAO {
+	if dm.campaignContactsDAO == nil {
+		dm.campaignContactsDAO = newCampaignContactsDAO(dm.db)
+	}
+	return dm.campaignContactsDAO
+}
+
+func (dm *daoManager) OrganizationsDAO() OrganizationsDAO {
+	if dm.organizationsDAO == nil {
+		dm.organizationsDAO = newOrganizationsDAO(dm.db)
+	}
+	return dm.organizationsDAO
+}
+
+func (dm *daoManager) OrganizationMembersDAO() OrganizationMembersDAO {
+	if dm.organizationMembersDAO == nil {
+		dm.organizationMembersDAO = newOrganizationMembersDAO(dm.db)
+	}
+	return dm.organizationMembersDAO
+}
+
+func (dm *daoManager) PhoneNumbersDAO() PhoneNumbersDAO {
+	if dm.phoneNumbersDAO == nil {
+		dm.phoneNumbersDAO = newPhoneNumbersDAO(dm.db)
+	}
+	return dm.phoneNumbersDAO
+}
+
+func (dm *daoManager) APIKeysDAO() APIKeysDAO {
+	if dm.apiKeysDAO == nil {
+		dm.apiKeysDAO = newAPIKeysDAO(dm.db)
+	}
+	return dm.apiKeysDAO
+}
+
+func (dm *daoManager) APIKeysPrivilegesDAO() APIKeysPrivilegesDAO {
+	if dm.apiKeysPrivilegesDAO == nil {
+		dm.apiKeysPrivilegesDAO = newAPIKeysPrivilegesDAO(dm.db)
+	}
+	return dm.apiKeysPrivilegesDAO
+}
+
+func (dm *daoManager) AccountTokensDAO() AccountTokensDAO {
+	if dm.accountTokensDAO == nil {
+		dm.accountTokensDAO = newAccountTokensDAO(dm.db)
+	}
+	return dm.accountTokensDAO
+}
+
+func (dm *daoManager) UserIdentitiesDAO() UserIdentitiesDAO {
+	if dm.userIdentitiesDAO == nil {
+		dm.userIdentitiesDAO = newUserIdentitiesDAO(dm.db)
+	}
+	return dm.userIdentitiesDAO
+}
+
+func (dm *daoManager) OIDCLoginsDAO() OIDCLoginsDAO {
+	if dm.oidcLoginsDAO == nil {
+		dm.oidcLoginsDAO = newOIDCLoginsDAO(dm.db)
+	}
+	return dm.oidcLoginsDAO
+}
+
+func (dm *daoManager) LoginThrottlesDAO() LoginThrottlesDAO {
+	if dm.loginThrottlesDAO == nil {
+		dm.loginThrottlesDAO = newLoginThrottlesDAO(dm.db)
+	}
+	return dm.loginThrottlesDAO
+}
+
+func (dm *daoManager) SecurityEventsDAO() SecurityEventsDAO {
+	if dm.securityEventsDAO == nil {
+		dm.securityEventsDAO = newSecurityEventsDAO(dm.db)
+	}
+	return dm.securityEventsDAO
+}
+
+func (dm *daoManager) AuditEventsDAO() AuditEventsDAO {
+	if dm.auditEventsDAO == nil {
+		dm.auditEventsDAO = newAuditEventsDAO(dm.db)
+	}
+	return dm.auditEventsDAO
+}
+
+func (dm *daoManager) PrivilegeLevelsPatternsDAO() PrivilegeLevelsPatternsDAO {
+	if dm.privilegeLevelsPatternsDAO == nil {
+		dm.privilegeLevelsPatternsDAO = newPrivilegeLevelsPatternsDAO(dm.db)
+	}
+	return dm.privilegeLevelsPatternsDAO
+}
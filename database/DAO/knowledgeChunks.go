@@ -0,0 +1,95 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type knowledgeChunksDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.KnowledgeChunks]
+}
+
+func newKnowledgeChunksDAO(db *sql.DB) *knowledgeChunksDAO {
+	dao := &knowledgeChunksDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.KnowledgeChunks](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *knowledgeChunksDAO) Table() PostgresTable {
+	return table.KnowledgeChunks
+}
+
+func (dao *knowledgeChunksDAO) InsertCols() postgres.ColumnList {
+	return table.KnowledgeChunks.AllColumns.Except(
+		table.KnowledgeChunks.ID,
+		table.KnowledgeChunks.CreatedAt,
+	)
+}
+
+func (dao *knowledgeChunksDAO) UpdateCols() postgres.ColumnList {
+	return table.KnowledgeChunks.AllColumns.Except(
+		table.KnowledgeChunks.ID,
+		table.KnowledgeChunks.CreatedAt,
+	)
+}
+
+func (dao *knowledgeChunksDAO) AllCols() postgres.ColumnList {
+	return table.KnowledgeChunks.AllColumns
+}
+
+func (dao *knowledgeChunksDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *knowledgeChunksDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *knowledgeChunksDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.KnowledgeChunks.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *knowledgeChunksDAO) GetUpdatedAt(row *model.KnowledgeChunks) *time.Time {
+	return nil
+}
+
+// Search returns the chunks of a profile in the organization most similar to
+// embedding, using only chunks embedded by the same model.
+func (dao *knowledgeChunksDAO) Search(orgID int64, profileID int64, embeddingModel string, embedding string, limit int64) ([]agentModels.KnowledgeSearchResult, error) {
+	similarity := postgres.RawFloat(
+		"agent.cosine_similarity(knowledge_chunks.embedding, CAST(#embedding AS REAL[]))",
+		postgres.RawArgs{"#embedding": embedding},
+	)
+	var res []agentModels.KnowledgeSearchResult
+	err := table.KnowledgeChunks.
+		INNER_JOIN(table.KnowledgeDocuments, table.KnowledgeDocuments.ID.EQ(table.KnowledgeChunks.DocumentID)).
+		SELECT(
+			table.KnowledgeChunks.DocumentID.AS("KnowledgeSearchResult.DocumentID"),
+			table.KnowledgeDocuments.Title.AS("KnowledgeSearchResult.Title"),
+			table.KnowledgeChunks.ChunkIndex.AS("KnowledgeSearchResult.ChunkIndex"),
+			table.KnowledgeChunks.Content.AS("KnowledgeSearchResult.Content"),
+			similarity.AS("KnowledgeSearchResult.Score"),
+		).
+		WHERE(
+			table.KnowledgeChunks.ProfileID.EQ(postgres.Int(profileID)).
+				AND(table.KnowledgeDocuments.OrganizationID.EQ(postgres.Int(orgID))).
+				AND(table.KnowledgeChunks.EmbeddingModel.EQ(postgres.String(embeddingModel))),
+		).
+		ORDER_BY(similarity.DESC()).
+		LIMIT(limit).
+		Query(dao.db, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
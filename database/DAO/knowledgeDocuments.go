@@ -0,0 +1,88 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type knowledgeDocumentsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.KnowledgeDocuments]
+	OrgDAOBaseQueries[int64, model.KnowledgeDocuments]
+}
+
+func newKnowledgeDocumentsDAO(db *sql.DB) *knowledgeDocumentsDAO {
+	dao := &knowledgeDocumentsDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.KnowledgeDocuments](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.KnowledgeDocuments](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *knowledgeDocumentsDAO) Table() PostgresTable {
+	return table.KnowledgeDocuments
+}
+
+func (dao *knowledgeDocumentsDAO) InsertCols() postgres.ColumnList {
+	return table.KnowledgeDocuments.AllColumns.Except(
+		table.KnowledgeDocuments.ID,
+		table.KnowledgeDocuments.CreatedAt,
+		table.KnowledgeDocuments.UpdatedAt,
+	)
+}
+
+func (dao *knowledgeDocumentsDAO) UpdateCols() postgres.ColumnList {
+	return table.KnowledgeDocuments.AllColumns.Except(
+		table.KnowledgeDocuments.ID,
+		table.KnowledgeDocuments.CreatedAt,
+	)
+}
+
+func (dao *knowledgeDocumentsDAO) AllCols() postgres.ColumnList {
+	return table.KnowledgeDocuments.AllColumns
+}
+
+func (dao *knowledgeDocumentsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *knowledgeDocumentsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *knowledgeDocumentsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.KnowledgeDocuments.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *knowledgeDocumentsDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.KnowledgeDocuments.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *knowledgeDocumentsDAO) GetUpdatedAt(row *model.KnowledgeDocuments) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *knowledgeDocumentsDAO) GetByProfile(orgID int64, profileID int64) ([]*model.KnowledgeDocuments, error) {
+	var rows []*model.KnowledgeDocuments
+	err := table.KnowledgeDocuments.
+		SELECT(table.KnowledgeDocuments.AllColumns).
+		WHERE(
+			table.KnowledgeDocuments.ProfileID.EQ(postgres.Int(profileID)).
+				AND(dao.OrgMatch(orgID)),
+		).
+		ORDER_BY(table.KnowledgeDocuments.CreatedAt.DESC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,131 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type loginThrottlesDAO struct {
+	db *sql.DB
+	DAOBaseQueries[authModels.LoginThrottlesPrimaryKey, model.LoginThrottles]
+}
+
+func newLoginThrottlesDAO(db *sql.DB) *loginThrottlesDAO {
+	dao := &loginThrottlesDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[authModels.LoginThrottlesPrimaryKey, model.LoginThrottles](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *loginThrottlesDAO) Table() PostgresTable {
+	return table.LoginThrottles
+}
+
+func (dao *loginThrottlesDAO) InsertCols() postgres.ColumnList {
+	return table.LoginThrottles.AllColumns
+}
+
+func (dao *loginThrottlesDAO) UpdateCols() postgres.ColumnList {
+	return table.LoginThrottles.AllColumns.Except(
+		table.LoginThrottles.Kind,
+		table.LoginThrottles.Key,
+	)
+}
+
+func (dao *loginThrottlesDAO) AllCols() postgres.ColumnList {
+	return table.LoginThrottles.AllColumns
+}
+
+func (dao *loginThrottlesDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *loginThrottlesDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *loginThrottlesDAO) PKMatch(pk authModels.LoginThrottlesPrimaryKey) postgres.BoolExpression {
+	return table.LoginThrottles.
+		Kind.EQ(postgres.String(pk.Kind)).
+		AND(table.LoginThrottles.Key.EQ(postgres.String(pk.Key)))
+}
+
+func (dao *loginThrottlesDAO) GetUpdatedAt(row *model.LoginThrottles) *time.Time {
+	return nil
+}
+
+// RecordFailure counts a failed login in one statement so replicas counting at
+// the same time don't lose failures. The count starts over when the last failure
+// was before windowStart.
+func (dao *loginThrottlesDAO) RecordFailure(pk authModels.LoginThrottlesPrimaryKey, now time.Time, windowStart time.Time) (*model.LoginThrottles, error) {
+	t := table.LoginThrottles
+	var row model.LoginThrottles
+	err := t.
+		INSERT(t.Kind, t.Key, t.Failures, t.LastFailureAt).
+		VALUES(pk.Kind, pk.Key, 1, now).
+		ON_CONFLICT(t.Kind, t.Key).
+		DO_UPDATE(postgres.SET(
+			t.Failures.SET(postgres.IntExp(
+				postgres.CASE().
+					WHEN(t.LastFailureAt.LT(postgres.TimestampT(windowStart))).
+					THEN(postgres.Int(1)).
+					ELSE(t.Failures.ADD(postgres.Int(1))),
+			)),
+			t.LastFailureAt.SET(postgres.TimestampT(now)),
+		)).
+		RETURNING(t.AllColumns).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (dao *loginThrottlesDAO) Lock(pk authModels.LoginThrottlesPrimaryKey, until time.Time) error {
+	_, err := table.LoginThrottles.
+		UPDATE(table.LoginThrottles.LockedUntil).
+		SET(postgres.TimestampT(until)).
+		WHERE(dao.PKMatch(pk)).
+		Exec(dao.db)
+	return err
+}
+
+// GetLocked returns the throttles still locked at now, soonest to unlock first
+func (dao *loginThrottlesDAO) GetLocked(now time.Time) ([]*model.LoginThrottles, error) {
+	var rows []*model.LoginThrottles
+	err := table.LoginThrottles.
+		SELECT(table.LoginThrottles.AllColumns).
+		WHERE(table.LoginThrottles.LockedUntil.GT(postgres.TimestampT(now))).
+		ORDER_BY(table.LoginThrottles.LockedUntil.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// DeleteStale removes throttles with no failures since before and no lock left
+func (dao *loginThrottlesDAO) DeleteStale(before time.Time) (int64, error) {
+	res, err := table.LoginThrottles.
+		DELETE().
+		WHERE(
+			table.LoginThrottles.LastFailureAt.LT(postgres.TimestampT(before)).
+				AND(
+					table.LoginThrottles.LockedUntil.IS_NULL().
+						OR(table.LoginThrottles.LockedUntil.LT(postgres.TimestampT(before))),
+				),
+		).
+		Exec(dao.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
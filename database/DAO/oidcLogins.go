@@ -0,0 +1,100 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type oidcLoginsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[string, model.OidcLogins]
+}
+
+func newOIDCLoginsDAO(db *sql.DB) *oidcLoginsDAO {
+	dao := &oidcLoginsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[string, model.OidcLogins](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *oidcLoginsDAO) Table() PostgresTable {
+	return table.OidcLogins
+}
+
+func (dao *oidcLoginsDAO) InsertCols() postgres.ColumnList {
+	return table.OidcLogins.AllColumns.Except(
+		table.OidcLogins.CreatedAt,
+	)
+}
+
+func (dao *oidcLoginsDAO) UpdateCols() postgres.ColumnList {
+	return table.OidcLogins.AllColumns.Except(
+		table.OidcLogins.State,
+		table.OidcLogins.CreatedAt,
+	)
+}
+
+func (dao *oidcLoginsDAO) AllCols() postgres.ColumnList {
+	return table.OidcLogins.AllColumns
+}
+
+func (dao *oidcLoginsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *oidcLoginsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *oidcLoginsDAO) PKMatch(pk string) postgres.BoolExpression {
+	return table.OidcLogins.State.EQ(postgres.String(pk))
+}
+
+func (dao *oidcLoginsDAO) GetUpdatedAt(row *model.OidcLogins) *time.Time {
+	return nil
+}
+
+// Take deletes the unexpired login with state and returns it, so a login
+// finishes once even when the callback is replayed
+func (dao *oidcLoginsDAO) Take(state string, now time.Time) (*model.OidcLogins, error) {
+	var row model.OidcLogins
+	err := table.OidcLogins.
+		DELETE().
+		WHERE(
+			table.OidcLogins.State.EQ(postgres.String(state)).
+				AND(table.OidcLogins.ExpiresAt.GT(postgres.TimestampT(now))),
+		).
+		RETURNING(table.OidcLogins.AllColumns).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CountPending counts the logins that have not expired by now
+func (dao *oidcLoginsDAO) CountPending(now time.Time) (int64, error) {
+	var res struct {
+		Count int64
+	}
+	err := table.OidcLogins.
+		SELECT(postgres.COUNT(table.OidcLogins.State).AS("Count")).
+		WHERE(table.OidcLogins.ExpiresAt.GT(postgres.TimestampT(now))).
+		Query(dao.db, &res)
+	return res.Count, err
+}
+
+func (dao *oidcLoginsDAO) DeleteExpired(now time.Time) error {
+	_, err := table.OidcLogins.
+		DELETE().
+		WHERE(table.OidcLogins.ExpiresAt.LT_EQ(postgres.TimestampT(now))).
+		Exec(dao.db)
+	return err
+}
@@ -0,0 +1,106 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type organizationMembersDAO struct {
+	db *sql.DB
+	DAOBaseQueries[authModels.OrganizationMembersPrimaryKey, model.OrganizationMembers]
+}
+
+func newOrganizationMembersDAO(db *sql.DB) *organizationMembersDAO {
+	dao := &organizationMembersDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[authModels.OrganizationMembersPrimaryKey, model.OrganizationMembers](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *organizationMembersDAO) Table() PostgresTable {
+	return table.OrganizationMembers
+}
+
+func (dao *organizationMembersDAO) InsertCols() postgres.ColumnList {
+	return table.OrganizationMembers.AllColumns.Except(
+		table.OrganizationMembers.CreatedAt,
+		table.OrganizationMembers.UpdatedAt,
+	)
+}
+
+func (dao *organizationMembersDAO) UpdateCols() postgres.ColumnList {
+	return table.OrganizationMembers.AllColumns.Except(
+		table.OrganizationMembers.CreatedAt,
+		table.OrganizationMembers.OrganizationID,
+		table.OrganizationMembers.UserID,
+	)
+}
+
+func (dao *organizationMembersDAO) AllCols() postgres.ColumnList {
+	return table.OrganizationMembers.AllColumns
+}
+
+func (dao *organizationMembersDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *organizationMembersDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *organizationMembersDAO) PKMatch(pk authModels.OrganizationMembersPrimaryKey) postgres.BoolExpression {
+	return table.OrganizationMembers.
+		OrganizationID.EQ(postgres.Int(pk.OrganizationID)).
+		AND(table.OrganizationMembers.UserID.EQ(postgres.Int(pk.UserID)))
+}
+
+func (dao *organizationMembersDAO) GetUpdatedAt(row *model.OrganizationMembers) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *organizationMembersDAO) GetByOrganization(orgID int64) ([]authModels.OrganizationMemberJoin, error) {
+	var rows []authModels.OrganizationMemberJoin
+	err := table.OrganizationMembers.
+		INNER_JOIN(table.Users, table.Users.ID.EQ(table.OrganizationMembers.UserID)).
+		SELECT(
+			table.OrganizationMembers.AllColumns,
+			table.Users.Email.AS("OrganizationMemberJoin.Email"),
+			table.Users.FirstName.AS("OrganizationMemberJoin.FirstName"),
+			table.Users.LastName.AS("OrganizationMemberJoin.LastName"),
+		).
+		WHERE(table.OrganizationMembers.OrganizationID.EQ(postgres.Int(orgID))).
+		ORDER_BY(table.Users.Email.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// LockRole locks the organization's members with role FOR UPDATE and counts
+// them, so db should be a transaction that changes one of them before
+// committing. Postgres can't lock rows under an aggregate, hence the count in Go.
+func (dao *organizationMembersDAO) LockRole(orgID int64, role string, db qrm.Queryable) (int64, error) {
+	var rows []model.OrganizationMembers
+	err := table.OrganizationMembers.
+		SELECT(table.OrganizationMembers.UserID).
+		WHERE(
+			table.OrganizationMembers.OrganizationID.EQ(postgres.Int(orgID)).
+				AND(table.OrganizationMembers.Role.EQ(postgres.String(role))),
+		).
+		FOR(postgres.UPDATE()).
+		Query(db, &rows)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
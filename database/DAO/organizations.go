@@ -0,0 +1,96 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type organizationsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.Organizations]
+}
+
+func newOrganizationsDAO(db *sql.DB) *organizationsDAO {
+	dao := &organizationsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.Organizations](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *organizationsDAO) Table() PostgresTable {
+	return table.Organizations
+}
+
+func (dao *organizationsDAO) InsertCols() postgres.ColumnList {
+	return table.Organizations.AllColumns.Except(
+		table.Organizations.ID,
+		table.Organizations.CreatedAt,
+		table.Organizations.UpdatedAt,
+	)
+}
+
+func (dao *organizationsDAO) UpdateCols() postgres.ColumnList {
+	return table.Organizations.AllColumns.Except(
+		table.Organizations.ID,
+		table.Organizations.CreatedAt,
+	)
+}
+
+func (dao *organizationsDAO) AllCols() postgres.ColumnList {
+	return table.Organizations.AllColumns
+}
+
+func (dao *organizationsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *organizationsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *organizationsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.Organizations.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *organizationsDAO) GetUpdatedAt(row *model.Organizations) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *organizationsDAO) GetBySlug(slug string) (*model.Organizations, error) {
+	var row model.Organizations
+	err := table.Organizations.
+		SELECT(table.Organizations.AllColumns).
+		WHERE(table.Organizations.Slug.EQ(postgres.String(slug))).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetByUser returns the organizations the user is a member of with their role in each
+func (dao *organizationsDAO) GetByUser(userID int64) ([]authModels.UserOrganizationJoin, error) {
+	var rows []authModels.UserOrganizationJoin
+	err := table.Organizations.
+		INNER_JOIN(table.OrganizationMembers, table.OrganizationMembers.OrganizationID.EQ(table.Organizations.ID)).
+		SELECT(
+			table.Organizations.AllColumns,
+			table.OrganizationMembers.Role.AS("UserOrganizationJoin.Role"),
+		).
+		WHERE(table.OrganizationMembers.UserID.EQ(postgres.Int(userID))).
+		ORDER_BY(table.Organizations.Name.ASC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
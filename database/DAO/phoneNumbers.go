@@ -0,0 +1,85 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type phoneNumbersDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.PhoneNumbers]
+	OrgDAOBaseQueries[int64, model.PhoneNumbers]
+}
+
+func newPhoneNumbersDAO(db *sql.DB) *phoneNumbersDAO {
+	dao := &phoneNumbersDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.PhoneNumbers](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.PhoneNumbers](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *phoneNumbersDAO) Table() PostgresTable {
+	return table.PhoneNumbers
+}
+
+func (dao *phoneNumbersDAO) InsertCols() postgres.ColumnList {
+	return table.PhoneNumbers.AllColumns.Except(
+		table.PhoneNumbers.ID,
+		table.PhoneNumbers.CreatedAt,
+		table.PhoneNumbers.UpdatedAt,
+	)
+}
+
+func (dao *phoneNumbersDAO) UpdateCols() postgres.ColumnList {
+	return table.PhoneNumbers.AllColumns.Except(
+		table.PhoneNumbers.ID,
+		table.PhoneNumbers.CreatedAt,
+	)
+}
+
+func (dao *phoneNumbersDAO) AllCols() postgres.ColumnList {
+	return table.PhoneNumbers.AllColumns
+}
+
+func (dao *phoneNumbersDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *phoneNumbersDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *phoneNumbersDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.PhoneNumbers.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *phoneNumbersDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.PhoneNumbers.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *phoneNumbersDAO) GetUpdatedAt(row *model.PhoneNumbers) *time.Time {
+	return row.UpdatedAt
+}
+
+func (dao *phoneNumbersDAO) GetByNumber(number string) (*model.PhoneNumbers, error) {
+	var row model.PhoneNumbers
+	err := table.PhoneNumbers.
+		SELECT(table.PhoneNumbers.AllColumns).
+		WHERE(table.PhoneNumbers.Number.EQ(postgres.String(number))).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
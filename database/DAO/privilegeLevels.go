@@ -7,6 +7,7 @@ import (
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/gen/go_db/auth/table"
 	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
 )
 
 type privilegeLevelsDAO struct {
@@ -64,3 +65,45 @@ func (dao *privilegeLevelsDAO) PKMatch(pk int64) postgres.BoolExpression {
 func (dao *privilegeLevelsDAO) GetUpdatedAt(row *model.PrivilegeLevels) *time.Time {
 	return row.UpdatedAt
 }
+
+func (dao *privilegeLevelsDAO) GetByName(name string) (*model.PrivilegeLevels, error) {
+	var row model.PrivilegeLevels
+	err := table.PrivilegeLevels.
+		SELECT(table.PrivilegeLevels.AllColumns).
+		WHERE(table.PrivilegeLevels.Name.EQ(postgres.String(name))).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetSignupDefault returns the level new users sign up with
+func (dao *privilegeLevelsDAO) GetSignupDefault() (*model.PrivilegeLevels, error) {
+	var row model.PrivilegeLevels
+	err := table.PrivilegeLevels.
+		SELECT(table.PrivilegeLevels.AllColumns).
+		WHERE(table.PrivilegeLevels.SignupDefault.IS_TRUE()).
+		LIMIT(1).
+		Query(dao.db, &row)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// LockAll returns every level, locked FOR UPDATE so db should be a transaction
+// that changes the hierarchy before committing
+func (dao *privilegeLevelsDAO) LockAll(db qrm.Queryable) ([]*model.PrivilegeLevels, error) {
+	var rows []*model.PrivilegeLevels
+	err := table.PrivilegeLevels.
+		SELECT(table.PrivilegeLevels.AllColumns).
+		ORDER_BY(table.PrivilegeLevels.ID.ASC()).
+		FOR(postgres.UPDATE()).
+		Query(db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
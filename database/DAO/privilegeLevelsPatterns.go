@@ -0,0 +1,69 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type privilegeLevelsPatternsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[authModels.PrivilegeLevelsPatternsPrimaryKey, model.PrivilegeLevelsPatterns]
+}
+
+func newPrivilegeLevelsPatternsDAO(db *sql.DB) *privilegeLevelsPatternsDAO {
+	dao := &privilegeLevelsPatternsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[authModels.PrivilegeLevelsPatternsPrimaryKey, model.PrivilegeLevelsPatterns](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *privilegeLevelsPatternsDAO) Table() PostgresTable {
+	return table.PrivilegeLevelsPatterns
+}
+
+func (dao *privilegeLevelsPatternsDAO) InsertCols() postgres.ColumnList {
+	return table.PrivilegeLevelsPatterns.AllColumns.Except(
+		table.PrivilegeLevelsPatterns.CreatedAt,
+	)
+}
+
+func (dao *privilegeLevelsPatternsDAO) UpdateCols() postgres.ColumnList {
+	return table.PrivilegeLevelsPatterns.AllColumns.Except(
+		table.PrivilegeLevelsPatterns.CreatedAt,
+	)
+}
+
+func (dao *privilegeLevelsPatternsDAO) AllCols() postgres.ColumnList {
+	return table.PrivilegeLevelsPatterns.AllColumns
+}
+
+func (dao *privilegeLevelsPatternsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{
+		table.PrivilegeLevelsPatterns.PrivilegeLevelID,
+		table.PrivilegeLevelsPatterns.Pattern,
+	}
+}
+
+func (dao *privilegeLevelsPatternsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{
+		table.PrivilegeLevelsPatterns.Pattern.SET(table.PrivilegeLevelsPatterns.Pattern),
+	}
+}
+
+func (dao *privilegeLevelsPatternsDAO) PKMatch(pk authModels.PrivilegeLevelsPatternsPrimaryKey) postgres.BoolExpression {
+	return table.PrivilegeLevelsPatterns.
+		PrivilegeLevelID.EQ(postgres.Int(pk.PrivilegeLevelID)).
+		AND(table.PrivilegeLevelsPatterns.Pattern.EQ(postgres.String(pk.Pattern)))
+}
+
+func (dao *privilegeLevelsPatternsDAO) GetUpdatedAt(row *model.PrivilegeLevelsPatterns) *time.Time {
+	return nil
+}
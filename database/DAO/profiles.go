@@ -0,0 +1,77 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type profilesDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.Profiles]
+	OrgDAOBaseQueries[int64, model.Profiles]
+}
+
+func newProfilesDAO(db *sql.DB) *profilesDAO {
+	dao := &profilesDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.Profiles](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.Profiles](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *profilesDAO) Table() PostgresTable {
+	return table.Profiles
+}
+
+func (dao *profilesDAO) InsertCols() postgres.ColumnList {
+	return table.Profiles.AllColumns.Except(
+		table.Profiles.ID,
+		table.Profiles.CreatedAt,
+		table.Profiles.UpdatedAt,
+	)
+}
+
+func (dao *profilesDAO) UpdateCols() postgres.ColumnList {
+	return table.Profiles.AllColumns.Except(
+		table.Profiles.ID,
+		table.Profiles.CreatedAt,
+	)
+}
+
+func (dao *profilesDAO) AllCols() postgres.ColumnList {
+	return table.Profiles.AllColumns
+}
+
+func (dao *profilesDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{table.Profiles.OrganizationID, table.Profiles.Name}
+}
+
+func (dao *profilesDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{
+		table.Profiles.Prompt.SET(table.Profiles.EXCLUDED.Prompt),
+		table.Profiles.VoicemailScript.SET(table.Profiles.EXCLUDED.VoicemailScript),
+		table.Profiles.VoicemailAction.SET(table.Profiles.EXCLUDED.VoicemailAction),
+		table.Profiles.UpdatedAt.SET(table.Profiles.EXCLUDED.UpdatedAt),
+	}
+}
+
+func (dao *profilesDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.Profiles.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *profilesDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.Profiles.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *profilesDAO) GetUpdatedAt(row *model.Profiles) *time.Time {
+	return row.UpdatedAt
+}
@@ -0,0 +1,91 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type securityEventsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.SecurityEvents]
+}
+
+func newSecurityEventsDAO(db *sql.DB) *securityEventsDAO {
+	dao := &securityEventsDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.SecurityEvents](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *securityEventsDAO) Table() PostgresTable {
+	return table.SecurityEvents
+}
+
+func (dao *securityEventsDAO) InsertCols() postgres.ColumnList {
+	return table.SecurityEvents.AllColumns.Except(
+		table.SecurityEvents.ID,
+		table.SecurityEvents.CreatedAt,
+	)
+}
+
+func (dao *securityEventsDAO) UpdateCols() postgres.ColumnList {
+	return table.SecurityEvents.AllColumns.Except(
+		table.SecurityEvents.ID,
+		table.SecurityEvents.CreatedAt,
+	)
+}
+
+func (dao *securityEventsDAO) AllCols() postgres.ColumnList {
+	return table.SecurityEvents.AllColumns
+}
+
+func (dao *securityEventsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *securityEventsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *securityEventsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.SecurityEvents.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *securityEventsDAO) GetUpdatedAt(row *model.SecurityEvents) *time.Time {
+	return nil
+}
+
+// Search returns the newest events matching filter
+func (dao *securityEventsDAO) Search(filter authModels.SecurityEventFilter) ([]*model.SecurityEvents, error) {
+	where := postgres.Bool(true)
+	if filter.Kind != "" {
+		where = where.AND(table.SecurityEvents.Kind.EQ(postgres.String(filter.Kind)))
+	}
+	if filter.Email != "" {
+		where = where.AND(table.SecurityEvents.Email.EQ(postgres.String(filter.Email)))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var rows []*model.SecurityEvents
+	err := table.SecurityEvents.
+		SELECT(table.SecurityEvents.AllColumns).
+		WHERE(where).
+		ORDER_BY(table.SecurityEvents.CreatedAt.DESC(), table.SecurityEvents.ID.DESC()).
+		LIMIT(limit).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -31,12 +31,14 @@ func (dao *sessionsDAO) Table() PostgresTable {
 
 func (dao *sessionsDAO) InsertCols() postgres.ColumnList {
 	return table.Sessions.AllColumns.Except(
+		table.Sessions.ID,
 		table.Sessions.CreatedAt,
 	)
 }
 
 func (dao *sessionsDAO) UpdateCols() postgres.ColumnList {
 	return table.Sessions.AllColumns.Except(
+		table.Sessions.ID,
 		table.Sessions.CreatedAt,
 		table.Sessions.UserID,
 		table.Sessions.Token,
@@ -64,3 +66,56 @@ func (dao *sessionsDAO) PKMatch(pk authModels.SessionsPrimaryKey) postgres.BoolE
 func (dao *sessionsDAO) GetUpdatedAt(row *model.Sessions) *time.Time {
 	return nil
 }
+
+// GetActiveByUser returns the user's sessions that have not expired, most recently used first
+func (dao *sessionsDAO) GetActiveByUser(userID int64, now time.Time) ([]*model.Sessions, error) {
+	var rows []*model.Sessions
+	err := table.Sessions.
+		SELECT(table.Sessions.AllColumns).
+		WHERE(
+			table.Sessions.UserID.EQ(postgres.Int(userID)).
+				AND(table.Sessions.ExpiresAt.IS_NULL().OR(table.Sessions.ExpiresAt.GT(postgres.TimestampT(now)))),
+		).
+		ORDER_BY(table.Sessions.LastSeenAt.DESC()).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (dao *sessionsDAO) DeleteByID(userID int64, id int64) (int64, error) {
+	res, err := table.Sessions.
+		DELETE().
+		WHERE(
+			table.Sessions.UserID.EQ(postgres.Int(userID)).
+				AND(table.Sessions.ID.EQ(postgres.Int(id))),
+		).
+		Exec(dao.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (dao *sessionsDAO) DeleteByUser(userID int64) (int64, error) {
+	res, err := table.Sessions.
+		DELETE().
+		WHERE(table.Sessions.UserID.EQ(postgres.Int(userID))).
+		Exec(dao.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (dao *sessionsDAO) DeleteExpired(now time.Time) (int64, error) {
+	res, err := table.Sessions.
+		DELETE().
+		WHERE(table.Sessions.ExpiresAt.LT_EQ(postgres.TimestampT(now))).
+		Exec(dao.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,104 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/agent/table"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type toolCallsDAO struct {
+	db *sql.DB
+	DAOBaseQueries[int64, model.ToolCalls]
+	OrgDAOBaseQueries[int64, model.ToolCalls]
+}
+
+func newToolCallsDAO(db *sql.DB) *toolCallsDAO {
+	dao := &toolCallsDAO{
+		db:                db,
+		DAOBaseQueries:    nil,
+		OrgDAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[int64, model.ToolCalls](dao)
+	dao.DAOBaseQueries = &queries
+	orgQueries := newOrgDAOQueryable[int64, model.ToolCalls](dao)
+	dao.OrgDAOBaseQueries = &orgQueries
+	return dao
+}
+
+func (dao *toolCallsDAO) Table() PostgresTable {
+	return table.ToolCalls
+}
+
+func (dao *toolCallsDAO) InsertCols() postgres.ColumnList {
+	return table.ToolCalls.AllColumns.Except(
+		table.ToolCalls.ID,
+		table.ToolCalls.CreatedAt,
+	)
+}
+
+func (dao *toolCallsDAO) UpdateCols() postgres.ColumnList {
+	return table.ToolCalls.AllColumns.Except(
+		table.ToolCalls.ID,
+		table.ToolCalls.CreatedAt,
+	)
+}
+
+func (dao *toolCallsDAO) AllCols() postgres.ColumnList {
+	return table.ToolCalls.AllColumns
+}
+
+func (dao *toolCallsDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *toolCallsDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *toolCallsDAO) PKMatch(pk int64) postgres.BoolExpression {
+	return table.ToolCalls.ID.EQ(postgres.Int(pk))
+}
+
+func (dao *toolCallsDAO) OrgMatch(orgID int64) postgres.BoolExpression {
+	return table.ToolCalls.OrganizationID.EQ(postgres.Int(orgID))
+}
+
+func (dao *toolCallsDAO) GetUpdatedAt(row *model.ToolCalls) *time.Time {
+	return nil
+}
+
+func (dao *toolCallsDAO) Search(orgID int64, filter agentModels.ToolCallFilter) ([]*model.ToolCalls, error) {
+	where := dao.OrgMatch(orgID)
+	if filter.ToolName != "" {
+		where = where.AND(table.ToolCalls.ToolName.EQ(postgres.String(filter.ToolName)))
+	}
+	if filter.UserID != nil {
+		where = where.AND(table.ToolCalls.UserID.EQ(postgres.Int(*filter.UserID)))
+	}
+	if filter.ConversationID != nil {
+		where = where.AND(table.ToolCalls.ConversationID.EQ(postgres.Int(*filter.ConversationID)))
+	}
+	if filter.ErrorsOnly {
+		where = where.AND(table.ToolCalls.Error.IS_NOT_NULL())
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var rows []*model.ToolCalls
+	err := table.ToolCalls.
+		SELECT(table.ToolCalls.AllColumns).
+		WHERE(where).
+		ORDER_BY(table.ToolCalls.CreatedAt.DESC()).
+		LIMIT(limit).
+		Query(dao.db, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,66 @@
+package DAO
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+type userIdentitiesDAO struct {
+	db *sql.DB
+	DAOBaseQueries[authModels.UserIdentitiesPrimaryKey, model.UserIdentities]
+}
+
+func newUserIdentitiesDAO(db *sql.DB) *userIdentitiesDAO {
+	dao := &userIdentitiesDAO{
+		db:             db,
+		DAOBaseQueries: nil,
+	}
+	queries := newDAOQueryable[authModels.UserIdentitiesPrimaryKey, model.UserIdentities](dao)
+	dao.DAOBaseQueries = &queries
+	return dao
+}
+
+func (dao *userIdentitiesDAO) Table() PostgresTable {
+	return table.UserIdentities
+}
+
+func (dao *userIdentitiesDAO) InsertCols() postgres.ColumnList {
+	return table.UserIdentities.AllColumns.Except(
+		table.UserIdentities.CreatedAt,
+	)
+}
+
+func (dao *userIdentitiesDAO) UpdateCols() postgres.ColumnList {
+	return table.UserIdentities.AllColumns.Except(
+		table.UserIdentities.CreatedAt,
+		table.UserIdentities.Issuer,
+		table.UserIdentities.Subject,
+	)
+}
+
+func (dao *userIdentitiesDAO) AllCols() postgres.ColumnList {
+	return table.UserIdentities.AllColumns
+}
+
+func (dao *userIdentitiesDAO) OnConflictCols() postgres.ColumnList {
+	return []postgres.Column{}
+}
+
+func (dao *userIdentitiesDAO) UpdateOnConflictCols() []postgres.ColumnAssigment {
+	return []postgres.ColumnAssigment{}
+}
+
+func (dao *userIdentitiesDAO) PKMatch(pk authModels.UserIdentitiesPrimaryKey) postgres.BoolExpression {
+	return table.UserIdentities.
+		Issuer.EQ(postgres.String(pk.Issuer)).
+		AND(table.UserIdentities.Subject.EQ(postgres.String(pk.Subject)))
+}
+
+func (dao *userIdentitiesDAO) GetUpdatedAt(row *model.UserIdentities) *time.Time {
+	return nil
+}
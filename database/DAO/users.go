@@ -109,3 +109,12 @@ func (dao *usersDAO) GetUserPrivilegeJoinAll() (*[]authModels.UserPrivilegeLevel
 	}
 	return &rows, nil
 }
+
+func (dao *usersDAO) SetPassword(userID int64, hash string) error {
+	_, err := table.Users.
+		UPDATE(table.Users.Password, table.Users.UpdatedAt).
+		SET(postgres.String(hash), postgres.TimestampT(time.Now())).
+		WHERE(table.Users.ID.EQ(postgres.Int(userID))).
+		Exec(dao.db)
+	return err
+}
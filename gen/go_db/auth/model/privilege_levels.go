@@ -12,8 +12,10 @@ import (
 )
 
 type PrivilegeLevels struct {
-	ID        int64 `sql:"primary_key"`
-	Name      string
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID            int64 `sql:"primary_key"`
+	Name          string
+	CreatedAt     *time.Time
+	UpdatedAt     *time.Time
+	ParentID      *int64
+	SignupDefault bool
 }
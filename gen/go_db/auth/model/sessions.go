@@ -12,8 +12,13 @@ import (
 )
 
 type Sessions struct {
-	UserID    int64  `sql:"primary_key"`
-	Token     string `sql:"primary_key"`
-	ExpiresAt *time.Time
-	CreatedAt *time.Time
+	UserID         int64  `sql:"primary_key"`
+	Token          string `sql:"primary_key"`
+	ExpiresAt      *time.Time
+	CreatedAt      *time.Time
+	OrganizationID *int64
+	LastSeenAt     *time.Time
+	UserAgent      string
+	IPAddress      string
+	ID             int64
 }
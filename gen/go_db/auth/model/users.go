@@ -20,4 +20,5 @@ type Users struct {
 	PrivilegeLevelID int64
 	CreatedAt        *time.Time
 	UpdatedAt        *time.Time
+	EmailVerifiedAt  *time.Time
 }
@@ -17,10 +17,12 @@ type privilegeLevelsTable struct {
 	postgres.Table
 
 	// Columns
-	ID        postgres.ColumnInteger
-	Name      postgres.ColumnString
-	CreatedAt postgres.ColumnTimestamp
-	UpdatedAt postgres.ColumnTimestamp
+	ID            postgres.ColumnInteger
+	Name          postgres.ColumnString
+	CreatedAt     postgres.ColumnTimestamp
+	UpdatedAt     postgres.ColumnTimestamp
+	ParentID      postgres.ColumnInteger
+	SignupDefault postgres.ColumnBool
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -61,22 +63,26 @@ func newPrivilegeLevelsTable(schemaName, tableName, alias string) *PrivilegeLeve
 
 func newPrivilegeLevelsTableImpl(schemaName, tableName, alias string) privilegeLevelsTable {
 	var (
-		IDColumn        = postgres.IntegerColumn("id")
-		NameColumn      = postgres.StringColumn("name")
-		CreatedAtColumn = postgres.TimestampColumn("created_at")
-		UpdatedAtColumn = postgres.TimestampColumn("updated_at")
-		allColumns      = postgres.ColumnList{IDColumn, NameColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns  = postgres.ColumnList{NameColumn, CreatedAtColumn, UpdatedAtColumn}
+		IDColumn            = postgres.IntegerColumn("id")
+		NameColumn          = postgres.StringColumn("name")
+		CreatedAtColumn     = postgres.TimestampColumn("created_at")
+		UpdatedAtColumn     = postgres.TimestampColumn("updated_at")
+		ParentIDColumn      = postgres.IntegerColumn("parent_id")
+		SignupDefaultColumn = postgres.BoolColumn("signup_default")
+		allColumns          = postgres.ColumnList{IDColumn, NameColumn, CreatedAtColumn, UpdatedAtColumn, ParentIDColumn, SignupDefaultColumn}
+		mutableColumns      = postgres.ColumnList{NameColumn, CreatedAtColumn, UpdatedAtColumn, ParentIDColumn, SignupDefaultColumn}
 	)
 
 	return privilegeLevelsTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:        IDColumn,
-		Name:      NameColumn,
-		CreatedAt: CreatedAtColumn,
-		UpdatedAt: UpdatedAtColumn,
+		ID:            IDColumn,
+		Name:          NameColumn,
+		CreatedAt:     CreatedAtColumn,
+		UpdatedAt:     UpdatedAtColumn,
+		ParentID:      ParentIDColumn,
+		SignupDefault: SignupDefaultColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -17,10 +17,15 @@ type sessionsTable struct {
 	postgres.Table
 
 	// Columns
-	UserID    postgres.ColumnInteger
-	Token     postgres.ColumnString
-	ExpiresAt postgres.ColumnTimestamp
-	CreatedAt postgres.ColumnTimestamp
+	UserID         postgres.ColumnInteger
+	Token          postgres.ColumnString
+	ExpiresAt      postgres.ColumnTimestamp
+	CreatedAt      postgres.ColumnTimestamp
+	OrganizationID postgres.ColumnInteger
+	LastSeenAt     postgres.ColumnTimestamp
+	UserAgent      postgres.ColumnString
+	IPAddress      postgres.ColumnString
+	ID             postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -61,22 +66,32 @@ func newSessionsTable(schemaName, tableName, alias string) *SessionsTable {
 
 func newSessionsTableImpl(schemaName, tableName, alias string) sessionsTable {
 	var (
-		UserIDColumn    = postgres.IntegerColumn("user_id")
-		TokenColumn     = postgres.StringColumn("token")
-		ExpiresAtColumn = postgres.TimestampColumn("expires_at")
-		CreatedAtColumn = postgres.TimestampColumn("created_at")
-		allColumns      = postgres.ColumnList{UserIDColumn, TokenColumn, ExpiresAtColumn, CreatedAtColumn}
-		mutableColumns  = postgres.ColumnList{ExpiresAtColumn, CreatedAtColumn}
+		UserIDColumn         = postgres.IntegerColumn("user_id")
+		TokenColumn          = postgres.StringColumn("token")
+		ExpiresAtColumn      = postgres.TimestampColumn("expires_at")
+		CreatedAtColumn      = postgres.TimestampColumn("created_at")
+		OrganizationIDColumn = postgres.IntegerColumn("organization_id")
+		LastSeenAtColumn     = postgres.TimestampColumn("last_seen_at")
+		UserAgentColumn      = postgres.StringColumn("user_agent")
+		IPAddressColumn      = postgres.StringColumn("ip_address")
+		IDColumn             = postgres.IntegerColumn("id")
+		allColumns           = postgres.ColumnList{UserIDColumn, TokenColumn, ExpiresAtColumn, CreatedAtColumn, OrganizationIDColumn, LastSeenAtColumn, UserAgentColumn, IPAddressColumn, IDColumn}
+		mutableColumns       = postgres.ColumnList{ExpiresAtColumn, CreatedAtColumn, OrganizationIDColumn, LastSeenAtColumn, UserAgentColumn, IPAddressColumn, IDColumn}
 	)
 
 	return sessionsTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		UserID:    UserIDColumn,
-		Token:     TokenColumn,
-		ExpiresAt: ExpiresAtColumn,
-		CreatedAt: CreatedAtColumn,
+		UserID:         UserIDColumn,
+		Token:          TokenColumn,
+		ExpiresAt:      ExpiresAtColumn,
+		CreatedAt:      CreatedAtColumn,
+		OrganizationID: OrganizationIDColumn,
+		LastSeenAt:     LastSeenAtColumn,
+		UserAgent:      UserAgentColumn,
+		IPAddress:      IPAddressColumn,
+		ID:             IDColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
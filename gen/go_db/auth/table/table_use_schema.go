@@ -10,9 +10,20 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	APIKeys = APIKeys.FromSchema(schema)
+	APIKeysPrivileges = APIKeysPrivileges.FromSchema(schema)
+	AccountTokens = AccountTokens.FromSchema(schema)
+	AuditEvents = AuditEvents.FromSchema(schema)
+	LoginThrottles = LoginThrottles.FromSchema(schema)
+	OidcLogins = OidcLogins.FromSchema(schema)
+	OrganizationMembers = OrganizationMembers.FromSchema(schema)
+	Organizations = Organizations.FromSchema(schema)
 	PrivilegeLevels = PrivilegeLevels.FromSchema(schema)
+	PrivilegeLevelsPatterns = PrivilegeLevelsPatterns.FromSchema(schema)
 	PrivilegeLevelsPrivileges = PrivilegeLevelsPrivileges.FromSchema(schema)
 	Privileges = Privileges.FromSchema(schema)
+	SecurityEvents = SecurityEvents.FromSchema(schema)
 	Sessions = Sessions.FromSchema(schema)
+	UserIdentities = UserIdentities.FromSchema(schema)
 	Users = Users.FromSchema(schema)
 }
@@ -25,6 +25,7 @@ type usersTable struct {
 	PrivilegeLevelID postgres.ColumnInteger
 	CreatedAt        postgres.ColumnTimestamp
 	UpdatedAt        postgres.ColumnTimestamp
+	EmailVerifiedAt  postgres.ColumnTimestamp
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -73,8 +74,9 @@ func newUsersTableImpl(schemaName, tableName, alias string) usersTable {
 		PrivilegeLevelIDColumn = postgres.IntegerColumn("privilege_level_id")
 		CreatedAtColumn        = postgres.TimestampColumn("created_at")
 		UpdatedAtColumn        = postgres.TimestampColumn("updated_at")
-		allColumns             = postgres.ColumnList{IDColumn, EmailColumn, PasswordColumn, FirstNameColumn, LastNameColumn, PrivilegeLevelIDColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns         = postgres.ColumnList{EmailColumn, PasswordColumn, FirstNameColumn, LastNameColumn, PrivilegeLevelIDColumn, CreatedAtColumn, UpdatedAtColumn}
+		EmailVerifiedAtColumn  = postgres.TimestampColumn("email_verified_at")
+		allColumns             = postgres.ColumnList{IDColumn, EmailColumn, PasswordColumn, FirstNameColumn, LastNameColumn, PrivilegeLevelIDColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedAtColumn}
+		mutableColumns         = postgres.ColumnList{EmailColumn, PasswordColumn, FirstNameColumn, LastNameColumn, PrivilegeLevelIDColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedAtColumn}
 	)
 
 	return usersTable{
@@ -89,6 +91,7 @@ func newUsersTableImpl(schemaName, tableName, alias string) usersTable {
 		PrivilegeLevelID: PrivilegeLevelIDColumn,
 		CreatedAt:        CreatedAtColumn,
 		UpdatedAt:        UpdatedAtColumn,
+		EmailVerifiedAt:  EmailVerifiedAtColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
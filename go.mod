@@ -4,10 +4,13 @@ go 1.23.6
 
 require (
 	github.com/carsonkrueger/elevenlabs-go v0.0.0-20250529053402-9e3b5b7021b8
+	github.com/deepgram/deepgram-go-sdk/v3 v3.2.0
 	github.com/go-chi/chi/v5 v5.2.1
 	github.com/i2y/langchaingo-mcp-adapter v0.0.0-20250408100152-2fd6246dd090
 	github.com/openai/openai-go v1.1.0
 	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1
+	golang.org/x/net v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -28,7 +31,6 @@ require (
 	github.com/Masterminds/semver/v3 v3.2.0 // indirect
 	github.com/Masterminds/sprig/v3 v3.2.3 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/deepgram/deepgram-go-sdk/v3 v3.2.0 // indirect
 	github.com/dlclark/regexp2 v1.10.0 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/dvonthenen/websocket v1.5.1-dyv.2 // indirect
@@ -66,6 +68,5 @@ require (
 	go.starlark.net v0.0.0-20230302034142-4b1e35fe2254 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
 )
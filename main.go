@@ -13,5 +13,11 @@ func main() {
 		os.Exit(1)
 	}
 	name := os.Args[len(os.Args)-1]
+	// commands may also come first, go run . {cmd} -flag=value, in which case they
+	// are removed so the flags after them can be parsed
+	if cmd.Exists(os.Args[1]) {
+		name = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
 	cmd.Execute(name)
 }
@@ -13,12 +13,50 @@ func EnforceAuth(appCtx context.AppContext) func(next http.Handler) http.Handler
 	usersService := appCtx.SM().UsersService()
 	usersDAO := appCtx.DM().UsersDAO()
 	sessionsDAO := appCtx.DM().SessionsDAO()
+	orgsService := appCtx.SM().OrganizationsService()
+	apiKeysService := appCtx.SM().APIKeysService()
+	accountsService := appCtx.SM().AccountsService()
 	lgr := appCtx.Lgr("MW EnforceAuth")
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
 			ctx := req.Context()
 
+			if bearer, ok := tools.GetBearerToken(req); ok {
+				key, err := apiKeysService.Authenticate(bearer)
+				if err != nil {
+					tools.HandleError(req, res, lgr, err, 401, "Invalid API key")
+					return
+				}
+				user, err := usersDAO.GetOne(key.UserID, appCtx.DB())
+				if err != nil {
+					tools.HandleError(req, res, lgr, err, 401, "Invalid API key")
+					return
+				}
+				if err := accountsService.CheckVerified(user); err != nil {
+					tools.HandleError(req, res, lgr, err, 403, "Verify your email first")
+					return
+				}
+				member, err := appCtx.DM().OrganizationMembersDAO().GetOne(authModels.OrganizationMembersPrimaryKey{
+					OrganizationID: key.OrganizationID,
+					UserID:         key.UserID,
+				}, appCtx.DB())
+				if err != nil {
+					tools.HandleError(req, res, lgr, err, 403, "No organization")
+					return
+				}
+
+				ctx = context.WithToken(ctx, "")
+				ctx = context.WithUserId(ctx, user.ID)
+				ctx = context.WithPrivilegeLevelID(ctx, user.PrivilegeLevelID)
+				ctx = context.WithOrgID(ctx, member.OrganizationID)
+				ctx = context.WithOrgRole(ctx, member.Role)
+				ctx = context.WithAPIKeyID(ctx, key.ID)
+
+				next.ServeHTTP(res, req.WithContext(ctx))
+				return
+			}
+
 			token, id, err := usersService.GetAuthParts(req)
 			if err != nil {
 				tools.HandleError(req, res, lgr, err, 403, "Malformed auth token")
@@ -54,9 +92,30 @@ func EnforceAuth(appCtx context.AppContext) func(next http.Handler) http.Handler
 				return
 			}
 
+			if err := usersService.CheckSession(session); err != nil {
+				tools.HandleError(req, res, lgr, err, 403, "Session expired")
+				req.Header.Del(constant.AUTH_TOKEN_KEY)
+				res.Header().Set("Hx-Redirect", "/login")
+				return
+			}
+
+			if err := accountsService.CheckVerified(user); err != nil {
+				res.Header().Set("Hx-Redirect", "/verify")
+				tools.HandleError(req, res, lgr, err, 403, "Verify your email first")
+				return
+			}
+
+			orgID, role, err := orgsService.SessionOrganization(session)
+			if err != nil {
+				tools.HandleError(req, res, lgr, err, 403, "No organization")
+				return
+			}
+
 			ctx = context.WithToken(ctx, token)
 			ctx = context.WithUserId(ctx, id)
 			ctx = context.WithPrivilegeLevelID(ctx, user.PrivilegeLevelID)
+			ctx = context.WithOrgID(ctx, orgID)
+			ctx = context.WithOrgRole(ctx, role)
 
 			next.ServeHTTP(res, req.WithContext(ctx))
 		})
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/carsonkrueger/main/tools"
+)
+
+// ForwardedFor sets RemoteAddr to the client a trusted proxy forwarded the
+// request for, so tools.ClientIP sees the client rather than the proxy. The
+// X-Forwarded-For of requests from anywhere else is ignored.
+func ForwardedFor(trusted []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			req.RemoteAddr = tools.ForwardedClientIP(req, trusted)
+			next.ServeHTTP(res, req)
+		})
+	}
+}
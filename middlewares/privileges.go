@@ -21,6 +21,11 @@ func ApplyPermission(privilegeID int64, appCtx context.AppContext) func(next htt
 				tools.HandleError(req, res, lgr, nil, 403, "Insufficient privileges")
 				return
 			}
+			// an api key is further limited to the privileges it was given
+			if keyID, ok := context.LookupAPIKeyID(ctx); ok && !appCtx.SM().APIKeysService().HasScope(keyID, privilegeID) {
+				tools.HandleError(req, res, lgr, nil, 403, "API key is missing this scope")
+				return
+			}
 
 			next.ServeHTTP(res, req)
 		})
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS agent.tool_calls;
+
+DROP TABLE IF EXISTS agent.conversations;
+
+DROP SCHEMA IF EXISTS agent;
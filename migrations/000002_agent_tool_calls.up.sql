@@ -0,0 +1,34 @@
+CREATE SCHEMA IF NOT EXISTS agent;
+
+CREATE TABLE IF NOT EXISTS agent.conversations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    user_id BIGINT REFERENCES auth.users (id),
+    channel VARCHAR(32) NOT NULL,
+    ended_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent.tool_calls (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    conversation_id BIGINT REFERENCES agent.conversations (id),
+    user_id BIGINT REFERENCES auth.users (id),
+    tool_name VARCHAR(255) NOT NULL,
+    arguments JSONB NOT NULL DEFAULT '{}',
+    result_size INTEGER NOT NULL DEFAULT 0,
+    error TEXT DEFAULT NULL,
+    duration_ms BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS tool_calls_tool_name_created_at_idx ON agent.tool_calls (tool_name, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS tool_calls_conversation_id_idx ON agent.tool_calls (conversation_id);
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS agent.cosine_similarity(REAL[], REAL[]);
+
+DROP TABLE IF EXISTS agent.knowledge_chunks;
+
+DROP TABLE IF EXISTS agent.knowledge_documents;
+
+DROP TABLE IF EXISTS agent.profiles;
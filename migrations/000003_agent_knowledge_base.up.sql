@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS agent.profiles (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    prompt TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent.knowledge_documents (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    profile_id BIGINT NOT NULL REFERENCES agent.profiles (id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent.knowledge_chunks (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    document_id BIGINT NOT NULL REFERENCES agent.knowledge_documents (id) ON DELETE CASCADE,
+    profile_id BIGINT NOT NULL REFERENCES agent.profiles (id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    -- vectors from different embedders are not comparable
+    embedding_model VARCHAR(128) NOT NULL,
+    embedding REAL[] NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS knowledge_chunks_profile_id_model_idx ON agent.knowledge_chunks (profile_id, embedding_model);
+
+CREATE OR REPLACE FUNCTION agent.cosine_similarity(a REAL[], b REAL[]) RETURNS DOUBLE PRECISION AS $$
+    SELECT SUM(x * y) / NULLIF(SQRT(SUM(x * x)) * SQRT(SUM(y * y)), 0)
+    FROM UNNEST(a, b) AS t(x, y)
+$$ LANGUAGE SQL IMMUTABLE;
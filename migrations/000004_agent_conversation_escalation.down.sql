@@ -0,0 +1,4 @@
+ALTER TABLE agent.conversations
+    DROP COLUMN IF EXISTS escalated_at,
+    DROP COLUMN IF EXISTS transferred_to,
+    DROP COLUMN IF EXISTS escalation_reason;
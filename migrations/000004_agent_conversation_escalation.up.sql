@@ -0,0 +1,4 @@
+ALTER TABLE agent.conversations
+    ADD COLUMN IF NOT EXISTS escalation_reason TEXT DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS transferred_to VARCHAR(255) DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS agent.campaign_contacts;
+
+DROP TABLE IF EXISTS agent.campaigns;
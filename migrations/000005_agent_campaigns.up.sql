@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS agent.campaigns (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    profile_id BIGINT NOT NULL REFERENCES agent.profiles (id),
+    -- draft, running, paused or completed
+    status VARCHAR(32) NOT NULL DEFAULT 'draft',
+    -- daily calling window in minutes after midnight, in timezone
+    window_start INTEGER NOT NULL DEFAULT 540,
+    window_end INTEGER NOT NULL DEFAULT 1020,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    max_concurrent INTEGER NOT NULL DEFAULT 1,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    retry_delay_minutes INTEGER NOT NULL DEFAULT 60,
+    retry_voicemail BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent.campaign_contacts (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    campaign_id BIGINT NOT NULL REFERENCES agent.campaigns (id) ON DELETE CASCADE,
+    phone VARCHAR(32) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    -- pending, calling, connected or done
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    -- answered, voicemail, no_answer, completed, transferred or failed
+    outcome VARCHAR(32) DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT NULL,
+    last_attempt_at TIMESTAMP DEFAULT NULL,
+    call_id VARCHAR(255) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (campaign_id, phone)
+);
+
+CREATE INDEX IF NOT EXISTS campaign_contacts_campaign_id_status_idx ON agent.campaign_contacts (campaign_id, status);
+
+CREATE INDEX IF NOT EXISTS campaign_contacts_call_id_idx ON agent.campaign_contacts (call_id);
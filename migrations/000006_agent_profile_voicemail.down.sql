@@ -0,0 +1,3 @@
+ALTER TABLE agent.profiles
+    DROP COLUMN IF EXISTS voicemail_action,
+    DROP COLUMN IF EXISTS voicemail_script;
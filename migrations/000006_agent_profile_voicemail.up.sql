@@ -0,0 +1,3 @@
+ALTER TABLE agent.profiles
+    ADD COLUMN IF NOT EXISTS voicemail_script TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS voicemail_action VARCHAR(16) NOT NULL DEFAULT 'leave_message' CHECK (voicemail_action IN ('leave_message', 'hang_up'));
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS agent.phone_numbers;
+
+ALTER TABLE agent.tool_calls DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE agent.conversations DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE agent.campaigns DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE agent.knowledge_documents DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE agent.profiles
+    DROP CONSTRAINT IF EXISTS profiles_organization_id_name_key,
+    DROP COLUMN IF EXISTS organization_id,
+    ADD CONSTRAINT profiles_name_key UNIQUE (name);
+
+ALTER TABLE auth.sessions DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS auth.organization_members;
+
+DROP TABLE IF EXISTS auth.organizations;
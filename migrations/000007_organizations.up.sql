@@ -0,0 +1,101 @@
+CREATE TABLE IF NOT EXISTS auth.organizations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS auth.organization_members (
+    organization_id BIGINT NOT NULL REFERENCES auth.organizations (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES auth.users (id) ON DELETE CASCADE,
+    -- owner, admin or member
+    role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON auth.organization_members (user_id);
+
+-- everything created before organizations existed belongs to the default one
+INSERT INTO auth.organizations (id, name, slug)
+VALUES (1000, 'Default', 'default')
+ON CONFLICT DO NOTHING;
+
+-- admins and the earliest user own it so someone can manage its members
+INSERT INTO auth.organization_members (organization_id, user_id, role)
+SELECT
+    1000,
+    u.id,
+    CASE
+        WHEN pl.name = 'admin'
+        OR u.id = (SELECT MIN(id) FROM auth.users) THEN 'owner'
+        ELSE 'member'
+    END
+FROM auth.users u
+    JOIN auth.privilege_levels pl ON pl.id = u.privilege_level_id
+ON CONFLICT DO NOTHING;
+
+-- the organization the session is working in
+ALTER TABLE auth.sessions
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES auth.organizations (id) ON DELETE SET NULL;
+
+UPDATE auth.sessions SET organization_id = 1000;
+
+ALTER TABLE agent.profiles
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT NOT NULL DEFAULT 1000 REFERENCES auth.organizations (id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS profiles_name_key,
+    ADD CONSTRAINT profiles_organization_id_name_key UNIQUE (organization_id, name);
+
+ALTER TABLE agent.knowledge_documents
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT NOT NULL DEFAULT 1000 REFERENCES auth.organizations (id) ON DELETE CASCADE;
+
+ALTER TABLE agent.campaigns
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT NOT NULL DEFAULT 1000 REFERENCES auth.organizations (id) ON DELETE CASCADE;
+
+-- conversations and tool calls without a session, like the mcp server, have no organization
+ALTER TABLE agent.conversations
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT DEFAULT NULL REFERENCES auth.organizations (id) ON DELETE CASCADE;
+
+ALTER TABLE agent.tool_calls
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT DEFAULT NULL REFERENCES auth.organizations (id) ON DELETE CASCADE;
+
+UPDATE agent.conversations SET organization_id = 1000;
+
+UPDATE agent.tool_calls SET organization_id = 1000;
+
+ALTER TABLE agent.profiles ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE agent.knowledge_documents ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE agent.campaigns ALTER COLUMN organization_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS knowledge_documents_organization_id_idx ON agent.knowledge_documents (organization_id);
+
+CREATE INDEX IF NOT EXISTS campaigns_organization_id_idx ON agent.campaigns (organization_id);
+
+CREATE INDEX IF NOT EXISTS conversations_organization_id_idx ON agent.conversations (organization_id);
+
+CREATE INDEX IF NOT EXISTS tool_calls_organization_id_created_at_idx ON agent.tool_calls (organization_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS agent.phone_numbers (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    organization_id BIGINT NOT NULL REFERENCES auth.organizations (id) ON DELETE CASCADE,
+    -- E.164, a number belongs to a single organization
+    number VARCHAR(32) NOT NULL UNIQUE,
+    -- the agent profile that answers calls to this number
+    profile_id BIGINT DEFAULT NULL REFERENCES agent.profiles (id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS phone_numbers_organization_id_idx ON agent.phone_numbers (organization_id);
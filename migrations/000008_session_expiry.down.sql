@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS auth.sessions_expires_at_idx;
+
+ALTER TABLE auth.sessions
+    DROP COLUMN IF EXISTS id,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS last_seen_at;
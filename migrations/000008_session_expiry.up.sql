@@ -0,0 +1,14 @@
+ALTER TABLE auth.sessions
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    -- lets a session be revoked without putting its token in a page
+    ADD COLUMN IF NOT EXISTS id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) UNIQUE;
+
+UPDATE auth.sessions SET last_seen_at = created_at;
+
+CREATE INDEX IF NOT EXISTS sessions_expires_at_idx ON auth.sessions (expires_at);
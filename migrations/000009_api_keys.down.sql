@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auth.api_keys_privileges;
+
+DROP TABLE IF EXISTS auth.api_keys;
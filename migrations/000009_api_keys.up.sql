@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS auth.api_keys (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES auth.users (id) ON DELETE CASCADE,
+    organization_id BIGINT NOT NULL REFERENCES auth.organizations (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    -- the start of the key, enough to tell keys apart in a list
+    prefix VARCHAR(16) NOT NULL,
+    -- hex sha256 of the full key, the key itself is only shown once
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON auth.api_keys (user_id);
+
+-- the privileges a key may use, on top of the privileges of its user's level
+CREATE TABLE IF NOT EXISTS auth.api_keys_privileges (
+    api_key_id BIGINT NOT NULL REFERENCES auth.api_keys (id) ON DELETE CASCADE,
+    privilege_id BIGINT NOT NULL REFERENCES auth.privileges (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (api_key_id, privilege_id)
+);
@@ -0,0 +1,23 @@
+ALTER TABLE auth.users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- accounts from before verification existed are trusted
+UPDATE auth.users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- single use links emailed to a user, only the hash of the token is kept
+CREATE TABLE IF NOT EXISTS auth.account_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES auth.users (id) ON DELETE CASCADE,
+    -- reset or verify
+    purpose VARCHAR(16) NOT NULL CHECK (purpose IN ('reset', 'verify')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS account_tokens_user_id_idx ON auth.account_tokens (user_id, purpose);
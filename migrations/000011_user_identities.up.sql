@@ -0,0 +1,10 @@
+-- accounts at external identity providers that sign in as a user
+CREATE TABLE IF NOT EXISTS auth.user_identities (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES auth.users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON auth.user_identities (user_id);
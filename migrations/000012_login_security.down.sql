@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auth.security_events;
+
+DROP TABLE IF EXISTS auth.login_throttles;
@@ -0,0 +1,29 @@
+-- failed logins by account email and by client ip, shared by every replica
+CREATE TABLE IF NOT EXISTS auth.login_throttles (
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (kind, key)
+);
+
+CREATE INDEX IF NOT EXISTS login_throttles_locked_until_idx ON auth.login_throttles (locked_until);
+
+-- logins, lockouts and other account events admins review
+CREATE TABLE IF NOT EXISTS auth.security_events (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    user_id BIGINT REFERENCES auth.users (id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS security_events_created_at_idx ON auth.security_events (created_at);
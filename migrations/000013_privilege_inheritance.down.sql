@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auth.privilege_levels_patterns;
+
+ALTER TABLE auth.privilege_levels DROP COLUMN IF EXISTS parent_id;
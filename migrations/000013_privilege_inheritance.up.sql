@@ -0,0 +1,19 @@
+-- a level has every privilege of its parent
+ALTER TABLE auth.privilege_levels
+    ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES auth.privilege_levels (id) ON DELETE SET NULL CHECK (parent_id <> id);
+
+-- wildcards granting a level every privilege whose name matches, like Speak*
+CREATE TABLE IF NOT EXISTS auth.privilege_levels_patterns (
+    privilege_level_id BIGINT NOT NULL REFERENCES auth.privilege_levels (id) ON DELETE CASCADE,
+    pattern VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (privilege_level_id, pattern)
+);
+
+UPDATE auth.privilege_levels
+SET parent_id = (SELECT id FROM auth.privilege_levels WHERE name = 'basic')
+WHERE name = 'admin';
+
+INSERT INTO auth.privilege_levels_patterns (privilege_level_id, pattern)
+SELECT id, '*' FROM auth.privilege_levels WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
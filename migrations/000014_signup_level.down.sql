@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS auth.privilege_levels_signup_default_idx;
+
+ALTER TABLE auth.privilege_levels DROP COLUMN IF EXISTS signup_default;
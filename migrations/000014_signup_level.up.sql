@@ -0,0 +1,11 @@
+-- the level new users sign up with, set by the seed file
+ALTER TABLE auth.privilege_levels
+    ADD COLUMN IF NOT EXISTS signup_default BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX IF NOT EXISTS privilege_levels_signup_default_idx
+    ON auth.privilege_levels (signup_default)
+    WHERE signup_default;
+
+UPDATE auth.privilege_levels
+SET signup_default = true
+WHERE name = 'basic';
@@ -0,0 +1,22 @@
+-- who changed which user or privilege level, and how
+CREATE TABLE IF NOT EXISTS auth.audit_events (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY (
+        START
+        WITH
+            1000
+    ) PRIMARY KEY,
+    actor_id BIGINT REFERENCES auth.users (id) ON DELETE SET NULL,
+    api_key_id BIGINT REFERENCES auth.api_keys (id) ON DELETE SET NULL,
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NOT NULL,
+    target_id BIGINT NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON auth.audit_events (created_at);
+
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON auth.audit_events (target_type, target_id);
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON auth.audit_events (actor_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS auth.oidc_logins;
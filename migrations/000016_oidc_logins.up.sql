@@ -0,0 +1,11 @@
+-- single sign-on logins sent to the identity provider, kept in the database so
+-- the callback may land on any replica
+CREATE TABLE IF NOT EXISTS auth.oidc_logins (
+    state VARCHAR(128) PRIMARY KEY,
+    nonce VARCHAR(128) NOT NULL,
+    verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS oidc_logins_expires_at_idx ON auth.oidc_logins (expires_at);
@@ -0,0 +1,23 @@
+package agentModels
+
+const (
+	CampaignDraft     = "draft"
+	CampaignRunning   = "running"
+	CampaignPaused    = "paused"
+	CampaignCompleted = "completed"
+
+	ContactPending = "pending"
+	ContactCalling = "calling"
+	// answered and still on the call, the final outcome follows
+	ContactConnected = "connected"
+	ContactDone      = "done"
+)
+
+// CampaignOutcomeCounts counts a campaign's contacts by status and last outcome
+type CampaignOutcomeCounts struct {
+	Total    int
+	Pending  int
+	Calling  int
+	Done     int
+	Outcomes map[string]int
+}
@@ -0,0 +1,9 @@
+package agentModels
+
+type KnowledgeSearchResult struct {
+	DocumentID int64
+	Title      string
+	ChunkIndex int32
+	Content    string
+	Score      float64
+}
@@ -0,0 +1,65 @@
+package agentModels
+
+import (
+	"time"
+)
+
+type ToolCallFilter struct {
+	ToolName       string
+	UserID         *int64
+	ConversationID *int64
+	ErrorsOnly     bool
+	Limit          int64
+}
+
+// upper bounds of the tool latency histogram buckets, the last bucket is unbounded
+var ToolLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type ToolMetrics struct {
+	ToolName      string
+	Calls         int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+	// counts per ToolLatencyBuckets, with one extra for slower calls
+	Buckets []int64
+}
+
+func (m ToolMetrics) AvgDuration() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.TotalDuration / time.Duration(m.Calls)
+}
+
+// ToolCall is a single tool invocation to be recorded
+type ToolCall struct {
+	ToolName   string
+	Arguments  map[string]any
+	ResultSize int
+	Error      error
+	Duration   time.Duration
+}
+
+// ToolConfirmationRequest is sent to the caller before a flagged tool runs
+type ToolConfirmationRequest struct {
+	ID        string         `json:"id"`
+	ToolName  string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	Prompt    string         `json:"prompt"`
+}
+
+// ToolConfirmationResponse is the caller's answer to a ToolConfirmationRequest
+type ToolConfirmationResponse struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+}
@@ -0,0 +1,7 @@
+package authModels
+
+// what an account token may be redeemed for
+const (
+	TokenPurposeReset  = "reset"
+	TokenPurposeVerify = "verify"
+)
@@ -0,0 +1,12 @@
+package authModels
+
+type APIKeysPrivilegesPrimaryKey struct {
+	APIKeyID    int64
+	PrivilegeID int64
+}
+
+type APIKeyScope struct {
+	APIKeyID      int64
+	PrivilegeID   int64
+	PrivilegeName string
+}
@@ -0,0 +1,66 @@
+package authModels
+
+import "github.com/carsonkrueger/main/gen/go_db/auth/model"
+
+// what an audit event's target_id points at
+const (
+	AuditTargetUser         = "user"
+	AuditTargetLevel        = "privilege_level"
+	AuditTargetOrganization = "organization"
+	// privileges file applies have no single target, their target_id is 0
+	AuditTargetPrivilegesFile = "privileges_file"
+)
+
+var AuditTargetTypes = []string{
+	AuditTargetUser,
+	AuditTargetLevel,
+	AuditTargetOrganization,
+	AuditTargetPrivilegesFile,
+}
+
+// actions of auth.audit_events
+const (
+	AuditUserLevelChanged      = "user_level_changed"
+	AuditSessionRevoked        = "session_revoked"
+	AuditSessionsRevoked       = "sessions_revoked"
+	AuditLevelCreated          = "level_created"
+	AuditLevelParentChanged    = "level_parent_changed"
+	AuditLevelPrivilegeAdded   = "level_privilege_added"
+	AuditLevelPrivilegeRemoved = "level_privilege_removed"
+	AuditLevelPatternAdded     = "level_pattern_added"
+	AuditLevelPatternRemoved   = "level_pattern_removed"
+	AuditOrgMemberAdded        = "org_member_added"
+	AuditOrgMemberRemoved      = "org_member_removed"
+	AuditOrgMemberRoleChanged  = "org_member_role_changed"
+	AuditPrivilegesFileApplied = "privileges_file_applied"
+)
+
+var AuditActions = []string{
+	AuditUserLevelChanged,
+	AuditSessionRevoked,
+	AuditSessionsRevoked,
+	AuditLevelCreated,
+	AuditLevelParentChanged,
+	AuditLevelPrivilegeAdded,
+	AuditLevelPrivilegeRemoved,
+	AuditLevelPatternAdded,
+	AuditLevelPatternRemoved,
+	AuditOrgMemberAdded,
+	AuditOrgMemberRemoved,
+	AuditOrgMemberRoleChanged,
+	AuditPrivilegesFileApplied,
+}
+
+type AuditEventJoin struct {
+	model.AuditEvents
+	// nil when the event has no actor or they were deleted
+	ActorEmail *string
+}
+
+type AuditEventFilter struct {
+	Action     string
+	TargetType string
+	TargetID   *int64
+	ActorEmail string
+	Limit      int64
+}
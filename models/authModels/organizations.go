@@ -0,0 +1,47 @@
+package authModels
+
+import (
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+)
+
+const (
+	// the organization existing data was moved into when organizations were added
+	DefaultOrganizationID int64 = 1000
+
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+type OrganizationMembersPrimaryKey struct {
+	OrganizationID int64
+	UserID         int64
+}
+
+// OrganizationMemberJoin is a member with their user details
+type OrganizationMemberJoin struct {
+	model.OrganizationMembers
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// UserOrganizationJoin is an organization a user belongs to with their role in it
+type UserOrganizationJoin struct {
+	model.Organizations
+	Role string
+}
+
+// IsOrgRole reports whether role is one of the organization roles
+func IsOrgRole(role string) bool {
+	switch role {
+	case OrgRoleOwner, OrgRoleAdmin, OrgRoleMember:
+		return true
+	}
+	return false
+}
+
+// CanManageOrg reports whether role may manage an organization's members and settings
+func CanManageOrg(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin
+}
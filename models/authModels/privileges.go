@@ -14,6 +14,30 @@ type PrivilegeLevelsPrivilegesPrimaryKey struct {
 	PrivilegeLevelID int64
 }
 
+type PrivilegeLevelsPatternsPrimaryKey struct {
+	PrivilegeLevelID int64
+	Pattern          string
+}
+
+// how a level came to have a privilege
+const (
+	GrantDirect    = "direct"
+	GrantPattern   = "pattern"
+	GrantInherited = "inherited"
+)
+
+// EffectivePrivilege is a privilege a level has directly, through one of its
+// patterns or from a level it inherits. From is the pattern or the level
+// inherited from.
+type EffectivePrivilege struct {
+	LevelID       int64
+	LevelName     string
+	PrivilegeID   int64
+	PrivilegeName string
+	Grant         string
+	From          string
+}
+
 type JoinedPrivilegesRaw struct {
 	LevelID            int64
 	LevelName          string
@@ -0,0 +1,39 @@
+package authModels
+
+// what a login throttle counts failures of
+const (
+	ThrottleAccount = "account"
+	ThrottleIP      = "ip"
+)
+
+// kinds of auth.security_events
+const (
+	SecurityLoginFailed    = "login_failed"
+	SecurityLoginLocked    = "login_locked"
+	SecurityLoginBlocked   = "login_blocked"
+	SecurityLoginSucceeded = "login_succeeded"
+	SecurityLoginUnlocked  = "login_unlocked"
+	SecurityPasswordReset  = "password_reset"
+	SecurityResetRequested = "password_reset_requested"
+)
+
+var SecurityEventKinds = []string{
+	SecurityLoginFailed,
+	SecurityLoginLocked,
+	SecurityLoginBlocked,
+	SecurityLoginSucceeded,
+	SecurityLoginUnlocked,
+	SecurityPasswordReset,
+	SecurityResetRequested,
+}
+
+type LoginThrottlesPrimaryKey struct {
+	Kind string
+	Key  string
+}
+
+type SecurityEventFilter struct {
+	Kind  string
+	Email string
+	Limit int64
+}
@@ -9,3 +9,8 @@ type UserPrivilegeLevelJoin struct {
 	PLID   int64
 	PLName string
 }
+
+type UserIdentitiesPrimaryKey struct {
+	Issuer  string
+	Subject string
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"container/list"
+	"sync"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key  K
+	val  V
+	cost int64
+}
+
+// LRU is a size bounded least recently used cache. The size of each entry is
+// computed with the cost func and the oldest entries are evicted once the total
+// cost exceeds maxCost.
+type LRU[K comparable, V any] struct {
+	mu      sync.Mutex
+	maxCost int64
+	cost    int64
+	costFn  func(V) int64
+	ll      *list.List
+	items   map[K]*list.Element
+}
+
+func NewLRU[K comparable, V any](maxCost int64, costFn func(V) int64) *LRU[K, V] {
+	return &LRU[K, V]{
+		maxCost: maxCost,
+		costFn:  costFn,
+		ll:      list.New(),
+		items:   make(map[K]*list.Element),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).val, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *LRU[K, V]) Add(key K, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cost := c.costFn(val)
+	if cost > c.maxCost {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		c.cost += cost - entry.cost
+		entry.val = val
+		entry.cost = cost
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[key] = c.ll.PushFront(&lruEntry[K, V]{key, val, cost})
+		c.cost += cost
+	}
+	for c.cost > c.maxCost {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		entry := c.ll.Remove(oldest).(*lruEntry[K, V])
+		delete(c.items, entry.key)
+		c.cost -= entry.cost
+	}
+}
+
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		entry := c.ll.Remove(el).(*lruEntry[K, V])
+		delete(c.items, entry.key)
+		c.cost -= entry.cost
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
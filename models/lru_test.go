@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func newTestLRU(maxCost int64) *LRU[string, string] {
+	return NewLRU[string](maxCost, func(v string) int64 { return int64(len(v)) })
+}
+
+func TestLRUEvictsOldest(t *testing.T) {
+	c := newTestLRU(6)
+	c.Add("a", "aa")
+	c.Add("b", "bb")
+	c.Add("c", "cc")
+	// reading a makes b the oldest
+	if v, ok := c.Get("a"); !ok || v != "aa" {
+		t.Fatalf("got %q %v, want aa", v, ok)
+	}
+	c.Add("d", "dd")
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("got %d entries, want 3", c.Len())
+	}
+}
+
+func TestLRUCost(t *testing.T) {
+	c := newTestLRU(6)
+	c.Add("big", "1234567")
+	if _, ok := c.Get("big"); ok {
+		t.Error("expected an entry over the max cost to be skipped")
+	}
+
+	c.Add("a", "a")
+	c.Add("b", "b")
+	// growing an entry counts only the difference
+	c.Add("a", "aaaa")
+	if c.Len() != 2 {
+		t.Errorf("got %d entries after growing a, want 2", c.Len())
+	}
+	c.Add("c", "cc")
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted once over the max cost")
+	}
+	if v, _ := c.Get("a"); v != "aaaa" {
+		t.Errorf("got %q, want the updated value", v)
+	}
+
+	c.Remove("a")
+	c.Add("d", "dddd")
+	if _, ok := c.Get("c"); !ok {
+		t.Error("expected removing a to free its cost")
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// CallTransfer is a request to hand a call to a human number or queue
+type CallTransfer struct {
+	CallID string
+	// configured target name, such as "support"
+	Target string
+	// number or queue the provider dials, such as "+15551234567" or "queue:support"
+	Destination string
+	Reason      string
+	RequestedAt time.Time
+}
+
+// CallOutcome is how a call ended, as reported by the phone provider
+type CallOutcome string
+
+const (
+	// the callee picked up, the call is still in progress
+	CallAnswered    CallOutcome = "answered"
+	CallVoicemail   CallOutcome = "voicemail"
+	CallNoAnswer    CallOutcome = "no_answer"
+	CallCompleted   CallOutcome = "completed"
+	CallTransferred CallOutcome = "transferred"
+	CallFailed      CallOutcome = "failed"
+)
+
+// OutboundCall asks the provider to dial a number with an agent profile
+type OutboundCall struct {
+	// chosen by the caller so outcomes can be matched before Dial returns
+	CallID    string
+	To        string
+	ProfileID int64
+}
+
+const (
+	VoicemailLeaveMessage = "leave_message"
+	VoicemailHangUp       = "hang_up"
+)
+
+// AnswerOptions say how the agent opens an outbound call once it knows whether a
+// person or an answering machine picked up
+type AnswerOptions struct {
+	// said when a person answers
+	Greeting string
+	// left after the beep when a machine answers
+	VoicemailScript string
+	// VoicemailLeaveMessage or VoicemailHangUp
+	VoicemailAction string
+}
+
+const (
+	KeypadTransfer = "transfer"
+	KeypadHangUp   = "hangup"
+)
+
+// KeypadAction is a menu action a keypad digit triggers instead of being passed
+// to the agent
+type KeypadAction struct {
+	// KeypadTransfer or KeypadHangUp
+	Kind string
+	// transfer target, empty for the default target
+	Target string
+}
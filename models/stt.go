@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type TranscriptWord struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
+type Transcript struct {
+	Text string
+	// offsets relative to the start of the transcribed audio
+	Start time.Duration
+	End   time.Duration
+	// false while the speaker may still be talking and the text can change
+	Final bool
+	Words []TranscriptWord
+}
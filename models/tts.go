@@ -0,0 +1,12 @@
+package models
+
+type TTSRequest struct {
+	Text   string
+	Voice  string
+	Model  string
+	Format string
+	// Paced replays cached pcm no faster than real time, for writers that play
+	// the audio as it arrives. It doesn't change the audio so isn't part of the
+	// cache key.
+	Paced bool
+}
@@ -45,6 +45,10 @@ const (
 	SR_AGENT_START      StreamingResponseBodyType = "agent_start"
 	SR_AGENT_SPEAK      StreamingResponseBodyType = "agent_speak"
 	SR_AGENT_TRANSCRIBE StreamingResponseBodyType = "agent_transcribe"
+	// asks the web UI to approve a tool call, see agentModels.ToolConfirmationRequest
+	SR_TOOL_CONFIRM StreamingResponseBodyType = "tool_confirm"
+	// keypad press sent by the web UI
+	SR_DTMF StreamingResponseBodyType = "dtmf"
 )
 
 type StreamingResponseBody struct {
@@ -58,3 +62,15 @@ func WriteBinary[B any](sr StreamingWriter[B], body B) {
 		Data: body,
 	}
 }
+
+// TextMessage is the envelope of text frames from the web UI. Frames without a
+// type are tool confirmation answers.
+type TextMessage struct {
+	Type StreamingResponseBodyType `json:"type"`
+}
+
+// DTMFMessage is a keypad press from the web UI, for testing without a phone
+type DTMFMessage struct {
+	Type  StreamingResponseBodyType `json:"type"`
+	Digit string                    `json:"digit"`
+}
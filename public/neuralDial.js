@@ -1,19 +1,25 @@
 WsType = {
     AGENT_SPEAK: "agent_speak",
     AGENT_TRANSCRIBE: "agent_transcribe",
+    TOOL_CONFIRM: "tool_confirm",
+    DTMF: "dtmf",
     USER_SPEAK: "user_speak"
 }
 
 
 class NeuralDial {
     started = false;
+    ws = null;
 
     startWebsocket() {
         if (this.started) return;
         this.started = true;
 
-        let speakws = new WebSocket("/speak/ws");
+        // outbound calls wait for answering machine detection before the agent speaks
+        const outbound = document.getElementById("simulate-outbound")?.checked;
+        let speakws = new WebSocket(outbound ? "/speak/ws?outbound=true" : "/speak/ws");
         speakws.binaryType = "arraybuffer";
+        this.ws = speakws;
         const sampleRate = 16000;
         let audioPlayer = null;
 
@@ -37,6 +43,13 @@ class NeuralDial {
                     const decoder = new TextDecoder();
                     console.log("AGENT TRANSCRIBE:", decoder.decode(bytes));
                     break;
+                case WsType.TOOL_CONFIRM:
+                    const request = JSON.parse(new TextDecoder().decode(base64ToUint8Array(msg.data)));
+                    this.showToolConfirmation(request, (approved) => {
+                        // text frames are control messages, binary frames are audio
+                        speakws.send(JSON.stringify({ id: request.id, approved }));
+                    });
+                    break;
                 case WsType.USER_SPEAK:
                     console.log("USER");
                     audioPlayer.port.postMessage('clear');
@@ -52,6 +65,44 @@ class NeuralDial {
         }
     }
 
+    // sendDigit sends a keypad press, as a caller on a phone would
+    sendDigit(digit) {
+        if (!this.ws || this.ws.readyState !== WebSocket.OPEN) return;
+        this.ws.send(JSON.stringify({ type: WsType.DTMF, digit }));
+    }
+
+    // showToolConfirmation asks the user to approve a tool call. The agent asks out
+    // loud at the same time, whichever answer comes first is used.
+    showToolConfirmation(request, respond) {
+        const box = document.createElement("div");
+        box.className = "fixed bottom-8 right-8 bg-white text-black rounded-sm p-4 flex flex-col gap-2 max-w-md";
+
+        const prompt = document.createElement("p");
+        prompt.innerText = `Allow ${request.tool}?`;
+        const args = document.createElement("pre");
+        args.className = "text-xs whitespace-pre-wrap";
+        args.innerText = JSON.stringify(request.arguments, null, 2);
+
+        const buttons = document.createElement("div");
+        buttons.className = "flex gap-2 justify-end";
+        const answer = (approved) => {
+            respond(approved);
+            box.remove();
+        };
+        const decline = document.createElement("button");
+        decline.innerText = "Decline";
+        decline.className = "px-4 py-2 rounded-sm cursor-pointer";
+        decline.onclick = () => answer(false);
+        const approve = document.createElement("button");
+        approve.innerText = "Approve";
+        approve.className = "bg-primary px-4 py-2 text-white rounded-sm cursor-pointer";
+        approve.onclick = () => answer(true);
+        buttons.append(decline, approve);
+
+        box.append(prompt, args, buttons);
+        document.body.appendChild(box);
+    }
+
     async startSpeakingAudioStream(callback, sampleRate) {
         try {
             const stream = await navigator.mediaDevices.getUserMedia({ audio: true, video: false });
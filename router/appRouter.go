@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 
 	"github.com/go-chi/chi/v5"
 
@@ -23,14 +24,20 @@ type AppRouter struct {
 	addr    string
 	router  chi.Router
 	appCtx  context.AppContext
+	// proxies allowed to say who the client is
+	trustedProxies []netip.Prefix
 }
 
 func NewAppRouter(ctx context.AppContext, cfg cfg.Config) AppRouter {
 	return AppRouter{
-		appCtx: ctx,
+		appCtx:         ctx,
+		trustedProxies: cfg.TrustedProxies,
 		public: []builders.AppPublicRoute{
 			public.NewLogin(ctx),
+			public.NewSSO(ctx),
 			public.NewSignUp(ctx),
+			public.NewPasswordReset(ctx),
+			public.NewVerify(ctx),
 			public.NewWebPublic(ctx),
 			public.NewHome(ctx),
 		},
@@ -41,6 +48,15 @@ func NewAppRouter(ctx context.AppContext, cfg cfg.Config) AppRouter {
 			private.NewPrivilegeLevelsPrivileges(ctx),
 			private.NewSpeak(ctx, cfg.DeepgramAPIKey),
 			private.NewWebText(ctx),
+			private.NewTranscribe(ctx),
+			private.NewToolCalls(ctx),
+			private.NewKnowledgeBase(ctx),
+			private.NewCampaigns(ctx),
+			private.NewOrganizations(ctx),
+			private.NewSessions(ctx),
+			private.NewAPIKeys(ctx),
+			private.NewSecurityEvents(ctx),
+			private.NewAuditEvents(ctx),
 		},
 	}
 }
@@ -50,6 +66,7 @@ func (a *AppRouter) BuildRouter() {
 	lgr := a.appCtx.Lgr("BuildRouter")
 
 	a.router = a.router.With(middlewares.Recover(a.appCtx))
+	a.router = a.router.With(middlewares.ForwardedFor(a.trustedProxies))
 
 	for _, r := range a.public {
 		router := chi.NewRouter()
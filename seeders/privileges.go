@@ -2,73 +2,388 @@ package seeders
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/carsonkrueger/main/constant"
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/gen/go_db/auth/table"
+	"github.com/carsonkrueger/main/models/authModels"
 	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
 )
 
-func SeedPermissions(db *sql.DB) error {
-	allPrivilegeLevelNames := []string{
-		constant.ADMIN_LEVEL_NAME,
-		constant.BASIC_LEVEL_NAME,
+// PrivilegesDiff is what applying a privileges file would change, by name
+type PrivilegesDiff struct {
+	// route permission names no route has registered yet
+	NewPrivileges []string
+	NewLevels     []string
+	Parents       []ParentChange
+	// nil when the signup level stays the same
+	SignupLevel *NameChange
+	Grants      []GrantChange
+	Patterns    []GrantChange
+}
+
+type NameChange struct {
+	From string
+	To   string
+}
+
+type ParentChange struct {
+	Level string
+	NameChange
+}
+
+// GrantChange adds or removes a privilege or pattern of a level
+type GrantChange struct {
+	Level string
+	Name  string
+	Add   bool
+}
+
+func (d *PrivilegesDiff) Empty() bool {
+	return len(d.NewPrivileges) == 0 &&
+		len(d.NewLevels) == 0 &&
+		len(d.Parents) == 0 &&
+		d.SignupLevel == nil &&
+		len(d.Grants) == 0 &&
+		len(d.Patterns) == 0
+}
+
+// String lists the changes one per line, + for additions, - for removals and ~
+// for updates
+func (d *PrivilegesDiff) String() string {
+	if d.Empty() {
+		return "no changes\n"
+	}
+	orNone := func(name string) string {
+		if name == "" {
+			return "(none)"
+		}
+		return name
 	}
-	adminLevelName := constant.ADMIN_LEVEL_NAME
+	var b strings.Builder
+	for _, name := range d.NewPrivileges {
+		fmt.Fprintf(&b, "+ privilege %s\n", name)
+	}
+	for _, name := range d.NewLevels {
+		fmt.Fprintf(&b, "+ level %s\n", name)
+	}
+	for _, p := range d.Parents {
+		fmt.Fprintf(&b, "~ level %s inherits %s -> %s\n", p.Level, orNone(p.From), orNone(p.To))
+	}
+	if d.SignupLevel != nil {
+		fmt.Fprintf(&b, "~ signup level %s -> %s\n", orNone(d.SignupLevel.From), d.SignupLevel.To)
+	}
+	for _, g := range d.Grants {
+		fmt.Fprintf(&b, "%s level %s privilege %s\n", sign(g.Add), g.Level, g.Name)
+	}
+	for _, g := range d.Patterns {
+		fmt.Fprintf(&b, "%s level %s pattern %s\n", sign(g.Add), g.Level, g.Name)
+	}
+	return b.String()
+}
 
-	newPrivileges := make([]model.PrivilegeLevels, len(allPrivilegeLevelNames))
-	for i, name := range allPrivilegeLevelNames {
-		newPrivileges[i] = model.PrivilegeLevels{Name: name}
+func sign(add bool) string {
+	if add {
+		return "+"
 	}
+	return "-"
+}
 
-	_, err := table.PrivilegeLevels.
-		INSERT(table.PrivilegeLevels.Name).
-		MODELS(newPrivileges).
-		ON_CONFLICT().
-		DO_NOTHING().
-		Exec(db)
+// DiffPrivileges compares the file with the database. The file's levels end up
+// with exactly its privileges and patterns, other levels are untouched.
+func DiffPrivileges(db qrm.Queryable, pf *PrivilegesFile) (*PrivilegesDiff, error) {
+	var levels []model.PrivilegeLevels
+	if err := table.PrivilegeLevels.SELECT(table.PrivilegeLevels.AllColumns).Query(db, &levels); err != nil {
+		return nil, err
+	}
+	var privileges []model.Privileges
+	if err := table.Privileges.SELECT(table.Privileges.AllColumns).Query(db, &privileges); err != nil {
+		return nil, err
+	}
+	var links []model.PrivilegeLevelsPrivileges
+	if err := table.PrivilegeLevelsPrivileges.SELECT(table.PrivilegeLevelsPrivileges.AllColumns).Query(db, &links); err != nil {
+		return nil, err
+	}
+	var patterns []model.PrivilegeLevelsPatterns
+	if err := table.PrivilegeLevelsPatterns.SELECT(table.PrivilegeLevelsPatterns.AllColumns).Query(db, &patterns); err != nil {
+		return nil, err
+	}
+
+	levelNames := make(map[int64]string, len(levels))
+	for _, l := range levels {
+		levelNames[l.ID] = l.Name
+	}
+	privilegeNames := make(map[int64]string, len(privileges))
+	for _, p := range privileges {
+		privilegeNames[p.ID] = p.Name
+	}
+	currentParents := make(map[string]string, len(levels))
+	currentSignup := ""
+	for _, l := range levels {
+		if l.ParentID != nil {
+			currentParents[l.Name] = levelNames[*l.ParentID]
+		}
+		if l.SignupDefault {
+			currentSignup = l.Name
+		}
+	}
+	currentGrants := map[string]map[string]bool{}
+	for _, link := range links {
+		level := levelNames[link.PrivilegeLevelID]
+		if currentGrants[level] == nil {
+			currentGrants[level] = map[string]bool{}
+		}
+		currentGrants[level][privilegeNames[link.PrivilegeID]] = true
+	}
+	currentPatterns := map[string]map[string]bool{}
+	for _, p := range patterns {
+		level := levelNames[p.PrivilegeLevelID]
+		if currentPatterns[level] == nil {
+			currentPatterns[level] = map[string]bool{}
+		}
+		currentPatterns[level][p.Pattern] = true
+	}
+	knownPrivileges := make(map[string]bool, len(privileges))
+	for _, p := range privileges {
+		knownPrivileges[p.Name] = true
+	}
+	knownLevels := make(map[string]bool, len(levels))
+	for _, l := range levels {
+		knownLevels[l.Name] = true
+	}
+
+	diff := &PrivilegesDiff{}
+	for _, l := range pf.Levels {
+		if !knownLevels[l.Name] {
+			diff.NewLevels = append(diff.NewLevels, l.Name)
+		}
+		if from := currentParents[l.Name]; from != l.Inherits {
+			diff.Parents = append(diff.Parents, ParentChange{
+				Level:      l.Name,
+				NameChange: NameChange{From: from, To: l.Inherits},
+			})
+		}
+		for _, name := range l.Privileges {
+			if !knownPrivileges[name] {
+				knownPrivileges[name] = true
+				diff.NewPrivileges = append(diff.NewPrivileges, name)
+			}
+		}
+		diff.Grants = append(diff.Grants, diffNames(l.Name, currentGrants[l.Name], l.Privileges)...)
+		diff.Patterns = append(diff.Patterns, diffNames(l.Name, currentPatterns[l.Name], l.Patterns)...)
+	}
+	if currentSignup != pf.SignupLevel {
+		diff.SignupLevel = &NameChange{From: currentSignup, To: pf.SignupLevel}
+	}
+	return diff, nil
+}
+
+// diffNames adds the wanted names missing from current and removes the rest,
+// sorted so the diff reads the same every run
+func diffNames(level string, current map[string]bool, wanted []string) []GrantChange {
+	var changes []GrantChange
+	keep := make(map[string]bool, len(wanted))
+	for _, name := range wanted {
+		if !keep[name] && !current[name] {
+			changes = append(changes, GrantChange{Level: level, Name: name, Add: true})
+		}
+		keep[name] = true
+	}
+	var removed []string
+	for name := range current {
+		if !keep[name] {
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(removed)
+	for _, name := range removed {
+		changes = append(changes, GrantChange{Level: level, Name: name, Add: false})
+	}
+	return changes
+}
+
+// Apply makes the changes in one transaction, recording file as their source in
+// the audit log, and tells running replicas to drop their cached privileges
+func (d *PrivilegesDiff) Apply(db *sql.DB, file string) error {
+	if d.Empty() {
+		return nil
+	}
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	var allLevels []model.PrivilegeLevels
-	if err = table.PrivilegeLevels.SELECT(table.PrivilegeLevels.AllColumns).Query(db, &allLevels); err != nil {
-		return err
+	if len(d.NewPrivileges) > 0 {
+		rows := make([]model.Privileges, len(d.NewPrivileges))
+		for i, name := range d.NewPrivileges {
+			rows[i] = model.Privileges{Name: name}
+		}
+		_, err := table.Privileges.
+			INSERT(table.Privileges.Name).
+			MODELS(rows).
+			ON_CONFLICT(table.Privileges.Name).
+			DO_NOTHING().
+			Exec(tx)
+		if err != nil {
+			return err
+		}
+	}
+	if len(d.NewLevels) > 0 {
+		rows := make([]model.PrivilegeLevels, len(d.NewLevels))
+		for i, name := range d.NewLevels {
+			rows[i] = model.PrivilegeLevels{Name: name}
+		}
+		_, err := table.PrivilegeLevels.
+			INSERT(table.PrivilegeLevels.Name).
+			MODELS(rows).
+			ON_CONFLICT(table.PrivilegeLevels.Name).
+			DO_NOTHING().
+			Exec(tx)
+		if err != nil {
+			return err
+		}
 	}
 
-	var allPrivileges []model.Privileges
-	if err = table.Privileges.SELECT(table.Privileges.AllColumns).Query(db, &allPrivileges); err != nil {
+	// names to ids, including the rows inserted above
+	var levels []model.PrivilegeLevels
+	if err := table.PrivilegeLevels.SELECT(table.PrivilegeLevels.AllColumns).Query(tx, &levels); err != nil {
 		return err
 	}
-
-	var adminLevel model.PrivilegeLevels
-	err = table.PrivilegeLevels.
-		SELECT(table.PrivilegeLevels.AllColumns).
-		WHERE(table.PrivilegeLevels.Name.EQ(postgres.String(adminLevelName))).
-		Query(db, &adminLevel)
-	if err != nil {
+	levelIDs := make(map[string]int64, len(levels))
+	for _, l := range levels {
+		levelIDs[l.Name] = l.ID
+	}
+	var privileges []model.Privileges
+	if err := table.Privileges.SELECT(table.Privileges.AllColumns).Query(tx, &privileges); err != nil {
 		return err
 	}
+	privilegeIDs := make(map[string]int64, len(privileges))
+	for _, p := range privileges {
+		privilegeIDs[p.Name] = p.ID
+	}
 
-	privilegeLevelPrivileges := make([]model.PrivilegeLevelsPrivileges, len(allPrivileges))
-	for i, p := range allPrivileges {
-		privilegeLevelPrivileges[i] = model.PrivilegeLevelsPrivileges{
-			PrivilegeLevelID: adminLevel.ID,
-			PrivilegeID:      p.ID,
+	for _, p := range d.Parents {
+		var parent postgres.Expression = postgres.NULL
+		if p.To != "" {
+			parent = postgres.Int(levelIDs[p.To])
+		}
+		_, err := table.PrivilegeLevels.
+			UPDATE(table.PrivilegeLevels.ParentID).
+			SET(parent).
+			WHERE(table.PrivilegeLevels.ID.EQ(postgres.Int(levelIDs[p.Level]))).
+			Exec(tx)
+		if err != nil {
+			return err
 		}
 	}
 
-	_, err = table.PrivilegeLevelsPrivileges.
-		INSERT(table.PrivilegeLevelsPrivileges.PrivilegeLevelID, table.PrivilegeLevelsPrivileges.PrivilegeID).
-		MODELS(privilegeLevelPrivileges).
-		ON_CONFLICT().
-		DO_NOTHING().
-		Exec(db)
+	if d.SignupLevel != nil {
+		// clear the old level first, only one may be the default
+		_, err := table.PrivilegeLevels.
+			UPDATE(table.PrivilegeLevels.SignupDefault).
+			SET(postgres.Bool(false)).
+			WHERE(table.PrivilegeLevels.SignupDefault.IS_TRUE()).
+			Exec(tx)
+		if err != nil {
+			return err
+		}
+		_, err = table.PrivilegeLevels.
+			UPDATE(table.PrivilegeLevels.SignupDefault).
+			SET(postgres.Bool(true)).
+			WHERE(table.PrivilegeLevels.ID.EQ(postgres.Int(levelIDs[d.SignupLevel.To]))).
+			Exec(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, g := range d.Grants {
+		levelID, privilegeID := levelIDs[g.Level], privilegeIDs[g.Name]
+		if g.Add {
+			_, err = table.PrivilegeLevelsPrivileges.
+				INSERT(table.PrivilegeLevelsPrivileges.PrivilegeLevelID, table.PrivilegeLevelsPrivileges.PrivilegeID).
+				VALUES(levelID, privilegeID).
+				ON_CONFLICT().
+				DO_NOTHING().
+				Exec(tx)
+		} else {
+			_, err = table.PrivilegeLevelsPrivileges.DELETE().
+				WHERE(
+					table.PrivilegeLevelsPrivileges.PrivilegeLevelID.EQ(postgres.Int(levelID)).
+						AND(table.PrivilegeLevelsPrivileges.PrivilegeID.EQ(postgres.Int(privilegeID))),
+				).
+				Exec(tx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range d.Patterns {
+		levelID := levelIDs[p.Level]
+		if p.Add {
+			_, err = table.PrivilegeLevelsPatterns.
+				INSERT(table.PrivilegeLevelsPatterns.PrivilegeLevelID, table.PrivilegeLevelsPatterns.Pattern).
+				VALUES(levelID, p.Name).
+				ON_CONFLICT().
+				DO_NOTHING().
+				Exec(tx)
+		} else {
+			_, err = table.PrivilegeLevelsPatterns.DELETE().
+				WHERE(
+					table.PrivilegeLevelsPatterns.PrivilegeLevelID.EQ(postgres.Int(levelID)).
+						AND(table.PrivilegeLevelsPatterns.Pattern.EQ(postgres.String(p.Name))),
+				).
+				Exec(tx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := d.audit(tx, file); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return NotifyPrivilegesChanged(db)
+}
+
+// audit records the apply as done by the system, listing the changes as String does
+func (d *PrivilegesDiff) audit(tx qrm.Executable, file string) error {
+	after, err := json.Marshal(map[string]any{
+		"file":    file,
+		"changes": strings.Split(strings.TrimSuffix(d.String(), "\n"), "\n"),
+	})
 	if err != nil {
 		return err
 	}
+	afterJSON := string(after)
+	_, err = table.AuditEvents.
+		INSERT(table.AuditEvents.Action, table.AuditEvents.TargetType, table.AuditEvents.TargetID, table.AuditEvents.After).
+		MODEL(model.AuditEvents{
+			Action:     authModels.AuditPrivilegesFileApplied,
+			TargetType: authModels.AuditTargetPrivilegesFile,
+			After:      &afterJSON,
+		}).
+		Exec(tx)
+	return err
+}
 
-	return nil
+// NotifyPrivilegesChanged tells running replicas to drop their cached privileges
+func NotifyPrivilegesChanged(db *sql.DB) error {
+	_, err := postgres.RawStatement("SELECT pg_notify(#channel, '')", postgres.RawArgs{
+		"#channel": constant.PRIVILEGES_CHANGED_CHANNEL,
+	}).Exec(db)
+	return err
 }
 
 func UndoPermissions(db *sql.DB) error {
@@ -86,6 +401,13 @@ func UndoPermissions(db *sql.DB) error {
 		return err
 	}
 
+	_, err = table.PrivilegeLevelsPatterns.DELETE().
+		WHERE(postgres.Bool(true)).
+		Exec(db)
+	if err != nil {
+		return err
+	}
+
 	_, err = table.PrivilegeLevelsPrivileges.DELETE().
 		WHERE(postgres.Bool(true)).
 		Exec(db)
@@ -107,5 +429,5 @@ func UndoPermissions(db *sql.DB) error {
 		return err
 	}
 
-	return nil
+	return NotifyPrivilegesChanged(db)
 }
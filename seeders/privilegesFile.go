@@ -0,0 +1,111 @@
+package seeders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrivilegesFile declares the privilege levels a deployment runs with. Levels
+// missing from the file are left alone.
+type PrivilegesFile struct {
+	// level new users sign up with
+	SignupLevel string      `yaml:"signup_level" json:"signup_level"`
+	Levels      []LevelSeed `yaml:"levels" json:"levels"`
+}
+
+type LevelSeed struct {
+	Name string `yaml:"name" json:"name"`
+	// another level in the file whose privileges this one also has
+	Inherits string `yaml:"inherits" json:"inherits"`
+	// route permission names, like SessionsGet
+	Privileges []string `yaml:"privileges" json:"privileges"`
+	// wildcards over permission names, like Speak*
+	Patterns []string `yaml:"patterns" json:"patterns"`
+}
+
+// LoadPrivilegesFile reads a yaml or, by its .json extension, json privileges file
+func LoadPrivilegesFile(file string) (*PrivilegesFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var pf PrivilegesFile
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&pf)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&pf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := pf.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &pf, nil
+}
+
+func (pf *PrivilegesFile) validate() error {
+	levels := make(map[string]*LevelSeed, len(pf.Levels))
+	for i := range pf.Levels {
+		l := &pf.Levels[i]
+		l.Name = strings.TrimSpace(l.Name)
+		l.Inherits = strings.TrimSpace(l.Inherits)
+		if l.Name == "" || len(l.Name) > 255 {
+			return fmt.Errorf("level %d: name must be 1 to 255 characters", i+1)
+		}
+		if _, ok := levels[l.Name]; ok {
+			return fmt.Errorf("level %q is declared twice", l.Name)
+		}
+		levels[l.Name] = l
+		for j, p := range l.Privileges {
+			l.Privileges[j] = strings.TrimSpace(p)
+			if l.Privileges[j] == "" || len(l.Privileges[j]) > 255 {
+				return fmt.Errorf("level %q: privilege names must be 1 to 255 characters", l.Name)
+			}
+		}
+		for j, p := range l.Patterns {
+			l.Patterns[j] = strings.TrimSpace(p)
+			if _, err := path.Match(l.Patterns[j], ""); err != nil || l.Patterns[j] == "" || len(l.Patterns[j]) > 255 {
+				return fmt.Errorf("level %q: invalid pattern %q", l.Name, p)
+			}
+		}
+	}
+
+	for _, l := range pf.Levels {
+		if l.Inherits == "" {
+			continue
+		}
+		if _, ok := levels[l.Inherits]; !ok {
+			return fmt.Errorf("level %q inherits undeclared level %q", l.Name, l.Inherits)
+		}
+		// walk up the parents, reaching the level again would make a cycle
+		seen := map[string]bool{l.Name: true}
+		for p := l.Inherits; p != ""; p = levels[p].Inherits {
+			if seen[p] {
+				return fmt.Errorf("level %q inherits from itself", l.Name)
+			}
+			seen[p] = true
+		}
+	}
+
+	pf.SignupLevel = strings.TrimSpace(pf.SignupLevel)
+	if pf.SignupLevel == "" {
+		return errors.New("signup_level is required")
+	}
+	if _, ok := levels[pf.SignupLevel]; !ok {
+		return fmt.Errorf("signup_level %q is not a declared level", pf.SignupLevel)
+	}
+	return nil
+}
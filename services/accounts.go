@@ -0,0 +1,209 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidAccountToken covers unknown, used and expired tokens alike
+	ErrInvalidAccountToken = errors.New("this link is invalid or has expired")
+	ErrEmailNotVerified    = errors.New("email not verified")
+)
+
+type accountsService struct {
+	context.ServiceContext
+	cfg cfg.AccountConfig
+}
+
+func NewAccountsService(ctx context.ServiceContext, cfg cfg.AccountConfig) *accountsService {
+	return &accountsService{
+		ServiceContext: ctx,
+		cfg:            cfg,
+	}
+}
+
+// RequestPasswordReset emails a reset link when an account has email. Requests
+// count against the login throttle of the email and ip, and ErrLoginLocked is
+// returned for either account or none alike. The email is looked up and sent in
+// the background so the response takes as long whether or not it has an account.
+func (as *accountsService) RequestPasswordReset(ctx gctx.Context, email string, req *http.Request) error {
+	email = strings.TrimSpace(email)
+	security := as.SM().SecurityService()
+	if err := security.CheckLogin(email, req); err != nil {
+		return err
+	}
+	security.ResetRequested(email, req)
+	go as.sendPasswordReset(gctx.WithoutCancel(ctx), email)
+	return nil
+}
+
+func (as *accountsService) sendPasswordReset(ctx gctx.Context, email string) {
+	lgr := as.Lgr("sendPasswordReset")
+	user, err := as.DM().UsersDAO().GetByEmail(email)
+	if errors.Is(err, qrm.ErrNoRows) {
+		lgr.Info("Password reset for unknown email")
+		return
+	} else if err != nil {
+		lgr.Error("Failed to find user", zap.Error(err))
+		return
+	}
+	token, err := as.issue(user.ID, authModels.TokenPurposeReset, as.cfg.ResetTokenTTL)
+	if err != nil {
+		lgr.Error("Failed to issue reset token", zap.Int64("user id", user.ID), zap.Error(err))
+		return
+	}
+	link := as.link("/reset/confirm", token)
+	err = as.SM().Mailer().Send(ctx, models.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to choose a new password:\n\n%s\n\nIt expires in %s and works once. If you didn't ask for it you can ignore this email.\n",
+			user.FirstName, link, as.cfg.ResetTokenTTL),
+	})
+	if err != nil {
+		lgr.Error("Failed to send password reset", zap.Int64("user id", user.ID), zap.Error(err))
+	}
+}
+
+// ResetPassword redeems a reset token, sets the password and signs the user out
+// everywhere
+func (as *accountsService) ResetPassword(token string, password string) error {
+	lgr := as.Lgr("ResetPassword")
+	row, err := as.consume(token, authModels.TokenPurposeReset)
+	if err != nil {
+		return err
+	}
+	usersDAO := as.DM().UsersDAO()
+	user, err := usersDAO.GetOne(row.UserID, as.DB())
+	if err != nil {
+		return err
+	}
+	hash, err := tools.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	// the link arrived by email, which proves the address
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := usersDAO.Update(user, user.ID, as.DB()); err != nil {
+		lgr.Error("Failed to update password", zap.Int64("user id", user.ID), zap.Error(err))
+		return err
+	}
+	as.SM().SecurityService().Record(authModels.SecurityPasswordReset, &user.ID, user.Email, nil, "")
+	// the reset link isn't a session, so the system is audited as signing them out
+	return as.SM().UsersService().RevokeAllSessions(gctx.Background(), user.ID)
+}
+
+// SendVerification emails the user a link confirming their address
+func (as *accountsService) SendVerification(ctx gctx.Context, user *model.Users) error {
+	token, err := as.issue(user.ID, authModels.TokenPurposeVerify, as.cfg.VerifyTokenTTL)
+	if err != nil {
+		return err
+	}
+	link := as.link("/verify", token)
+	return as.SM().Mailer().Send(ctx, models.Mail{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm this is your email by opening:\n\n%s\n\nThe link expires in %s.\n",
+			user.FirstName, link, as.cfg.VerifyTokenTTL),
+	})
+}
+
+// ResendVerification is SendVerification by email, succeeding without sending
+// for unknown or already verified accounts
+func (as *accountsService) ResendVerification(ctx gctx.Context, email string) error {
+	user, err := as.DM().UsersDAO().GetByEmail(strings.TrimSpace(email))
+	if errors.Is(err, qrm.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return as.SendVerification(ctx, user)
+}
+
+// Verify redeems a verify token, marking the user's email verified
+func (as *accountsService) Verify(token string) error {
+	row, err := as.consume(token, authModels.TokenPurposeVerify)
+	if err != nil {
+		return err
+	}
+	usersDAO := as.DM().UsersDAO()
+	user, err := usersDAO.GetOne(row.UserID, as.DB())
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return usersDAO.Update(user, user.ID, as.DB())
+}
+
+// CheckVerified returns ErrEmailNotVerified for unverified users when
+// verification is required
+func (as *accountsService) CheckVerified(user *model.Users) error {
+	if as.cfg.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// issue replaces the user's tokens for purpose with a new one valid for ttl
+func (as *accountsService) issue(userID int64, purpose string, ttl time.Duration) (string, error) {
+	dao := as.DM().AccountTokensDAO()
+	if err := dao.DeleteByUser(userID, purpose); err != nil {
+		return "", err
+	}
+	token, err := tools.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	row := model.AccountTokens{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: tools.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := dao.Insert(&row, as.DB()); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (as *accountsService) consume(token string, purpose string) (*model.AccountTokens, error) {
+	if token == "" {
+		return nil, ErrInvalidAccountToken
+	}
+	row, err := as.DM().AccountTokensDAO().Consume(tools.HashToken(token), purpose, time.Now())
+	if errors.Is(err, qrm.ErrNoRows) {
+		return nil, ErrInvalidAccountToken
+	} else if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func (as *accountsService) link(path string, token string) string {
+	return as.cfg.PublicURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type fakeTokensDAO struct {
+	DAO.AccountTokensDAO
+}
+
+func (d fakeTokensDAO) DeleteByUser(userID int64, purpose string) error {
+	return nil
+}
+
+func (d fakeTokensDAO) Insert(row *model.AccountTokens, db qrm.Queryable) error {
+	return nil
+}
+
+type fakeAccountDAOs struct {
+	DAO.DAOManager
+	users fakeUsersDAO
+}
+
+func (dm fakeAccountDAOs) UsersDAO() DAO.UsersDAO {
+	return dm.users
+}
+
+func (dm fakeAccountDAOs) AccountTokensDAO() DAO.AccountTokensDAO {
+	return fakeTokensDAO{}
+}
+
+// countedResets locks out once more than free resets were requested
+type countedResets struct {
+	context.SecurityService
+	free      int
+	requested []string
+}
+
+func (s *countedResets) CheckLogin(email string, req *http.Request) error {
+	if len(s.requested) >= s.free {
+		return ErrLoginLocked
+	}
+	return nil
+}
+
+func (s *countedResets) ResetRequested(email string, req *http.Request) {
+	s.requested = append(s.requested, email)
+}
+
+// heldMailer sends nothing until the test releases it
+type heldMailer struct {
+	release chan struct{}
+	sent    chan models.Mail
+}
+
+func (m heldMailer) Send(ctx gctx.Context, mail models.Mail) error {
+	<-m.release
+	m.sent <- mail
+	return nil
+}
+
+func newTestAccounts(free int) (*accountsService, *countedResets, heldMailer) {
+	dm := fakeAccountDAOs{users: fakeUsersDAO{users: map[int64]*model.Users{
+		1: {ID: 1, Email: "ada@example.com", FirstName: "Ada"},
+	}}}
+	security := &countedResets{free: free}
+	mailer := heldMailer{release: make(chan struct{}), sent: make(chan models.Mail, 1)}
+	sm := fakeServiceManager{security: security, mailer: mailer}
+	config := cfg.AccountConfig{PublicURL: "https://app.example.com", ResetTokenTTL: time.Hour}
+	return NewAccountsService(testContext{sm: sm, dm: dm}, config), security, mailer
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	as, security, mailer := newTestAccounts(5)
+	req := httptest.NewRequest("POST", "/reset", nil)
+
+	// returns while the mail is still being sent, as fast as for an unknown email
+	if err := as.RequestPasswordReset(gctx.Background(), " ada@example.com ", req); err != nil {
+		t.Fatal(err)
+	}
+	close(mailer.release)
+	select {
+	case mail := <-mailer.sent:
+		if mail.To != "ada@example.com" || !strings.Contains(mail.Body, "https://app.example.com/reset/confirm?token=") {
+			t.Errorf("got mail %+v, want a reset link for ada", mail)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reset mail sent")
+	}
+
+	if err := as.RequestPasswordReset(gctx.Background(), "nobody@example.com", req); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case mail := <-mailer.sent:
+		t.Errorf("got mail %+v for an unknown email", mail)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(security.requested) != 2 {
+		t.Errorf("got %d throttled requests, want both counted", len(security.requested))
+	}
+}
+
+func TestRequestPasswordResetThrottled(t *testing.T) {
+	as, security, mailer := newTestAccounts(0)
+	close(mailer.release)
+	req := httptest.NewRequest("POST", "/reset", nil)
+
+	if err := as.RequestPasswordReset(gctx.Background(), "ada@example.com", req); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("got %v, want ErrLoginLocked", err)
+	}
+	select {
+	case mail := <-mailer.sent:
+		t.Errorf("got mail %+v while locked out", mail)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(security.requested) != 0 {
+		t.Errorf("got %d counted requests while locked out, want none", len(security.requested))
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
+)
+
+const (
+	// how long to wait for a machine's beep, or the end of its greeting, before
+	// leaving the message anyway
+	voicemailBeepWait = 10 * time.Second
+	// how long a voicemail message may take before hanging up
+	voicemailMessageWait = time.Minute
+)
+
+// answeringMachine runs answering machine detection on the caller audio of an
+// outbound call and acts on the decision
+type answeringMachine struct {
+	context.ServiceContext
+	opts      models.AnswerOptions
+	speaker   context.AgentSpeaker
+	audioDone <-chan struct{}
+
+	mu  sync.Mutex
+	amd *tools.AMD
+	// samples of an incomplete frame
+	pending      []int16
+	frameSamples int
+	decided      bool
+	recording    bool
+
+	decision  chan tools.AMDResult
+	ready     chan struct{}
+	connected atomic.Bool
+}
+
+func newAnsweringMachine(ctx context.ServiceContext, cfg tools.AMDConfig, opts models.AnswerOptions, speaker context.AgentSpeaker, audioDone <-chan struct{}) *answeringMachine {
+	if opts.VoicemailAction == "" {
+		opts.VoicemailAction = models.VoicemailLeaveMessage
+	}
+	return &answeringMachine{
+		ServiceContext: ctx,
+		opts:           opts,
+		speaker:        speaker,
+		audioDone:      audioDone,
+		amd:            tools.NewAMD(cfg),
+		frameSamples:   cfg.VAD.FrameSamples(),
+		decision:       make(chan tools.AMDResult, 1),
+		ready:          make(chan struct{}),
+	}
+}
+
+// Connected reports whether a person answered and the caller audio should reach
+// the agent
+func (am *answeringMachine) Connected() bool {
+	return am.connected.Load()
+}
+
+// Write analyzes 16 bit little endian PCM from the caller
+func (am *answeringMachine) Write(pcm []byte) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	for i := 0; i+1 < len(pcm); i += 2 {
+		am.pending = append(am.pending, int16(binary.LittleEndian.Uint16(pcm[i:])))
+	}
+	for len(am.pending) >= am.frameSamples {
+		frame := am.pending[:am.frameSamples]
+		res := am.amd.Process(frame)
+		am.pending = am.pending[am.frameSamples:]
+		if res.Decision == tools.AMDPending {
+			continue
+		}
+		if !am.decided {
+			am.decided = true
+			am.decision <- res
+		}
+		// a machine records after its beep, or once its greeting ends without one
+		if res.Decision == tools.AMDMachine && !am.recording && (am.amd.Beeped() || am.amd.Silent()) {
+			am.recording = true
+			close(am.ready)
+		}
+	}
+	// keep the backing array from growing with every write
+	am.pending = append([]int16(nil), am.pending...)
+}
+
+// Run waits for the decision, then greets a person or handles the machine
+func (am *answeringMachine) Run(ctx gctx.Context) {
+	lgr := am.Lgr("Run")
+	phone := am.SM().PhoneService()
+	var res tools.AMDResult
+	select {
+	case <-ctx.Done():
+		return
+	case res = <-am.decision:
+	}
+	lgr.Info("call answered", zap.Stringer("by", res.Decision), zap.String("reason", res.Reason), zap.Duration("after", res.After))
+
+	if res.Decision != tools.AMDMachine {
+		am.connected.Store(true)
+		am.recordOutcome(ctx, phone, models.CallAnswered)
+		if am.opts.Greeting != "" {
+			if err := am.speaker.Say(ctx, am.opts.Greeting); err != nil {
+				lgr.Error("Failed to greet caller", zap.Error(err))
+			}
+		}
+		return
+	}
+
+	am.recordOutcome(ctx, phone, models.CallVoicemail)
+	if am.opts.VoicemailAction == models.VoicemailLeaveMessage && am.opts.VoicemailScript != "" {
+		select {
+		case <-ctx.Done():
+			return
+		case <-am.ready:
+		case <-time.After(voicemailBeepWait):
+			lgr.Warn("no beep heard, leaving the message anyway")
+		}
+		if err := am.speaker.Say(ctx, am.opts.VoicemailScript); err != nil {
+			lgr.Error("Failed to leave voicemail", zap.Error(err))
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			case <-am.audioDone:
+			case <-time.After(voicemailMessageWait):
+			}
+		}
+	}
+
+	if err := phone.EndCall(ctx); err != nil && !errors.Is(err, ErrNoActiveCall) {
+		lgr.Error("Failed to hang up", zap.Error(err))
+	}
+	context.GetCancel(ctx)()
+}
+
+func (am *answeringMachine) recordOutcome(ctx gctx.Context, phone context.PhoneService, outcome models.CallOutcome) {
+	if err := phone.RecordOutcome(ctx, outcome); err != nil && !errors.Is(err, ErrNoActiveCall) {
+		am.Lgr("recordOutcome").Error("Failed to record call outcome", zap.Error(err))
+	}
+}
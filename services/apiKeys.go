@@ -0,0 +1,299 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+const (
+	// APIKeyPrefix starts every key so they are easy to spot in code and logs
+	APIKeyPrefix = "ghk_"
+	// the characters of a key kept in the clear to tell keys apart
+	apiKeyShownLen = 12
+	// how often last_used_at is written for a key in constant use
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyExpired        = errors.New("api key expired")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrInvalidAPIKeyName    = errors.New("api key name is required")
+	ErrNoAPIKeyScopes       = errors.New("choose at least one scope")
+	ErrAPIKeyScopeForbidden = errors.New("api keys can only be given privileges you have")
+)
+
+type apiKeysService struct {
+	context.ServiceContext
+
+	mu sync.RWMutex
+	// key to the privileges it was given, loaded on first use. Scopes don't
+	// change after a key is made, and a key revoked on another replica fails
+	// Authenticate before its scopes are looked at.
+	scopes map[int64]map[int64]bool
+	// bumped on every revoke so a load racing one isn't kept
+	generation uint64
+}
+
+func NewAPIKeysService(ctx context.ServiceContext) *apiKeysService {
+	return &apiKeysService{
+		ServiceContext: ctx,
+		scopes:         make(map[int64]map[int64]bool),
+	}
+}
+
+// Create makes a key for the user and organization in ctx limited to privilegeIDs,
+// which must be privileges of the user's level and, when ctx was authenticated by
+// a key, scopes of that key. The returned key is not stored and can't be shown
+// again. A zero expiresIn never expires.
+func (aks *apiKeysService) Create(ctx gctx.Context, name string, expiresIn time.Duration, privilegeIDs []int64) (string, *model.APIKeys, error) {
+	lgr := aks.Lgr("Create")
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return "", nil, ErrInvalidAPIKeyName
+	}
+	if len(privilegeIDs) == 0 {
+		return "", nil, ErrNoAPIKeyScopes
+	}
+	levelID := context.GetPrivilegeLevelID(ctx)
+	privileges := aks.SM().PrivilegesService()
+	// a key may only hand on the scopes it was given itself
+	keyID, byKey := context.LookupAPIKeyID(ctx)
+	for _, id := range privilegeIDs {
+		if !privileges.HasPermissionByID(levelID, id) {
+			return "", nil, ErrAPIKeyScopeForbidden
+		}
+		if byKey && !aks.HasScope(keyID, id) {
+			return "", nil, ErrAPIKeyScopeForbidden
+		}
+	}
+
+	token, err := tools.GenerateToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + token
+	row := model.APIKeys{
+		UserID:         context.GetUserId(ctx),
+		OrganizationID: context.GetOrgID(ctx),
+		Name:           name,
+		Prefix:         key[:apiKeyShownLen],
+		KeyHash:        tools.HashToken(key),
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		row.ExpiresAt = &expiresAt
+	}
+
+	tx, err := aks.DB().Begin()
+	if err != nil {
+		return "", nil, err
+	}
+	defer tx.Rollback()
+
+	if err := aks.DM().APIKeysDAO().Insert(&row, tx); err != nil {
+		lgr.Error("Failed to insert api key", zap.Error(err))
+		return "", nil, err
+	}
+	scopes := make([]*model.APIKeysPrivileges, len(privilegeIDs))
+	for i, id := range privilegeIDs {
+		scopes[i] = &model.APIKeysPrivileges{
+			APIKeyID:    row.ID,
+			PrivilegeID: id,
+		}
+	}
+	if err := aks.DM().APIKeysPrivilegesDAO().UpsertMany(&scopes, tx); err != nil {
+		lgr.Error("Failed to insert api key scopes", zap.Int64("api key", row.ID), zap.Error(err))
+		return "", nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", nil, err
+	}
+	return key, &row, nil
+}
+
+// Authenticate finds the key, recording that it was used
+func (aks *apiKeysService) Authenticate(key string) (*model.APIKeys, error) {
+	lgr := aks.Lgr("Authenticate")
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	row, err := aks.DM().APIKeysDAO().GetByHash(tools.HashToken(key))
+	if errors.Is(err, qrm.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if row.ExpiresAt != nil && !now.Before(*row.ExpiresAt) {
+		aks.forgetScopes(row.ID)
+		return nil, ErrAPIKeyExpired
+	}
+	if row.LastUsedAt == nil || now.Sub(*row.LastUsedAt) >= apiKeyTouchInterval {
+		if err := aks.DM().APIKeysDAO().Touch(row.ID, now); err != nil {
+			lgr.Warn("Failed to record api key use", zap.Int64("api key", row.ID), zap.Error(err))
+		}
+		row.LastUsedAt = &now
+	}
+	return row, nil
+}
+
+// HasScope reports whether the key was given the privilege
+func (aks *apiKeysService) HasScope(keyID int64, privilegeID int64) bool {
+	scopes, err := aks.getScopes(keyID)
+	if err != nil {
+		aks.Lgr("HasScope").Error("Failed to load api key scopes", zap.Int64("api key", keyID), zap.Error(err))
+		return false
+	}
+	return scopes[privilegeID]
+}
+
+func (aks *apiKeysService) getScopes(keyID int64) (map[int64]bool, error) {
+	aks.mu.RLock()
+	scopes, ok := aks.scopes[keyID]
+	generation := aks.generation
+	aks.mu.RUnlock()
+	if ok {
+		return scopes, nil
+	}
+
+	rows, err := aks.DM().APIKeysPrivilegesDAO().GetScopes([]int64{keyID})
+	if err != nil {
+		return nil, err
+	}
+	scopes = make(map[int64]bool, len(rows))
+	for _, r := range rows {
+		scopes[r.PrivilegeID] = true
+	}
+	aks.mu.Lock()
+	if aks.generation == generation {
+		aks.scopes[keyID] = scopes
+	}
+	aks.mu.Unlock()
+	return scopes, nil
+}
+
+func (aks *apiKeysService) forgetScopes(keyID int64) {
+	aks.mu.Lock()
+	delete(aks.scopes, keyID)
+	aks.generation++
+	aks.mu.Unlock()
+}
+
+// Revoke deletes one of the user's keys
+func (aks *apiKeysService) Revoke(userID int64, id int64) error {
+	lgr := aks.Lgr("Revoke")
+	lgr.Info("Revoking api key", zap.Int64("user id", userID), zap.Int64("api key", id))
+	deleted, err := aks.DM().APIKeysDAO().DeleteByUser(userID, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAPIKeyNotFound
+	}
+	aks.forgetScopes(id)
+	return nil
+}
+
+// AvailableScopes lists the effective privileges of the level, the ones a key
+// may be given
+func (aks *apiKeysService) AvailableScopes(levelID int64) ([]authModels.JoinedPrivilegesRaw, error) {
+	effective, err := aks.SM().PrivilegesService().EffectivePrivileges()
+	if err != nil {
+		return nil, err
+	}
+	var scopes []authModels.JoinedPrivilegesRaw
+	for _, ep := range effective {
+		if ep.LevelID == levelID {
+			scopes = append(scopes, authModels.JoinedPrivilegesRaw{
+				LevelID:       ep.LevelID,
+				LevelName:     ep.LevelName,
+				PrivilegeID:   ep.PrivilegeID,
+				PrivilegeName: ep.PrivilegeName,
+			})
+		}
+	}
+	return scopes, nil
+}
+
+func (aks *apiKeysService) APIKeysAsRowData(keys []*model.APIKeys, scopes []authModels.APIKeyScope) []datadisplay.RowData {
+	names := make(map[int64][]string, len(keys))
+	for _, s := range scopes {
+		names[s.APIKeyID] = append(names[s.APIKeyID], s.PrivilegeName)
+	}
+	rows := make([]datadisplay.RowData, len(keys))
+	for i, k := range keys {
+		created, lastUsed, expires := "-", "Never", "Never"
+		if k.CreatedAt != nil {
+			created = k.CreatedAt.Format("2006-01-02 15:04")
+		}
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		if k.ExpiresAt != nil {
+			expires = k.ExpiresAt.Format("2006-01-02 15:04")
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "na-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(k.Name, datadisplay.MD),
+				},
+				{
+					ID:    "pr-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(k.Prefix+"…", datadisplay.SM),
+				},
+				{
+					ID:    "sc-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(strings.Join(names[k.ID], ", "), datadisplay.XS),
+				},
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(created, datadisplay.SM),
+				},
+				{
+					ID:    "lu-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(lastUsed, datadisplay.SM),
+				},
+				{
+					ID:    "ex-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(expires, datadisplay.SM),
+				},
+				{
+					ID:    "rv-" + strconv.Itoa(i),
+					Width: 1,
+					Body: datadisplay.X(templ.Attributes{
+						"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+						"hx-delete":  fmt.Sprintf("/api-keys/%d", k.ID),
+						"hx-trigger": "click",
+						"hx-swap":    "none",
+						"hx-confirm": "Revoke this key? Anything using it will stop working.",
+						"_":          "on htmx:afterRequest if event.detail.successful remove closest <tr/>",
+					}),
+				},
+			},
+		}
+	}
+	return rows
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/models/authModels"
+)
+
+type fakeKeyScopesDAO struct {
+	DAO.APIKeysPrivilegesDAO
+	scopes map[authModels.APIKeysPrivilegesPrimaryKey]bool
+	loads  *int
+}
+
+func (d fakeKeyScopesDAO) GetScopes(keyIDs []int64) ([]authModels.APIKeyScope, error) {
+	*d.loads++
+	var rows []authModels.APIKeyScope
+	for pk, ok := range d.scopes {
+		if ok && slices.Contains(keyIDs, pk.APIKeyID) {
+			rows = append(rows, authModels.APIKeyScope{APIKeyID: pk.APIKeyID, PrivilegeID: pk.PrivilegeID})
+		}
+	}
+	return rows, nil
+}
+
+type revokedKeysDAO struct {
+	DAO.APIKeysDAO
+}
+
+func (d revokedKeysDAO) DeleteByUser(userID int64, id int64) (int64, error) {
+	return 1, nil
+}
+
+type fakeAPIKeyDAOs struct {
+	DAO.DAOManager
+	scopes fakeKeyScopesDAO
+}
+
+func (dm fakeAPIKeyDAOs) APIKeysPrivilegesDAO() DAO.APIKeysPrivilegesDAO {
+	return dm.scopes
+}
+
+func (dm fakeAPIKeyDAOs) APIKeysDAO() DAO.APIKeysDAO {
+	return revokedKeysDAO{}
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	const (
+		level     int64 = 10
+		listPriv  int64 = 100
+		postPriv  int64 = 101
+		adminPriv int64 = 102
+		callerKey int64 = 500
+	)
+	dm := fakeAPIKeyDAOs{scopes: fakeKeyScopesDAO{scopes: map[authModels.APIKeysPrivilegesPrimaryKey]bool{
+		{APIKeyID: callerKey, PrivilegeID: postPriv}: true,
+	}, loads: new(int)}}
+	sm := fakeServiceManager{privileges: levelPrivileges{granted: map[int64][]int64{level: {listPriv, postPriv}}}}
+	aks := NewAPIKeysService(testContext{sm: sm, dm: dm})
+	session := context.WithPrivilegeLevelID(context.WithUserId(gctx.Background(), 1), level)
+
+	tests := []struct {
+		name   string
+		ctx    gctx.Context
+		scopes []int64
+	}{
+		{name: "privilege the level lacks", ctx: session, scopes: []int64{adminPriv}},
+		// the user has listPriv but the calling key was only given postPriv
+		{name: "privilege the key lacks", ctx: context.WithAPIKeyID(session, callerKey), scopes: []int64{postPriv, listPriv}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := aks.Create(tt.ctx, "minted", time.Hour, tt.scopes); !errors.Is(err, ErrAPIKeyScopeForbidden) {
+				t.Errorf("got %v, want ErrAPIKeyScopeForbidden", err)
+			}
+		})
+	}
+}
+
+func TestHasScopeCachesUntilRevoked(t *testing.T) {
+	const (
+		key      int64 = 500
+		listPriv int64 = 100
+		postPriv int64 = 101
+	)
+	scopes := fakeKeyScopesDAO{scopes: map[authModels.APIKeysPrivilegesPrimaryKey]bool{
+		{APIKeyID: key, PrivilegeID: listPriv}: true,
+	}, loads: new(int)}
+	aks := NewAPIKeysService(testContext{dm: fakeAPIKeyDAOs{scopes: scopes}})
+
+	for range 3 {
+		if !aks.HasScope(key, listPriv) {
+			t.Fatal("got no list scope, want it")
+		}
+		if aks.HasScope(key, postPriv) {
+			t.Fatal("got post scope, want only list")
+		}
+	}
+	if *scopes.loads != 1 {
+		t.Errorf("loaded scopes %d times, want once", *scopes.loads)
+	}
+
+	if err := aks.Revoke(1, key); err != nil {
+		t.Fatal(err)
+	}
+	delete(scopes.scopes, authModels.APIKeysPrivilegesPrimaryKey{APIKeyID: key, PrivilegeID: listPriv})
+	if aks.HasScope(key, listPriv) {
+		t.Error("got list scope after revoking the key, want none")
+	}
+	if *scopes.loads != 2 {
+		t.Errorf("loaded scopes %d times, want again after revoking", *scopes.loads)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"go.uber.org/zap"
+)
+
+type auditService struct {
+	context.ServiceContext
+}
+
+func NewAuditService(ctx context.ServiceContext) *auditService {
+	return &auditService{
+		ServiceContext: ctx,
+	}
+}
+
+// Record saves that the user of ctx, or the system when there is none, did
+// action to the target. before and after are stored as json, nil for none. A
+// failure is logged rather than returned since the change already happened.
+func (aud *auditService) Record(ctx gctx.Context, action string, targetType string, targetID int64, before any, after any) {
+	lgr := aud.Lgr("Record")
+	event := model.AuditEvents{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if id, ok := context.LookupUserId(ctx); ok {
+		event.ActorID = &id
+	}
+	if id, ok := context.LookupAPIKeyID(ctx); ok {
+		event.APIKeyID = &id
+	}
+	var err error
+	if event.Before, err = auditJSON(before); err != nil {
+		lgr.Error("Failed to encode audit before", zap.String("action", action), zap.Error(err))
+	}
+	if event.After, err = auditJSON(after); err != nil {
+		lgr.Error("Failed to encode audit after", zap.String("action", action), zap.Error(err))
+	}
+	if err := aud.DM().AuditEventsDAO().Insert(&event, aud.DB()); err != nil {
+		lgr.Error("Failed to record audit event",
+			zap.String("action", action),
+			zap.String("target type", targetType),
+			zap.Int64("target id", targetID),
+			zap.Error(err),
+		)
+	}
+}
+
+func auditJSON(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+func (aud *auditService) EventsAsRowData(events []authModels.AuditEventJoin) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(events))
+	for i, e := range events {
+		created, before, after := "-", "-", "-"
+		if e.CreatedAt != nil {
+			created = e.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+		if e.Before != nil {
+			before = *e.Before
+		}
+		if e.After != nil {
+			after = *e.After
+		}
+		actor := "system"
+		if e.ActorEmail != nil {
+			actor = *e.ActorEmail
+		} else if e.ActorID != nil {
+			actor = "user " + strconv.FormatInt(*e.ActorID, 10)
+		}
+		if e.APIKeyID != nil {
+			actor += " (api key " + strconv.FormatInt(*e.APIKeyID, 10) + ")"
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(created, datadisplay.SM),
+				},
+				{
+					ID:    "ac-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(actor, datadisplay.SM),
+				},
+				{
+					ID:    "an-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(e.Action, datadisplay.SM),
+				},
+				{
+					ID:    "tg-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(e.TargetType+" "+strconv.FormatInt(e.TargetID, 10), datadisplay.SM),
+				},
+				{
+					ID:    "bf-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(before, datadisplay.XS),
+				},
+				{
+					ID:    "af-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(after, datadisplay.XS),
+				},
+			},
+		}
+	}
+	return rows
+}
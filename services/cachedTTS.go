@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
+)
+
+var ErrTTSCacheMiss = errors.New("tts cache miss while offline")
+
+// how much cached audio is written per chunk when replaying it
+const cachedTTSChunk = 100 * time.Millisecond
+
+// cachedTTSService wraps a TTS provider and serves repeated phrases from the
+// TTSCache instead of synthesizing them again.
+type cachedTTSService struct {
+	context.ServiceContext
+	provider string
+	next     context.ElevenLabsService
+	cache    context.TTSCache
+}
+
+func NewCachedTTSService(ctx context.ServiceContext, provider string, next context.ElevenLabsService, cache context.TTSCache) *cachedTTSService {
+	return &cachedTTSService{
+		ServiceContext: ctx,
+		provider:       provider,
+		next:           next,
+		cache:          cache,
+	}
+}
+
+func (c *cachedTTSService) DefaultTTSRequest(msg string) models.TTSRequest {
+	return c.next.DefaultTTSRequest(msg)
+}
+
+// TextToSpeechStream is for writers that play the audio as it arrives, so cache
+// hits are paced
+func (c *cachedTTSService) TextToSpeechStream(msg string, w io.Writer) error {
+	req := c.DefaultTTSRequest(msg)
+	req.Paced = true
+	return c.Synthesize(req, w)
+}
+
+func (c *cachedTTSService) Synthesize(req models.TTSRequest, w io.Writer) error {
+	lgr := c.Lgr("cachedTTS.Synthesize")
+	key := c.cache.Key(c.provider, req)
+
+	if audio, ok := c.cache.Get(key); ok {
+		lgr.Debug("cache hit", zap.String("key", key))
+		// a player fed the whole clip at once would buffer or drop it, anything
+		// else gets it as fast as it can take it
+		if rate, ok := tools.PCMSampleRate(req.Format); ok && req.Paced {
+			return tools.WritePCMPaced(w, audio, rate, cachedTTSChunk)
+		}
+		_, err := w.Write(audio)
+		return err
+	}
+
+	if c.cache.Offline() {
+		lgr.Warn("cache miss while offline", zap.String("key", key))
+		return ErrTTSCacheMiss
+	}
+
+	var buf bytes.Buffer
+	if err := c.next.Synthesize(req, io.MultiWriter(w, &buf)); err != nil {
+		return err
+	}
+	if err := c.cache.Put(key, buf.Bytes()); err != nil {
+		lgr.Warn("failed to cache tts audio", zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+)
+
+// countingTTS synthesizes a fixed clip and counts how often it was asked to
+type countingTTS struct {
+	context.ElevenLabsService
+	audio []byte
+	calls int
+}
+
+func (t *countingTTS) DefaultTTSRequest(msg string) models.TTSRequest {
+	return models.TTSRequest{Text: msg, Voice: "voice", Model: "model", Format: "pcm_16000"}
+}
+
+func (t *countingTTS) Synthesize(req models.TTSRequest, w io.Writer) error {
+	t.calls++
+	_, err := w.Write(t.audio)
+	return err
+}
+
+// 300ms of 16 kHz pcm
+var testClip = bytes.Repeat([]byte{1, 0}, 4800)
+
+func newTestCachedTTS(config cfg.TTSCacheConfig) (*cachedTTSService, *countingTTS) {
+	if config.MaxBytes == 0 {
+		config.MaxBytes = 1 << 20
+	}
+	next := &countingTTS{audio: testClip}
+	cache := NewTTSCache(testContext{}, config)
+	return NewCachedTTSService(testContext{}, "test", next, cache), next
+}
+
+func synthesizeTimed(t *testing.T, tts *cachedTTSService, req models.TTSRequest) ([]byte, time.Duration) {
+	t.Helper()
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := tts.Synthesize(req, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), time.Since(start)
+}
+
+func TestCachedTTSServesRepeats(t *testing.T) {
+	tts, next := newTestCachedTTS(cfg.TTSCacheConfig{})
+	req := tts.DefaultTTSRequest("Thanks for calling.")
+
+	if audio, _ := synthesizeTimed(t, tts, req); !bytes.Equal(audio, testClip) {
+		t.Errorf("got %d bytes on a miss, want the synthesized clip", len(audio))
+	}
+	// whitespace doesn't change the audio so shares the entry
+	req.Text = "  Thanks for   calling. "
+	audio, took := synthesizeTimed(t, tts, req)
+	if !bytes.Equal(audio, testClip) {
+		t.Errorf("got %d bytes on a hit, want the cached clip", len(audio))
+	}
+	if next.calls != 1 {
+		t.Errorf("got %d provider calls, want 1", next.calls)
+	}
+	// a buffer is not a player, it gets the clip at once
+	if took > 100*time.Millisecond {
+		t.Errorf("unpaced hit took %s", took)
+	}
+
+	req.Voice = "other"
+	synthesizeTimed(t, tts, req)
+	if next.calls != 2 {
+		t.Errorf("got %d provider calls after changing the voice, want 2", next.calls)
+	}
+}
+
+func TestCachedTTSPacedHit(t *testing.T) {
+	tts, _ := newTestCachedTTS(cfg.TTSCacheConfig{})
+	req := tts.DefaultTTSRequest("Please hold.")
+	synthesizeTimed(t, tts, req)
+
+	req.Paced = true
+	audio, took := synthesizeTimed(t, tts, req)
+	if !bytes.Equal(audio, testClip) {
+		t.Errorf("got %d bytes, want the cached clip", len(audio))
+	}
+	// the last 100ms chunk is written without waiting after it
+	if took < 150*time.Millisecond {
+		t.Errorf("paced hit of a 300ms clip took only %s", took)
+	}
+}
+
+func TestCachedTTSOffline(t *testing.T) {
+	dir := t.TempDir()
+	warm, _ := newTestCachedTTS(cfg.TTSCacheConfig{Dir: dir})
+	synthesizeTimed(t, warm, warm.DefaultTTSRequest("Goodbye."))
+
+	// a fresh process over the pre-warmed directory never calls the provider
+	tts, next := newTestCachedTTS(cfg.TTSCacheConfig{Dir: dir, Offline: true})
+	if audio, _ := synthesizeTimed(t, tts, tts.DefaultTTSRequest("Goodbye.")); !bytes.Equal(audio, testClip) {
+		t.Errorf("got %d bytes from the warm directory, want the cached clip", len(audio))
+	}
+	var buf bytes.Buffer
+	if err := tts.Synthesize(tts.DefaultTTSRequest("Hello."), &buf); !errors.Is(err, ErrTTSCacheMiss) {
+		t.Errorf("got %v for an uncached phrase, want ErrTTSCacheMiss", err)
+	}
+	if next.calls != 0 {
+		t.Errorf("got %d provider calls offline, want none", next.calls)
+	}
+}
@@ -0,0 +1,529 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+const (
+	campaignTickInterval = 15 * time.Second
+	// calls ringing without an outcome for this long are counted as unanswered
+	campaignCallTimeout = 10 * time.Minute
+	minutesPerDay       = 24 * 60
+)
+
+var (
+	ErrInvalidCampaign     = errors.New("invalid campaign")
+	ErrInvalidStatusChange = errors.New("campaign cannot change to that status")
+	ErrNoPhoneColumn       = errors.New("csv has no phone column")
+)
+
+type campaignsService struct {
+	context.ServiceContext
+	// ticks and outcomes both move contacts between statuses
+	mu sync.Mutex
+}
+
+func NewCampaignsService(ctx context.ServiceContext) *campaignsService {
+	return &campaignsService{ServiceContext: ctx}
+}
+
+// Create validates and inserts a draft campaign
+func (cs *campaignsService) Create(c *model.Campaigns) error {
+	lgr := cs.Lgr("Create")
+	c.Name = strings.TrimSpace(c.Name)
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidCampaign)
+	case c.WindowStart < 0 || c.WindowStart >= minutesPerDay || c.WindowEnd < 0 || c.WindowEnd > minutesPerDay:
+		return fmt.Errorf("%w: calling window must be within a day", ErrInvalidCampaign)
+	case c.WindowStart == c.WindowEnd:
+		return fmt.Errorf("%w: calling window is empty", ErrInvalidCampaign)
+	case c.MaxConcurrent < 1 || c.MaxAttempts < 1 || c.RetryDelayMinutes < 0:
+		return fmt.Errorf("%w: concurrency and attempts must be at least 1", ErrInvalidCampaign)
+	}
+	if c.Timezone == "" {
+		c.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidCampaign, c.Timezone)
+	}
+	c.Status = agentModels.CampaignDraft
+	if err := cs.DM().CampaignsDAO().Insert(c, cs.DB()); err != nil {
+		lgr.Error("Failed to create campaign", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SetStatus starts or pauses a campaign. Completed campaigns can be started again
+// after new contacts are uploaded.
+func (cs *campaignsService) SetStatus(orgID int64, id int64, status string) (*model.Campaigns, error) {
+	lgr := cs.Lgr("SetStatus")
+	dao := cs.DM().CampaignsDAO()
+	c, err := dao.GetOneInOrg(orgID, id, cs.DB())
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case agentModels.CampaignRunning:
+	case agentModels.CampaignPaused:
+		if c.Status != agentModels.CampaignRunning {
+			return nil, ErrInvalidStatusChange
+		}
+	default:
+		return nil, ErrInvalidStatusChange
+	}
+	c.Status = status
+	if err := dao.UpdateInOrg(orgID, c, id, cs.DB()); err != nil {
+		lgr.Error("Failed to update campaign", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return c, nil
+}
+
+// ImportContacts reads a csv with a phone column and an optional name column.
+// Numbers are normalized and deduplicated, uploading a number again updates its
+// name. It returns how many rows were imported and skipped as invalid.
+func (cs *campaignsService) ImportContacts(campaignID int64, r io.Reader) (int, int, error) {
+	lgr := cs.Lgr("ImportContacts")
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return 0, 0, err
+	}
+	phoneCol, nameCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "phone", "phone_number", "number":
+			phoneCol = i
+		case "name":
+			nameCol = i
+		}
+	}
+	if phoneCol < 0 {
+		return 0, 0, ErrNoPhoneColumn
+	}
+
+	seen := make(map[string]bool)
+	var contacts []*model.CampaignContacts
+	skipped := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if phoneCol >= len(record) {
+			skipped++
+			continue
+		}
+		phone, ok := NormalizePhone(record[phoneCol])
+		if !ok {
+			skipped++
+			continue
+		}
+		if seen[phone] {
+			continue
+		}
+		seen[phone] = true
+		contact := &model.CampaignContacts{
+			CampaignID: campaignID,
+			Phone:      phone,
+			Status:     agentModels.ContactPending,
+		}
+		if nameCol >= 0 && nameCol < len(record) {
+			contact.Name = strings.TrimSpace(record[nameCol])
+		}
+		contacts = append(contacts, contact)
+	}
+	if len(contacts) == 0 {
+		return 0, skipped, nil
+	}
+	if err := cs.DM().CampaignContactsDAO().UpsertMany(&contacts, cs.DB()); err != nil {
+		lgr.Error("Failed to import contacts", zap.Int64("campaign", campaignID), zap.Error(err))
+		return 0, 0, err
+	}
+	return len(contacts), skipped, nil
+}
+
+// NormalizePhone keeps the digits of a number and a leading +, rejecting numbers
+// that are too short or long to dial
+func NormalizePhone(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	var sb strings.Builder
+	if strings.HasPrefix(raw, "+") {
+		sb.WriteByte('+')
+	}
+	digits := 0
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// InCallingWindow reports whether t falls in the campaign's calling window, in
+// its timezone. Windows that end before they start wrap past midnight.
+func InCallingWindow(c *model.Campaigns, t time.Time) bool {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	minute := int32(local.Hour()*60 + local.Minute())
+	if c.WindowStart <= c.WindowEnd {
+		return minute >= c.WindowStart && minute < c.WindowEnd
+	}
+	return minute >= c.WindowStart || minute < c.WindowEnd
+}
+
+// Run dials due contacts of running campaigns until ctx is done
+func (cs *campaignsService) Run(ctx gctx.Context) {
+	lgr := cs.Lgr("Run")
+	cs.SM().PhoneService().OnOutcome(func(callID string, outcome models.CallOutcome) {
+		if err := cs.RecordOutcome(callID, outcome); err != nil {
+			lgr.Error("Failed to record call outcome", zap.String("call", callID), zap.Error(err))
+		}
+	})
+	ticker := time.NewTicker(campaignTickInterval)
+	defer ticker.Stop()
+	for {
+		if err := cs.tick(ctx, time.Now()); err != nil {
+			lgr.Error("Campaign tick failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cs *campaignsService) tick(ctx gctx.Context, now time.Time) error {
+	lgr := cs.Lgr("tick")
+	contactsDAO := cs.DM().CampaignContactsDAO()
+
+	stale, err := contactsDAO.GetStaleCalling(now.Add(-campaignCallTimeout))
+	if err != nil {
+		return err
+	}
+	for _, contact := range stale {
+		if contact.CallID != nil {
+			if err := cs.RecordOutcome(*contact.CallID, models.CallNoAnswer); err != nil {
+				lgr.Error("Failed to time out call", zap.Int64("contact", contact.ID), zap.Error(err))
+			}
+		}
+	}
+
+	campaigns, err := cs.DM().CampaignsDAO().GetByStatus(agentModels.CampaignRunning)
+	if err != nil {
+		return err
+	}
+	for _, c := range campaigns {
+		if !InCallingWindow(c, now) {
+			continue
+		}
+		if err := cs.dialDue(ctx, c, now); err != nil {
+			lgr.Error("Failed to dial campaign", zap.Int64("campaign", c.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// dialDue fills the campaign's free call slots with due contacts
+func (cs *campaignsService) dialDue(ctx gctx.Context, c *model.Campaigns, now time.Time) error {
+	cs.mu.Lock()
+	failed, err := cs.dialDueLocked(ctx, c, now)
+	cs.mu.Unlock()
+	// recorded once the lock is released
+	for _, callID := range failed {
+		if err := cs.RecordOutcome(callID, models.CallFailed); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// dialDueLocked returns the ids of calls that could not be placed
+func (cs *campaignsService) dialDueLocked(ctx gctx.Context, c *model.Campaigns, now time.Time) ([]string, error) {
+	dao := cs.DM().CampaignContactsDAO()
+	onCall, err := dao.CountByStatus(c.ID, agentModels.ContactCalling, agentModels.ContactConnected)
+	if err != nil {
+		return nil, err
+	}
+	free := int64(c.MaxConcurrent) - onCall
+	if free <= 0 {
+		return nil, nil
+	}
+
+	tx, err := cs.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	due, err := dao.GetDue(c.ID, now, free, tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		return nil, cs.completeIfFinished(c)
+	}
+	calls := make([]models.OutboundCall, 0, len(due))
+	for _, contact := range due {
+		token, err := tools.GenerateToken(8)
+		if err != nil {
+			return nil, err
+		}
+		callID := "campaign-" + token
+		contact.Status = agentModels.ContactCalling
+		contact.Attempts++
+		contact.CallID = &callID
+		contact.LastAttemptAt = &now
+		contact.NextAttemptAt = nil
+		if err := dao.Update(contact, contact.ID, tx); err != nil {
+			return nil, err
+		}
+		calls = append(calls, models.OutboundCall{CallID: callID, To: contact.Phone, ProfileID: c.ProfileID})
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// dialed once claimed so outcomes always find their contact calling
+	var dialed, failed []string
+	for _, call := range calls {
+		dialed = append(dialed, call.CallID)
+		if err := cs.SM().PhoneService().Dial(ctx, call); err != nil {
+			failed = append(failed, call.CallID)
+		}
+	}
+	cs.Lgr("dialDue").Info("dialed contacts", zap.Int64("campaign", c.ID), zap.Strings("calls", dialed))
+	return failed, nil
+}
+
+// RecordOutcome updates the contact dialed with callID. Unanswered and failed
+// calls, and voicemail when the campaign allows it, are retried after the retry
+// delay until the campaign's attempts run out.
+func (cs *campaignsService) RecordOutcome(callID string, outcome models.CallOutcome) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	dao := cs.DM().CampaignContactsDAO()
+	contact, err := dao.GetByCallID(callID)
+	if errors.Is(err, qrm.ErrNoRows) {
+		// not a campaign call
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if contact.Status != agentModels.ContactCalling && contact.Status != agentModels.ContactConnected {
+		return nil
+	}
+	c, err := cs.DM().CampaignsDAO().GetOne(contact.CampaignID, cs.DB())
+	if err != nil {
+		return err
+	}
+
+	o := string(outcome)
+	contact.Outcome = &o
+	switch outcome {
+	case models.CallAnswered:
+		// still on the call, the final outcome follows. Connected contacts are
+		// not timed out like ringing ones.
+		contact.Status = agentModels.ContactConnected
+	case models.CallNoAnswer, models.CallFailed, models.CallVoicemail:
+		retry := outcome != models.CallVoicemail || c.RetryVoicemail
+		if retry && contact.Attempts < c.MaxAttempts {
+			next := time.Now().Add(time.Duration(c.RetryDelayMinutes) * time.Minute)
+			contact.Status = agentModels.ContactPending
+			contact.NextAttemptAt = &next
+		} else {
+			contact.Status = agentModels.ContactDone
+		}
+	default:
+		contact.Status = agentModels.ContactDone
+	}
+	if err := dao.Update(contact, contact.ID, cs.DB()); err != nil {
+		return err
+	}
+	return cs.completeIfFinished(c)
+}
+
+// completeIfFinished marks a running campaign completed once no contact is
+// pending, ringing or on a call
+func (cs *campaignsService) completeIfFinished(c *model.Campaigns) error {
+	if c.Status != agentModels.CampaignRunning {
+		return nil
+	}
+	n, err := cs.DM().CampaignContactsDAO().CountByStatus(c.ID, agentModels.ContactPending, agentModels.ContactCalling, agentModels.ContactConnected)
+	if err != nil || n > 0 {
+		return err
+	}
+	c.Status = agentModels.CampaignCompleted
+	return cs.DM().CampaignsDAO().Update(c, c.ID, cs.DB())
+}
+
+func (cs *campaignsService) OutcomeCounts(contacts []*model.CampaignContacts) agentModels.CampaignOutcomeCounts {
+	counts := agentModels.CampaignOutcomeCounts{
+		Total:    len(contacts),
+		Outcomes: make(map[string]int),
+	}
+	for _, contact := range contacts {
+		switch contact.Status {
+		case agentModels.ContactPending:
+			counts.Pending++
+		case agentModels.ContactCalling, agentModels.ContactConnected:
+			counts.Calling++
+		case agentModels.ContactDone:
+			counts.Done++
+		}
+		if contact.Outcome != nil {
+			counts.Outcomes[*contact.Outcome]++
+		}
+	}
+	return counts
+}
+
+// ExportCSV writes the campaign's contacts and their latest outcome
+func (cs *campaignsService) ExportCSV(campaignID int64, w io.Writer) error {
+	contacts, err := cs.DM().CampaignContactsDAO().GetByCampaign(campaignID)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"phone", "name", "status", "outcome", "attempts", "last_attempt_at"}); err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		outcome, lastAttempt := "", ""
+		if c.Outcome != nil {
+			outcome = *c.Outcome
+		}
+		if c.LastAttemptAt != nil {
+			lastAttempt = c.LastAttemptAt.UTC().Format(time.RFC3339)
+		}
+		record := []string{c.Phone, c.Name, c.Status, outcome, strconv.Itoa(int(c.Attempts)), lastAttempt}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (cs *campaignsService) CampaignsAsRowData(campaigns []*model.Campaigns) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(campaigns))
+	for i, c := range campaigns {
+		window := fmt.Sprintf("%s - %s %s", FormatMinutes(c.WindowStart), FormatMinutes(c.WindowEnd), c.Timezone)
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "na-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Link(c.Name, fmt.Sprintf("/campaigns/%d", c.ID), datadisplay.SM),
+				},
+				{
+					ID:    "st-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(c.Status, datadisplay.SM),
+				},
+				{
+					ID:    "wi-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(window, datadisplay.SM),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+func (cs *campaignsService) ContactsAsRowData(contacts []*model.CampaignContacts) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(contacts))
+	for i, c := range contacts {
+		outcome, lastAttempt := "-", "Never"
+		if c.Outcome != nil {
+			outcome = *c.Outcome
+		}
+		if c.LastAttemptAt != nil {
+			lastAttempt = c.LastAttemptAt.Format("2006-01-02 15:04")
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ph-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(c.Phone, datadisplay.SM),
+				},
+				{
+					ID:    "na-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(c.Name, datadisplay.SM),
+				},
+				{
+					ID:    "st-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(c.Status, datadisplay.SM),
+				},
+				{
+					ID:    "ou-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(outcome, datadisplay.SM),
+				},
+				{
+					ID:    "at-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(strconv.Itoa(int(c.Attempts)), datadisplay.SM),
+				},
+				{
+					ID:    "la-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(lastAttempt, datadisplay.SM),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+// FormatMinutes formats minutes after midnight as HH:MM
+func FormatMinutes(m int32) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// ParseMinutes parses HH:MM into minutes after midnight
+func ParseMinutes(s string) (int32, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return int32(t.Hour()*60 + t.Minute()), nil
+}
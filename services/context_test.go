@@ -0,0 +1,80 @@
+package services
+
+import (
+	gctx "context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"go.uber.org/zap"
+)
+
+// testContext is a ServiceContext for services that don't touch the database,
+// or only through fake DAOs. DB can begin and commit transactions, which the
+// fake DAOs ignore, but runs no statements.
+type testContext struct {
+	sm context.ServiceManager
+	dm DAO.DAOManager
+}
+
+func (c testContext) Lgr(name string) *zap.Logger {
+	return zap.NewNop()
+}
+
+func (c testContext) SM() context.ServiceManager {
+	return c.sm
+}
+
+func (c testContext) DM() DAO.DAOManager {
+	return c.dm
+}
+
+func (c testContext) DB() *sql.DB {
+	return txOnlyDB
+}
+
+var txOnlyDB = sql.OpenDB(txOnlyConnector{})
+
+var errTxOnly = errors.New("the test database only runs transactions")
+
+type txOnlyConnector struct{}
+
+func (txOnlyConnector) Connect(ctx gctx.Context) (driver.Conn, error) {
+	return txOnlyConn{}, nil
+}
+
+func (txOnlyConnector) Driver() driver.Driver {
+	return txOnlyDriver{}
+}
+
+type txOnlyDriver struct{}
+
+func (txOnlyDriver) Open(name string) (driver.Conn, error) {
+	return txOnlyConn{}, nil
+}
+
+type txOnlyConn struct{}
+
+func (txOnlyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errTxOnly
+}
+
+func (txOnlyConn) Close() error {
+	return nil
+}
+
+func (txOnlyConn) Begin() (driver.Tx, error) {
+	return txOnlyTx{}, nil
+}
+
+type txOnlyTx struct{}
+
+func (txOnlyTx) Commit() error {
+	return nil
+}
+
+func (txOnlyTx) Rollback() error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	gctx "context"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"go.uber.org/zap"
+)
+
+type conversationsService struct {
+	context.ServiceContext
+}
+
+func NewConversationsService(ctx context.ServiceContext) *conversationsService {
+	return &conversationsService{ctx}
+}
+
+// Start creates a conversation for the user and organization in ctx, if any
+func (cs *conversationsService) Start(ctx gctx.Context, channel string) (int64, error) {
+	lgr := cs.Lgr("Start")
+	row := model.Conversations{
+		Channel: channel,
+	}
+	if userID, ok := context.LookupUserId(ctx); ok {
+		row.UserID = &userID
+	}
+	if orgID, ok := context.LookupOrgID(ctx); ok {
+		row.OrganizationID = &orgID
+	}
+	if err := cs.DM().ConversationsDAO().Insert(&row, cs.DB()); err != nil {
+		lgr.Error("Failed to create conversation", zap.Error(err))
+		return 0, err
+	}
+	return row.ID, nil
+}
+
+func (cs *conversationsService) End(id int64) error {
+	lgr := cs.Lgr("End")
+	dao := cs.DM().ConversationsDAO()
+	row, err := dao.GetOne(id, cs.DB())
+	if err != nil {
+		lgr.Error("Failed to fetch conversation", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	now := time.Now()
+	row.EndedAt = &now
+	if err := dao.Update(row, id, cs.DB()); err != nil {
+		lgr.Error("Failed to end conversation", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Escalate records why the conversation was handed to a person and to whom
+func (cs *conversationsService) Escalate(id int64, reason string, transferredTo string) error {
+	lgr := cs.Lgr("Escalate")
+	dao := cs.DM().ConversationsDAO()
+	row, err := dao.GetOne(id, cs.DB())
+	if err != nil {
+		lgr.Error("Failed to fetch conversation", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	now := time.Now()
+	row.EscalationReason = &reason
+	row.TransferredTo = &transferredTo
+	row.EscalatedAt = &now
+	if err := dao.Update(row, id, cs.DB()); err != nil {
+		lgr.Error("Failed to escalate conversation", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -6,6 +6,13 @@ import (
 
 	"github.com/carsonkrueger/elevenlabs-go"
 	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+)
+
+const (
+	defaultElevenLabsVoice  = "Bill"
+	defaultElevenLabsModel  = "Eleven Flash v2.5"
+	defaultElevenLabsFormat = "pcm_16000"
 )
 
 type elevenLabsService struct {
@@ -72,12 +79,12 @@ func (el *elevenLabsService) GetDefaultVoiceSettings() (*elevenlabs.VoiceSetting
 
 func (el *elevenLabsService) TextToSpeech(msg string) ([]byte, error) {
 	var bytes []byte
-	voice, err := el.GetVoice("Bill")
+	voice, err := el.GetVoice(defaultElevenLabsVoice)
 	if err != nil {
 		return bytes, err
 	}
 
-	model, err := el.GetModel("Eleven Flash v2.5")
+	model, err := el.GetModel(defaultElevenLabsModel)
 	if err != nil {
 		return bytes, err
 	}
@@ -99,13 +106,26 @@ func (el *elevenLabsService) TextToSpeech(msg string) ([]byte, error) {
 	return bytes, nil
 }
 
+func (el *elevenLabsService) DefaultTTSRequest(msg string) models.TTSRequest {
+	return models.TTSRequest{
+		Text:   msg,
+		Voice:  defaultElevenLabsVoice,
+		Model:  defaultElevenLabsModel,
+		Format: defaultElevenLabsFormat,
+	}
+}
+
 func (el *elevenLabsService) TextToSpeechStream(msg string, w io.Writer) error {
-	voice, err := el.GetVoice("Bill")
+	return el.Synthesize(el.DefaultTTSRequest(msg), w)
+}
+
+func (el *elevenLabsService) Synthesize(ttsReq models.TTSRequest, w io.Writer) error {
+	voice, err := el.GetVoice(ttsReq.Voice)
 	if err != nil {
 		return err
 	}
 
-	model, err := el.GetModel("Eleven Flash v2.5")
+	model, err := el.GetModel(ttsReq.Model)
 	if err != nil {
 		return err
 	}
@@ -116,11 +136,11 @@ func (el *elevenLabsService) TextToSpeechStream(msg string, w io.Writer) error {
 	}
 
 	req := elevenlabs.TextToSpeechRequest{
-		Text:          msg,
+		Text:          ttsReq.Text,
 		ModelID:       model.ModelId,
 		VoiceSettings: settings,
 	}
-	err = el.client.TextToSpeechStream(w, voice.VoiceId, req, elevenlabs.OutputFormat("pcm_16000"))
+	err = el.client.TextToSpeechStream(w, voice.VoiceId, req, elevenlabs.OutputFormat(ttsReq.Format))
 	if err != nil {
 		return err
 	}
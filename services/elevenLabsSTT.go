@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	gctx "context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/carsonkrueger/elevenlabs-go"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/tools"
+)
+
+const elevenLabsSTTModel = "scribe_v1"
+
+type elevenLabsSTT struct {
+	context.ServiceContext
+	client *elevenlabs.Client
+}
+
+func NewElevenLabsSTT(ctx context.ServiceContext, client *elevenlabs.Client) *elevenLabsSTT {
+	return &elevenLabsSTT{
+		ServiceContext: ctx,
+		client:         client,
+	}
+}
+
+type elevenLabsSTTResponse struct {
+	Text  string `json:"text"`
+	Words []struct {
+		Text  string  `json:"text"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		// word, spacing or audio_event
+		Type string `json:"type"`
+	} `json:"words"`
+}
+
+func (el *elevenLabsSTT) Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Transcript{}, err
+	}
+	wav := tools.Int16ToWAV(pcm, 16000)
+	body, err := el.client.SpeechToText(elevenLabsSTTModel, bytes.NewReader(wav))
+	if err != nil {
+		return models.Transcript{}, err
+	}
+
+	var res elevenLabsSTTResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return models.Transcript{}, err
+	}
+
+	t := models.Transcript{
+		Text:  strings.TrimSpace(res.Text),
+		Final: true,
+		End:   tools.PCMDuration(len(pcm)*2, 16000, 1, 16),
+	}
+	for _, w := range res.Words {
+		if w.Type != "word" {
+			continue
+		}
+		t.Words = append(t.Words, models.TranscriptWord{
+			Text:  w.Text,
+			Start: secondsToDuration(w.Start),
+			End:   secondsToDuration(w.End),
+		})
+	}
+	if len(t.Words) > 0 {
+		t.Start = t.Words[0].Start
+		t.End = t.Words[len(t.Words)-1].End
+	}
+	return t, nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
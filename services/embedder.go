@@ -0,0 +1,118 @@
+package services
+
+import (
+	gctx "context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/openai/openai-go"
+)
+
+const (
+	EmbedderOpenAI = "openai"
+	EmbedderFake   = "fake"
+
+	fakeEmbeddingDims = 256
+)
+
+// NewEmbedder returns the embedder named by provider, falling back to OpenAI
+func NewEmbedder(ctx context.ServiceContext, provider string, model string, client *openai.Client) context.Embedder {
+	if provider == EmbedderFake {
+		return NewFakeEmbedder(fakeEmbeddingDims)
+	}
+	return NewOpenAIEmbedder(ctx, client, model)
+}
+
+type openAIEmbedder struct {
+	context.ServiceContext
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIEmbedder(ctx context.ServiceContext, client *openai.Client, model string) *openAIEmbedder {
+	if model == "" {
+		model = openai.EmbeddingModelTextEmbedding3Small
+	}
+	return &openAIEmbedder{
+		ServiceContext: ctx,
+		client:         client,
+		model:          model,
+	}
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.model
+}
+
+func (e *openAIEmbedder) Embed(ctx gctx.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	res, err := e.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: e.model,
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(res.Data))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range res.Data {
+		if d.Index < 0 || int(d.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		v := make([]float32, len(d.Embedding))
+		for i, f := range d.Embedding {
+			v[i] = float32(f)
+		}
+		vectors[d.Index] = v
+	}
+	return vectors, nil
+}
+
+// fakeEmbedder hashes words into a fixed number of buckets. It needs no network
+// and gives the same vector for the same text, so texts sharing words are similar.
+type fakeEmbedder struct {
+	dims int
+}
+
+func NewFakeEmbedder(dims int) *fakeEmbedder {
+	return &fakeEmbedder{dims: dims}
+}
+
+func (e *fakeEmbedder) Model() string {
+	return fmt.Sprintf("fake-hash-%d", e.dims)
+}
+
+func (e *fakeEmbedder) Embed(ctx gctx.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, e.dims)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, w := range words {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%uint32(e.dims)]++
+		}
+		var norm float64
+		for _, f := range v {
+			norm += float64(f * f)
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for j := range v {
+				v[j] = float32(float64(v[j]) / norm)
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	gctx "context"
+	"math"
+	"testing"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestNewEmbedder(t *testing.T) {
+	if _, ok := NewEmbedder(testContext{}, EmbedderFake, "", nil).(*fakeEmbedder); !ok {
+		t.Error("expected the fake embedder")
+	}
+	if _, ok := NewEmbedder(testContext{}, EmbedderOpenAI, "", nil).(*openAIEmbedder); !ok {
+		t.Error("expected the openai embedder")
+	}
+}
+
+func TestFakeEmbedder(t *testing.T) {
+	e := NewFakeEmbedder(fakeEmbeddingDims)
+	vectors, err := e.Embed(gctx.Background(), []string{
+		"How do I reset my password?",
+		"how do i RESET my password",
+		"Reset a forgotten password",
+		"Store opening hours on weekends",
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 5 {
+		t.Fatalf("got %d vectors, want 5", len(vectors))
+	}
+	for i, v := range vectors[:4] {
+		if len(v) != fakeEmbeddingDims {
+			t.Fatalf("vector %d has %d dims, want %d", i, len(v), fakeEmbeddingDims)
+		}
+		if norm := math.Sqrt(cosine(v, v)); math.Abs(norm-1) > 1e-5 {
+			t.Errorf("vector %d has norm %f, want 1", i, norm)
+		}
+	}
+
+	if sim := cosine(vectors[0], vectors[1]); math.Abs(sim-1) > 1e-5 {
+		t.Errorf("case and punctuation changed the vector, similarity %f", sim)
+	}
+	related, unrelated := cosine(vectors[0], vectors[2]), cosine(vectors[0], vectors[3])
+	if related <= unrelated {
+		t.Errorf("got related similarity %f <= unrelated %f", related, unrelated)
+	}
+	for i, f := range vectors[4] {
+		if f != 0 {
+			t.Fatalf("empty text has %f at %d, want a zero vector", f, i)
+		}
+	}
+}
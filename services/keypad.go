@@ -0,0 +1,160 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
+)
+
+const (
+	// digits pressed within this long of each other are sent to the agent together
+	keypadDigitTimeout = 2 * time.Second
+
+	KeypadSourceTelephony = "telephony"
+	KeypadSourceInBand    = "in-band"
+	KeypadSourceWeb       = "web"
+)
+
+// injectUserMessage adds text to the conversation as if the caller had said it.
+// The deepgram sdk has no type for it yet.
+type injectUserMessage struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// keypad collects the DTMF presses of a voice session, from the telephony
+// stream, tones in the caller audio and the web UI. A digit with a menu action
+// pressed on its own runs the action, other digits are sent to the agent once
+// the caller stops typing or presses #.
+type keypad struct {
+	context.ServiceContext
+	agent agentJSONWriter
+
+	mu       sync.Mutex
+	detector *tools.DTMFDetector
+	// samples of an incomplete frame
+	pending      []int16
+	frameSamples int
+	digits       strings.Builder
+	flushTimer   *time.Timer
+}
+
+func newKeypad(ctx context.ServiceContext, agent agentJSONWriter) *keypad {
+	cfg := tools.DefaultDTMFConfig()
+	return &keypad{
+		ServiceContext: ctx,
+		agent:          agent,
+		detector:       tools.NewDTMFDetector(cfg),
+		// 20ms frames, short enough for the 40ms minimum tone length
+		frameSamples: cfg.SampleRate / 50,
+	}
+}
+
+// Write looks for in-band keypad tones in 16 bit little endian caller audio
+func (k *keypad) Write(ctx gctx.Context, pcm []byte) {
+	var pressed []rune
+	k.mu.Lock()
+	for i := 0; i+1 < len(pcm); i += 2 {
+		k.pending = append(k.pending, int16(binary.LittleEndian.Uint16(pcm[i:])))
+	}
+	for len(k.pending) >= k.frameSamples {
+		if digit, ok := k.detector.Process(k.pending[:k.frameSamples]); ok {
+			pressed = append(pressed, digit)
+		}
+		k.pending = k.pending[k.frameSamples:]
+	}
+	k.pending = append([]int16(nil), k.pending...)
+	k.mu.Unlock()
+
+	for _, digit := range pressed {
+		k.Press(ctx, digit, KeypadSourceInBand)
+	}
+}
+
+// Press handles a single key press
+func (k *keypad) Press(ctx gctx.Context, digit rune, source string) {
+	lgr := k.Lgr("Press")
+	if !IsKeypadDigit(digit) {
+		lgr.Warn("ignoring invalid keypad digit", zap.String("digit", string(digit)), zap.String("source", source))
+		return
+	}
+	lgr.Info("keypad press", zap.String("digit", string(digit)), zap.String("source", source))
+
+	k.mu.Lock()
+	if k.digits.Len() == 0 {
+		if action, ok := k.SM().PhoneService().MenuAction(digit); ok {
+			k.mu.Unlock()
+			go k.runAction(ctx, digit, action)
+			return
+		}
+	}
+	k.digits.WriteRune(digit)
+	if k.flushTimer != nil {
+		k.flushTimer.Stop()
+	}
+	if digit == '#' {
+		k.mu.Unlock()
+		k.flush(ctx)
+		return
+	}
+	k.flushTimer = time.AfterFunc(keypadDigitTimeout, func() { k.flush(ctx) })
+	k.mu.Unlock()
+}
+
+// flush sends the digits pressed so far to the agent
+func (k *keypad) flush(ctx gctx.Context) {
+	k.mu.Lock()
+	digits := k.digits.String()
+	k.digits.Reset()
+	k.mu.Unlock()
+	if digits == "" || ctx.Err() != nil {
+		return
+	}
+	msg := injectUserMessage{
+		Type:    "InjectUserMessage",
+		Content: fmt.Sprintf("[keypad] The caller pressed: %s", digits),
+	}
+	if err := k.agent.WriteJSON(msg); err != nil {
+		k.Lgr("flush").Error("Failed to send keypad input to the agent", zap.Error(err))
+	}
+}
+
+func (k *keypad) runAction(ctx gctx.Context, digit rune, action models.KeypadAction) {
+	lgr := k.Lgr("runAction")
+	phone := k.SM().PhoneService()
+	switch action.Kind {
+	case models.KeypadTransfer:
+		// the web keypad's context has no speaker, the agent is the same either way
+		ctx = context.WithAgentSpeaker(ctx, agentSpeaker{k.agent})
+		_, err := phone.HandOff(ctx, action.Target, fmt.Sprintf("caller pressed %c", digit), defaultHandoffMessage)
+		if errors.Is(err, ErrNoActiveCall) {
+			lgr.Warn("no phone call to transfer")
+		} else if err != nil {
+			lgr.Error("Keypad transfer failed", zap.Error(err))
+		}
+	case models.KeypadHangUp:
+		if err := phone.EndCall(ctx); err != nil && !errors.Is(err, ErrNoActiveCall) {
+			lgr.Error("Failed to hang up", zap.Error(err))
+		}
+		context.GetCancel(ctx)()
+	}
+}
+
+// Stop drops digits that have not been sent yet
+func (k *keypad) Stop() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.flushTimer != nil {
+		k.flushTimer.Stop()
+	}
+	k.digits.Reset()
+}
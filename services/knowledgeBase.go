@@ -0,0 +1,208 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
+)
+
+const (
+	DocumentMarkdown = "markdown"
+	DocumentText     = "text"
+	DocumentHTML     = "html"
+
+	// texts sent to the embedder per request
+	embedBatchSize = 64
+)
+
+var (
+	ErrUnsupportedDocument = errors.New("unsupported document type, expected markdown, text or html")
+	ErrEmptyDocument       = errors.New("document has no text")
+)
+
+type knowledgeBaseService struct {
+	context.ServiceContext
+	embedder context.Embedder
+	chunking tools.ChunkOptions
+}
+
+func NewKnowledgeBaseService(ctx context.ServiceContext, embedder context.Embedder, chunking tools.ChunkOptions) *knowledgeBaseService {
+	return &knowledgeBaseService{
+		ServiceContext: ctx,
+		embedder:       embedder,
+		chunking:       chunking,
+	}
+}
+
+// DocumentType maps an uploaded file to markdown, text or html by its extension,
+// then its content type
+func DocumentType(filename string, contentType string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return DocumentMarkdown, nil
+	case ".txt":
+		return DocumentText, nil
+	case ".html", ".htm":
+		return DocumentHTML, nil
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/markdown"):
+		return DocumentMarkdown, nil
+	case strings.HasPrefix(contentType, "text/html"):
+		return DocumentHTML, nil
+	case strings.HasPrefix(contentType, "text/plain"):
+		return DocumentText, nil
+	}
+	return "", ErrUnsupportedDocument
+}
+
+// AddDocument chunks and embeds a document into the knowledge base of a profile
+// in the organization in ctx
+func (ks *knowledgeBaseService) AddDocument(ctx gctx.Context, profileID int64, title string, docType string, body []byte) (*model.KnowledgeDocuments, int, error) {
+	lgr := ks.Lgr("AddDocument")
+	orgID, ok := context.LookupOrgID(ctx)
+	if !ok {
+		return nil, 0, ErrNoOrganization
+	}
+	text := string(body)
+	switch docType {
+	case DocumentMarkdown, DocumentText:
+	case DocumentHTML:
+		htmlTitle, readable, err := tools.ExtractReadableText(text)
+		if err != nil {
+			return nil, 0, err
+		}
+		if title == "" {
+			title = htmlTitle
+		}
+		// each extracted line is a block element, chunk them as paragraphs
+		text = strings.ReplaceAll(readable, "\n", "\n\n")
+	default:
+		return nil, 0, ErrUnsupportedDocument
+	}
+	chunks := tools.ChunkText(text, ks.chunking)
+	if len(chunks) == 0 {
+		return nil, 0, ErrEmptyDocument
+	}
+	if title == "" {
+		title = "Untitled"
+	}
+
+	vectors := make([][]float32, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		batch, err := ks.embedder.Embed(ctx, chunks[start:min(start+embedBatchSize, len(chunks))])
+		if err != nil {
+			lgr.Error("Failed to embed chunks", zap.Error(err))
+			return nil, 0, err
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	tx, err := ks.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	doc := model.KnowledgeDocuments{
+		OrganizationID: orgID,
+		ProfileID:      profileID,
+		Title:          title,
+		ContentType:    docType,
+	}
+	if err := ks.DM().KnowledgeDocumentsDAO().Insert(&doc, tx); err != nil {
+		lgr.Error("Failed to insert document", zap.Error(err))
+		return nil, 0, err
+	}
+	rows := make([]*model.KnowledgeChunks, len(chunks))
+	for i, chunk := range chunks {
+		rows[i] = &model.KnowledgeChunks{
+			DocumentID:     doc.ID,
+			ProfileID:      profileID,
+			ChunkIndex:     int32(i),
+			Content:        chunk,
+			EmbeddingModel: ks.embedder.Model(),
+			Embedding:      formatVector(vectors[i]),
+		}
+	}
+	if err := ks.DM().KnowledgeChunksDAO().InsertMany(&rows, tx); err != nil {
+		lgr.Error("Failed to insert chunks", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	return &doc, len(chunks), nil
+}
+
+// Search returns the chunks of the profile's documents most similar to query,
+// within the organization in ctx
+func (ks *knowledgeBaseService) Search(ctx gctx.Context, profileID int64, query string, limit int) ([]agentModels.KnowledgeSearchResult, error) {
+	orgID, ok := context.LookupOrgID(ctx)
+	if !ok {
+		return nil, ErrNoOrganization
+	}
+	vectors, err := ks.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	return ks.DM().KnowledgeChunksDAO().Search(orgID, profileID, ks.embedder.Model(), formatVector(vectors[0]), int64(limit))
+}
+
+func (ks *knowledgeBaseService) DocumentsAsRowData(docs []*model.KnowledgeDocuments) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(docs))
+	for i, d := range docs {
+		ca := "No Created At"
+		if d.CreatedAt != nil {
+			ca = d.CreatedAt.Format("2006-01-02")
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ti-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(d.Title, datadisplay.SM),
+				},
+				{
+					ID:    "ct-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(d.ContentType, datadisplay.SM),
+				},
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(ca, datadisplay.SM),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+// formatVector writes a postgres REAL[] literal
+func formatVector(v []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	gctx "context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/models"
+	"go.uber.org/zap"
+)
+
+const (
+	MailerSMTP = "smtp"
+	MailerFile = "file"
+	MailerLog  = "log"
+)
+
+// formatMail renders mail as a message with the headers SMTP servers expect
+func formatMail(from string, mail models.Mail) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
+
+// headerSafe rejects values that would add headers to a message
+func headerSafe(mail models.Mail) error {
+	if strings.ContainsAny(mail.To, "\r\n") || strings.ContainsAny(mail.Subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+	return nil
+}
+
+type smtpMailer struct {
+	cfg cfg.MailConfig
+}
+
+func NewSMTPMailer(cfg cfg.MailConfig) *smtpMailer {
+	return &smtpMailer{cfg: cfg}
+}
+
+func (m *smtpMailer) Send(ctx gctx.Context, mail models.Mail) error {
+	if err := headerSafe(mail); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+	}
+	addr := m.cfg.SMTPHost + ":" + m.cfg.SMTPPort
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{mail.To}, formatMail(m.cfg.From, mail))
+}
+
+// fileMailer writes each message to its own file in dir, for local development
+// and tests that read the links back out
+type fileMailer struct {
+	from string
+	dir  string
+	mu   sync.Mutex
+	seq  int
+}
+
+func NewFileMailer(from string, dir string) *fileMailer {
+	return &fileMailer{from: from, dir: dir}
+}
+
+func (m *fileMailer) Send(ctx gctx.Context, mail models.Mail) error {
+	if err := headerSafe(mail); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%d-%03d.eml", time.Now().UnixNano(), m.seq)
+	m.mu.Unlock()
+	return os.WriteFile(filepath.Join(m.dir, name), formatMail(m.from, mail), 0o600)
+}
+
+// logMailer writes messages to the log instead of sending them
+type logMailer struct {
+	lgr *zap.Logger
+}
+
+func NewLogMailer(lgr *zap.Logger) *logMailer {
+	return &logMailer{lgr: lgr}
+}
+
+func (m *logMailer) Send(ctx gctx.Context, mail models.Mail) error {
+	if err := headerSafe(mail); err != nil {
+		return err
+	}
+	m.lgr.Info("Mail", zap.String("to", mail.To), zap.String("subject", mail.Subject), zap.String("body", mail.Body))
+	return nil
+}
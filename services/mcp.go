@@ -2,37 +2,113 @@ package services
 
 import (
 	gctx "context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/carsonkrueger/main/cfg"
 	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/tools"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
 )
 
+const (
+	defaultExcerptChars = 2000
+	maxExcerptChars     = 8000
+
+	defaultKnowledgeResults = 4
+	maxKnowledgeResults     = 10
+
+	defaultHandoffMessage = "Please hold while I transfer you to a member of our team."
+)
+
+// prefix of the privilege each tool requires, e.g. Tool:web_search
+const ToolPrivilegePrefix = "Tool:"
+
 type appMCP struct {
 	context.ServiceContext
-	server *server.MCPServer
-	client *client.Client
+	server    *server.MCPServer
+	client    *client.Client
+	upstreams []*mcpUpstream
+	fetcher   *tools.Fetcher
+	// tool name to the id of the privilege needed to list and call it
+	toolPrivilegesMu sync.RWMutex
+	toolPrivileges   map[string]int64
+	// tools the caller must approve, and how long they are given to answer
+	confirmToolsMu sync.RWMutex
+	confirmTools   map[string]bool
+	confirmTimeout time.Duration
+}
+
+func NewMcpService(ctx context.ServiceContext, upstreams []cfg.MCPServerConfig, confirmTimeout time.Duration) *appMCP {
+	m := newAppMCP(ctx, confirmTimeout)
+
+	logTool := mcp.NewTool("console_log", mcp.WithNumber("number", mcp.Min(0), mcp.Max(64), mcp.Required()), mcp.WithDescription("Use if the user gives you number to log"))
+	webSearchTool := mcp.NewTool("web_search",
+		mcp.WithString("url", mcp.Required()),
+		mcp.WithNumber("max_chars", mcp.Min(100), mcp.Max(maxExcerptChars), mcp.Description("Max characters of page text to return")),
+		mcp.WithDescription("Use if the user gives you a url to answer questions about. Returns the page title and an excerpt of its text"),
+	)
+	knowledgeTool := mcp.NewTool("search_knowledge_base",
+		mcp.WithString("query", mcp.Required(), mcp.Description("What to look up, phrased as a question or keywords")),
+		mcp.WithNumber("limit", mcp.Min(1), mcp.Max(maxKnowledgeResults), mcp.Description("Max passages to return")),
+		mcp.WithDescription("Search the documents uploaded for this agent. Use it to answer questions about products, policies and procedures instead of guessing"),
+	)
+	transferTool := mcp.NewTool("transfer_call",
+		mcp.WithString("reason", mcp.Required(), mcp.Description("Why the caller needs a person, in one sentence")),
+		mcp.WithString("target", mcp.Description("Team or queue to transfer to, leave empty for the default")),
+		mcp.WithString("message", mcp.Description("What to tell the caller before the transfer")),
+		mcp.WithDescription("Transfer the phone call to a human. Use when the caller asks for a person or you cannot help them"),
+	)
+	m.AddTools(
+		server.ServerTool{Tool: logTool, Handler: m.loggingTool},
+		server.ServerTool{Tool: webSearchTool, Handler: m.webSearchTool},
+		server.ServerTool{Tool: knowledgeTool, Handler: m.searchKnowledgeBaseTool},
+		server.ServerTool{Tool: transferTool, Handler: m.transferCallTool},
+	)
+
+	for _, u := range upstreams {
+		upstream := newMCPUpstream(ctx, u, m)
+		m.upstreams = append(m.upstreams, upstream)
+		go upstream.Run(gctx.Background())
+	}
+	return m
 }
 
-func NewMcpService(ctx context.ServiceContext) *appMCP {
-	s := server.NewMCPServer("llm-agent", "1.0")
+// newAppMCP sets up the server and its middleware without any tools
+func newAppMCP(ctx context.ServiceContext, confirmTimeout time.Duration) *appMCP {
+	m := &appMCP{
+		ServiceContext: ctx,
+		toolPrivileges: make(map[string]int64),
+		confirmTools:   make(map[string]bool),
+		confirmTimeout: confirmTimeout,
+		fetcher:        tools.NewFetcher(tools.DefaultFetchOptions()),
+	}
+	s := server.NewMCPServer("llm-agent", "1.0",
+		server.WithToolFilter(m.filterTools),
+		// audit first so rejected calls are recorded too
+		server.WithToolHandlerMiddleware(m.auditTool),
+		server.WithToolHandlerMiddleware(m.authorizeTool),
+		server.WithToolHandlerMiddleware(m.confirmTool),
+	)
 	client, err := client.NewInProcessClient(s)
 	if err != nil {
 		panic(err)
 	}
-	m := &appMCP{
-		ServiceContext: ctx,
-		server:         s,
-		client:         client,
+	// the client refuses to list tools until it has shaken hands
+	if _, err := client.Initialize(gctx.Background(), mcp.InitializeRequest{}); err != nil {
+		panic(err)
 	}
-	logTool := mcp.NewTool("console_log", mcp.WithNumber("number", mcp.Min(0), mcp.Max(64), mcp.Required()), mcp.WithDescription("Use if the user gives you number to log"))
-	webSearchTool := mcp.NewTool("web_search", mcp.WithString("url", mcp.Required()), mcp.WithDescription("Use if the user gives you a url to answer questions about"))
-	s.AddTool(logTool, m.loggingTool)
-	s.AddTool(webSearchTool, m.webSearchTool)
+	m.server = s
+	m.client = client
 	return m
 }
 
@@ -47,43 +123,241 @@ func (s *appMCP) loggingTool(ctx gctx.Context, request mcp.CallToolRequest) (*mc
 }
 
 func (s *appMCP) webSearchTool(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	lgr := s.Lgr("webSearchTool")
 	url := request.GetString("url", "")
-	fmt.Println("searching url:", url)
 	if url == "" {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Please provide a valid URL"),
-			},
-			IsError: true,
-		}, nil
-	}
-	// make request to url
-	res, err := http.Get(url)
+		return mcp.NewToolResultError("Please provide a valid URL"), nil
+	}
+	maxChars := tools.Clamp(request.GetInt("max_chars", defaultExcerptChars), 100, maxExcerptChars)
+
+	page, err := s.fetcher.Fetch(ctx, url)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Could not fetch URL"),
-			},
-			IsError: true,
-		}, nil
-	}
-	defer res.Body.Close()
-	bodyBytes, err := io.ReadAll(io.LimitReader(res.Body, 1_000)) // 1K byte limit for safety
+		lgr.Warn("fetch failed", zap.String("url", url), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not fetch URL: %v", err)), nil
+	}
+
+	var sb strings.Builder
+	if page.Title != "" {
+		sb.WriteString("Title: " + page.Title + "\n")
+	}
+	sb.WriteString("URL: " + page.URL + "\n\n")
+	sb.WriteString(page.Excerpt(maxChars))
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (s *appMCP) searchKnowledgeBaseTool(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	lgr := s.Lgr("searchKnowledgeBaseTool")
+	query := strings.TrimSpace(request.GetString("query", ""))
+	if query == "" {
+		return mcp.NewToolResultError("Please provide a query"), nil
+	}
+	profileID, ok := context.LookupAgentProfileID(ctx)
+	if !ok {
+		return mcp.NewToolResultError("This conversation has no knowledge base"), nil
+	}
+	limit := tools.Clamp(request.GetInt("limit", defaultKnowledgeResults), 1, maxKnowledgeResults)
+
+	results, err := s.SM().KnowledgeBaseService().Search(ctx, profileID, query, limit)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Error reading response body"),
-			},
-			IsError: true,
-		}, nil
+		lgr.Error("knowledge base search failed", zap.Int64("profile", profileID), zap.Error(err))
+		return mcp.NewToolResultError("The knowledge base could not be searched"), nil
+	}
+	if len(results) == 0 {
+		return mcp.NewToolResultText("No matching documents were found."), nil
 	}
-	bodyText := string(bodyBytes)
+	var sb strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteString("\n\n---\n\n")
+		}
+		fmt.Fprintf(&sb, "Source: %s (part %d)\n%s", r.Title, r.ChunkIndex+1, r.Content)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.NewTextContent(bodyText),
-		},
-	}, nil
+func (s *appMCP) transferCallTool(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	reason := strings.TrimSpace(request.GetString("reason", ""))
+	if reason == "" {
+		return mcp.NewToolResultError("Please provide the reason for the transfer"), nil
+	}
+	target := strings.TrimSpace(request.GetString("target", ""))
+	message := request.GetString("message", defaultHandoffMessage)
+
+	phone := s.SM().PhoneService()
+	transfer, err := phone.HandOff(ctx, target, reason, message)
+	if errors.Is(err, ErrNoActiveCall) {
+		return mcp.NewToolResultError("There is no phone call to transfer"), nil
+	} else if errors.Is(err, ErrUnknownTransferTarget) {
+		return mcp.NewToolResultError(fmt.Sprintf("Unknown target, choose one of: %s", strings.Join(phone.TransferTargets(), ", "))), nil
+	} else if err != nil {
+		return mcp.NewToolResultError("The call could not be transferred"), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("The call was transferred to %s.", transfer.Target)), nil
+}
+
+// AddTools registers tools along with the privilege each one requires. Privileges
+// are upserted the same way private routes upsert theirs.
+func (s *appMCP) AddTools(tools ...server.ServerTool) {
+	lgr := s.Lgr("AddTools")
+	privDAO := s.DM().PrivilegeDAO()
+	added := false
+	for _, t := range tools {
+		priv := model.Privileges{Name: ToolPrivilegePrefix + t.Tool.Name}
+		if err := privDAO.Upsert(&priv, s.DB()); err != nil {
+			// without a privilege nobody can use the tool
+			lgr.Error("failed to upsert tool privilege", zap.String("tool", t.Tool.Name), zap.Error(err))
+			continue
+		}
+		s.toolPrivilegesMu.Lock()
+		if s.toolPrivileges[t.Tool.Name] != priv.ID {
+			added = true
+		}
+		s.toolPrivileges[t.Tool.Name] = priv.ID
+		s.toolPrivilegesMu.Unlock()
+	}
+	if added {
+		// levels granted the privileges by a pattern, like Tool:*, only get them
+		// once the cached matrix is rebuilt
+		s.SM().PrivilegesService().InvalidateCache()
+	}
+	s.server.AddTools(tools...)
+}
+
+func (s *appMCP) DeleteTools(names ...string) {
+	s.server.DeleteTools(names...)
+	s.toolPrivilegesMu.Lock()
+	for _, name := range names {
+		delete(s.toolPrivileges, name)
+	}
+	s.toolPrivilegesMu.Unlock()
+}
+
+// CanUseTool reports whether the session in ctx has the tool's privilege
+func (s *appMCP) CanUseTool(ctx gctx.Context, name string) bool {
+	levelID, ok := context.LookupPrivilegeLevelID(ctx)
+	if !ok {
+		return false
+	}
+	s.toolPrivilegesMu.RLock()
+	privID, ok := s.toolPrivileges[name]
+	s.toolPrivilegesMu.RUnlock()
+	if !ok {
+		return false
+	}
+	return s.SM().PrivilegesService().HasPermissionByID(levelID, privID)
+}
+
+func (s *appMCP) filterTools(ctx gctx.Context, tools []mcp.Tool) []mcp.Tool {
+	permitted := make([]mcp.Tool, 0, len(tools))
+	for _, t := range tools {
+		if s.CanUseTool(ctx, t.Name) {
+			permitted = append(permitted, t)
+		}
+	}
+	return permitted
+}
+
+func (s *appMCP) authorizeTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !s.CanUseTool(ctx, request.Params.Name) {
+			s.Lgr("authorizeTool").Warn("tool call rejected", zap.String("tool", request.Params.Name))
+			return mcp.NewToolResultError("You do not have permission to use this tool"), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// RequireConfirmation flags tools that only run once the caller approves the call
+func (s *appMCP) RequireConfirmation(names ...string) {
+	s.confirmToolsMu.Lock()
+	defer s.confirmToolsMu.Unlock()
+	for _, name := range names {
+		s.confirmTools[name] = true
+	}
+}
+
+func (s *appMCP) RequiresConfirmation(name string) bool {
+	s.confirmToolsMu.RLock()
+	defer s.confirmToolsMu.RUnlock()
+	return s.confirmTools[name]
+}
+
+// confirmTool asks the conversation's ToolConfirmer before running flagged tools.
+// Without a confirmer, or without approval in time, the call is declined.
+func (s *appMCP) confirmTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.Params.Name
+		if !s.RequiresConfirmation(name) {
+			return next(ctx, request)
+		}
+		lgr := s.Lgr("confirmTool")
+		declined := mcp.NewToolResultError("The user did not approve this action, so it was not performed. Do not retry unless the user asks again.")
+
+		confirmer, ok := context.LookupToolConfirmer(ctx)
+		if !ok {
+			lgr.Warn("no confirmer for tool requiring confirmation", zap.String("tool", name))
+			return declined, nil
+		}
+		id, err := tools.GenerateToken(8)
+		if err != nil {
+			lgr.Error("failed to generate confirmation id", zap.Error(err))
+			return declined, nil
+		}
+		confirmCtx, cancel := gctx.WithTimeout(ctx, s.confirmTimeout)
+		defer cancel()
+		req := agentModels.ToolConfirmationRequest{
+			ID:        id,
+			ToolName:  name,
+			Arguments: s.SM().ToolCallsService().Redact(request.GetArguments()),
+			Prompt:    fmt.Sprintf("Before I %s, can you confirm you want me to go ahead? Please say yes or no.", strings.ReplaceAll(name, "_", " ")),
+		}
+		approved, err := confirmer.ConfirmTool(confirmCtx, req)
+		if err != nil {
+			lgr.Warn("tool confirmation failed", zap.String("tool", name), zap.Error(err))
+		}
+		if !approved {
+			lgr.Info("tool call declined", zap.String("tool", name))
+			return declined, nil
+		}
+		return next(ctx, request)
+	}
+}
+
+func (s *appMCP) auditTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+		call := agentModels.ToolCall{
+			ToolName:  request.Params.Name,
+			Arguments: request.GetArguments(),
+			Duration:  time.Since(start),
+			Error:     err,
+		}
+		if result != nil {
+			if bytes, mErr := json.Marshal(result.Content); mErr == nil {
+				call.ResultSize = len(bytes)
+			}
+			if err == nil && result.IsError {
+				call.Error = errors.New(toolResultText(result))
+			}
+		}
+		s.SM().ToolCallsService().Record(ctx, call)
+		return result, err
+	}
+}
+
+// toolResultText joins the text content of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := mcp.AsTextContent(c); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return "tool returned an error"
+	}
+	return strings.Join(parts, "\n")
 }
 
 func (s *appMCP) Server() *server.MCPServer {
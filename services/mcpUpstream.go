@@ -0,0 +1,213 @@
+package services
+
+import (
+	gctx "context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// separates the upstream server name from its tool name in proxied tools
+const mcpNamespaceSep = "__"
+
+const (
+	mcpMinReconnect = time.Second
+	mcpMaxReconnect = time.Minute
+)
+
+var unsafeToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// mcpUpstream keeps a connection to an external MCP server and mirrors its tools
+// onto the app's MCP server, each gated by its own privilege. The connection is pinged on an interval and its tools
+// are removed while it is down and re-registered once it reconnects.
+type mcpUpstream struct {
+	context.ServiceContext
+	cfg       cfg.MCPServerConfig
+	namespace string
+	app       *appMCP
+
+	mu     sync.RWMutex
+	client *client.Client
+	tools  []string
+}
+
+func newMCPUpstream(ctx context.ServiceContext, config cfg.MCPServerConfig, app *appMCP) *mcpUpstream {
+	return &mcpUpstream{
+		ServiceContext: ctx,
+		cfg:            config,
+		namespace:      unsafeToolNameChars.ReplaceAllString(config.Name, "_"),
+		app:            app,
+	}
+}
+
+// Run connects and supervises the upstream until ctx is done
+func (u *mcpUpstream) Run(ctx gctx.Context) {
+	lgr := u.Lgr("mcpUpstream").With(zap.String("server", u.cfg.Name))
+	backoff := mcpMinReconnect
+	for {
+		if err := u.connect(ctx); err != nil {
+			lgr.Warn("failed to connect", zap.Error(err), zap.Duration("retry_in", backoff))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, mcpMaxReconnect)
+			continue
+		}
+		backoff = mcpMinReconnect
+		lgr.Info("connected", zap.Strings("tools", u.toolNames()))
+
+		u.watch(ctx)
+		u.disconnect()
+		if ctx.Err() != nil {
+			return
+		}
+		lgr.Warn("connection lost, reconnecting")
+	}
+}
+
+// watch blocks until a health check fails or ctx is done
+func (u *mcpUpstream) watch(ctx gctx.Context) {
+	ticker := time.NewTicker(u.cfg.HealthInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := gctx.WithTimeout(ctx, u.cfg.CallTimeout())
+			err := u.currentClient().Ping(pingCtx)
+			cancel()
+			if err != nil {
+				u.Lgr("mcpUpstream").Warn("health check failed", zap.String("server", u.cfg.Name), zap.Error(err))
+				return
+			}
+		}
+	}
+}
+
+func (u *mcpUpstream) connect(ctx gctx.Context) error {
+	c, err := u.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	initCtx, cancel := gctx.WithTimeout(ctx, u.cfg.CallTimeout())
+	defer cancel()
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "llm-agent", Version: "1.0"}
+	if _, err := c.Initialize(initCtx, initReq); err != nil {
+		c.Close()
+		return fmt.Errorf("initialize: %w", err)
+	}
+	listed, err := c.ListTools(initCtx, mcp.ListToolsRequest{})
+	if err != nil {
+		c.Close()
+		return fmt.Errorf("list tools: %w", err)
+	}
+
+	proxied := make([]server.ServerTool, 0, len(listed.Tools))
+	names := make([]string, 0, len(listed.Tools))
+	for _, tool := range listed.Tools {
+		original := tool.Name
+		tool.Name = u.namespace + mcpNamespaceSep + original
+		proxied = append(proxied, server.ServerTool{Tool: tool, Handler: u.proxy(original)})
+		names = append(names, tool.Name)
+		if slices.Contains(u.cfg.ConfirmTools, original) {
+			u.app.RequireConfirmation(tool.Name)
+		}
+	}
+
+	u.mu.Lock()
+	u.client = c
+	u.tools = names
+	u.mu.Unlock()
+	u.app.AddTools(proxied...)
+	return nil
+}
+
+func (u *mcpUpstream) newClient(ctx gctx.Context) (*client.Client, error) {
+	switch u.cfg.Transport {
+	case cfg.MCPTransportStdio:
+		// stdio clients start their subprocess when created
+		return client.NewStdioMCPClient(u.cfg.Command, u.cfg.Env, u.cfg.Args...)
+	case cfg.MCPTransportSSE:
+		c, err := client.NewSSEMCPClient(u.cfg.URL, transport.WithHeaders(u.cfg.Headers))
+		if err != nil {
+			return nil, err
+		}
+		return startClient(ctx, c)
+	case cfg.MCPTransportHTTP:
+		c, err := client.NewStreamableHttpClient(u.cfg.URL,
+			transport.WithHTTPHeaders(u.cfg.Headers),
+			transport.WithHTTPTimeout(u.cfg.CallTimeout()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return startClient(ctx, c)
+	default:
+		return nil, fmt.Errorf("unknown transport %q", u.cfg.Transport)
+	}
+}
+
+// startClient starts c and closes it again when it couldn't start, so a failed
+// connect leaves nothing running
+func startClient(ctx gctx.Context, c *client.Client) (*client.Client, error) {
+	if err := c.Start(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (u *mcpUpstream) disconnect() {
+	u.mu.Lock()
+	c, tools := u.client, u.tools
+	u.client, u.tools = nil, nil
+	u.mu.Unlock()
+
+	if len(tools) > 0 {
+		u.app.DeleteTools(tools...)
+	}
+	if c != nil {
+		c.Close()
+	}
+}
+
+func (u *mcpUpstream) proxy(name string) server.ToolHandlerFunc {
+	return func(ctx gctx.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		c := u.currentClient()
+		if c == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is currently unavailable", u.cfg.Name)), nil
+		}
+		ctx, cancel := gctx.WithTimeout(ctx, u.cfg.CallTimeout())
+		defer cancel()
+		req.Params.Name = name
+		return c.CallTool(ctx, req)
+	}
+}
+
+func (u *mcpUpstream) currentClient() *client.Client {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.client
+}
+
+func (u *mcpUpstream) toolNames() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.tools
+}
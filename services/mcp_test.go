@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fakePrivilegeDAO gives each privilege name an id the first time it's upserted
+type fakePrivilegeDAO struct {
+	DAO.PrivilegeDAO
+	ids map[string]int64
+}
+
+func (d fakePrivilegeDAO) Upsert(row *model.Privileges, db qrm.Queryable) error {
+	id, ok := d.ids[row.Name]
+	if !ok {
+		id = int64(1000 + len(d.ids))
+		d.ids[row.Name] = id
+	}
+	row.ID = id
+	return nil
+}
+
+type fakePrivilegeDAOs struct {
+	DAO.DAOManager
+	privileges fakePrivilegeDAO
+}
+
+func (dm fakePrivilegeDAOs) PrivilegeDAO() DAO.PrivilegeDAO {
+	return dm.privileges
+}
+
+type countedInvalidations struct {
+	context.PrivilegesService
+	count int
+}
+
+func (p *countedInvalidations) InvalidateCache() {
+	p.count++
+}
+
+func TestAddToolsInvalidatesPrivileges(t *testing.T) {
+	privileges := &countedInvalidations{}
+	dm := fakePrivilegeDAOs{privileges: fakePrivilegeDAO{ids: make(map[string]int64)}}
+	m := newAppMCP(testContext{sm: fakeServiceManager{privileges: privileges}, dm: dm}, time.Second)
+	tool := func(name string) server.ServerTool {
+		return server.ServerTool{Tool: mcp.NewTool(name)}
+	}
+
+	m.AddTools(tool("upstream_search"), tool("upstream_fetch"))
+	if privileges.count != 1 {
+		t.Fatalf("got %d invalidations for new tools, want 1", privileges.count)
+	}
+	if _, ok := dm.privileges.ids[ToolPrivilegePrefix+"upstream_search"]; !ok {
+		t.Errorf("got privileges %v, want one for upstream_search", dm.privileges.ids)
+	}
+
+	// an upstream reconnecting with the same tools changes nothing
+	m.AddTools(tool("upstream_search"), tool("upstream_fetch"))
+	if privileges.count != 1 {
+		t.Errorf("got %d invalidations for known tools, want 1", privileges.count)
+	}
+
+	m.AddTools(tool("upstream_search"), tool("upstream_translate"))
+	if privileges.count != 2 {
+		t.Errorf("got %d invalidations after a new tool, want 2", privileges.count)
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	gctx "context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/carsonkrueger/main/tools/oidc"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+const (
+	// how long a user has to log in at the provider
+	oidcLoginTTL = 10 * time.Minute
+	// logins that may be waiting on the provider at once, across replicas
+	maxPendingOIDCLogins = 1000
+)
+
+var (
+	ErrOIDCDisabled   = errors.New("single sign-on is not configured")
+	ErrOIDCState      = errors.New("single sign-on login expired, please try again")
+	ErrOIDCEmail      = errors.New("the identity provider did not share a verified email")
+	ErrOIDCNoUserName = errors.New("the identity provider did not share a name")
+	ErrOIDCBusy       = errors.New("too many single sign-on logins in progress, please try again shortly")
+)
+
+type oidcService struct {
+	context.ServiceContext
+	cfg    cfg.OIDCConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService(ctx context.ServiceContext, cfg cfg.OIDCConfig, client *http.Client) *oidcService {
+	return &oidcService{
+		ServiceContext: ctx,
+		cfg:            cfg,
+		client:         client,
+	}
+}
+
+func (oid *oidcService) Enabled() bool {
+	return oid.cfg.IssuerURL != "" && oid.cfg.ClientID != ""
+}
+
+// Begin starts a login, returning where to send the user and the state to keep
+// in their browser for Finish. The login is saved so any replica can finish it,
+// and refused with ErrOIDCBusy while too many are pending.
+func (oid *oidcService) Begin(ctx gctx.Context) (string, string, error) {
+	provider, err := oid.getProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	code, err := oidc.NewAuthCode()
+	if err != nil {
+		return "", "", err
+	}
+	dao := oid.DM().OIDCLoginsDAO()
+	now := time.Now()
+	if err := dao.DeleteExpired(now); err != nil {
+		return "", "", err
+	}
+	pending, err := dao.CountPending(now)
+	if err != nil {
+		return "", "", err
+	}
+	if pending >= maxPendingOIDCLogins {
+		oid.Lgr("Begin").Warn("Refusing login, too many pending", zap.Int64("pending", pending))
+		return "", "", ErrOIDCBusy
+	}
+	login := model.OidcLogins{
+		State:     code.State,
+		Nonce:     code.Nonce,
+		Verifier:  code.Verifier,
+		ExpiresAt: now.Add(oidcLoginTTL),
+	}
+	if err := dao.Insert(&login, oid.DB()); err != nil {
+		return "", "", err
+	}
+	return provider.AuthCodeURL(code), code.State, nil
+}
+
+// Finish completes the login the provider redirected back from. browserState is
+// the state Begin gave the browser, it must match the state of the callback.
+func (oid *oidcService) Finish(ctx gctx.Context, state string, browserState string, code string) (*model.Users, error) {
+	lgr := oid.Lgr("Finish")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrOIDCState
+	}
+	login, err := oid.DM().OIDCLoginsDAO().Take(state, time.Now())
+	if errors.Is(err, qrm.ErrNoRows) {
+		return nil, ErrOIDCState
+	} else if err != nil {
+		return nil, err
+	}
+	provider, err := oid.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.Exchange(ctx, code, oidc.AuthCode{State: login.State, Nonce: login.Nonce, Verifier: login.Verifier})
+	if err != nil {
+		lgr.Warn("Failed to redeem login", zap.Error(err))
+		return nil, err
+	}
+	return oid.provision(ctx, claims)
+}
+
+// getProvider discovers the provider the first time it's needed, trying again
+// on the next login if it's unreachable
+func (oid *oidcService) getProvider(ctx gctx.Context) (*oidc.Provider, error) {
+	if !oid.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+	oid.mu.Lock()
+	defer oid.mu.Unlock()
+	if oid.provider != nil {
+		return oid.provider, nil
+	}
+	provider, err := oidc.Discover(ctx, oid.client, oid.cfg.IssuerURL, oidc.Config{
+		ClientID:     oid.cfg.ClientID,
+		ClientSecret: oid.cfg.ClientSecret,
+		RedirectURL:  oid.cfg.RedirectURL,
+		Scopes:       oid.cfg.Scopes,
+		GroupsClaim:  oid.cfg.GroupsClaim,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", oid.cfg.IssuerURL, err)
+	}
+	oid.provider = provider
+	return provider, nil
+}
+
+// provision finds or creates the user the claims are for. A known identity signs
+// in as its user, otherwise the verified email is linked to its account or a new
+// one. The privilege level follows the user's groups on every login.
+func (oid *oidcService) provision(ctx gctx.Context, claims *oidc.Claims) (*model.Users, error) {
+	lgr := oid.Lgr("provision")
+	usersDAO := oid.DM().UsersDAO()
+	identitiesDAO := oid.DM().UserIdentitiesDAO()
+	levelID, mapped, err := oid.levelFor(claims.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	key := authModels.UserIdentitiesPrimaryKey{Issuer: claims.Issuer, Subject: claims.Subject}
+	identity, err := identitiesDAO.GetOne(key, oid.DB())
+	var user *model.Users
+	switch {
+	case err == nil:
+		user, err = usersDAO.GetOne(identity.UserID, oid.DB())
+		if err != nil {
+			return nil, err
+		}
+	case errors.Is(err, qrm.ErrNoRows):
+		// only an email the provider vouches for may claim an account
+		if claims.Email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+			return nil, ErrOIDCEmail
+		}
+		user, err = usersDAO.GetByEmail(claims.Email)
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oid.createUser(claims, levelID)
+		} else if err != nil {
+			return nil, err
+		}
+		lgr.Info("Linking identity to existing user", zap.Int64("user id", user.ID), zap.String("issuer", claims.Issuer))
+		if err := identitiesDAO.Insert(&model.UserIdentities{Issuer: claims.Issuer, Subject: claims.Subject, UserID: user.ID}, oid.DB()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if mapped && user.PrivilegeLevelID != levelID {
+		if err := oid.SM().PrivilegesService().SetUserPrivilegeLevel(ctx, levelID, user.ID); err != nil {
+			return nil, err
+		}
+		user.PrivilegeLevelID = levelID
+	}
+	return user, nil
+}
+
+// createUser adds a user for the claims with their own organization, like signing up
+func (oid *oidcService) createUser(claims *oidc.Claims, levelID int64) (*model.Users, error) {
+	lgr := oid.Lgr("createUser")
+	first, last := claims.GivenName, claims.FamilyName
+	if first == "" {
+		first, last, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if first == "" {
+		first, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if first == "" {
+		return nil, ErrOIDCNoUserName
+	}
+	// nobody knows this password, a password login needs a reset first
+	password, err := tools.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := tools.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user := model.Users{
+		Email:            claims.Email,
+		Password:         hash,
+		FirstName:        truncate(first, 64),
+		LastName:         truncate(last, 64),
+		PrivilegeLevelID: levelID,
+		EmailVerifiedAt:  &now,
+	}
+
+	tx, err := oid.DB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := oid.DM().UsersDAO().Insert(&user, tx); err != nil {
+		lgr.Error("Failed to insert user", zap.Error(err))
+		return nil, err
+	}
+	identity := model.UserIdentities{Issuer: claims.Issuer, Subject: claims.Subject, UserID: user.ID}
+	if err := oid.DM().UserIdentitiesDAO().Insert(&identity, tx); err != nil {
+		lgr.Error("Failed to insert identity", zap.Error(err))
+		return nil, err
+	}
+	orgName := strings.TrimSpace(user.FirstName+" "+user.LastName) + "'s Team"
+	if _, err := oid.SM().OrganizationsService().Create(orgName, user.ID, tx); err != nil {
+		lgr.Error("Failed to create organization", zap.Int64("user id", user.ID), zap.Error(err))
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	lgr.Info("Provisioned user", zap.Int64("user id", user.ID), zap.String("issuer", claims.Issuer))
+	return &user, nil
+}
+
+// levelFor picks the level of the first configured group the user is in. Without
+// a match it returns the default level and false.
+func (oid *oidcService) levelFor(groups []string) (int64, bool, error) {
+	levelsDAO := oid.DM().PrivilegeLevelsDAO()
+	for _, gl := range oid.cfg.GroupLevels {
+		for _, g := range groups {
+			if g != gl.Group {
+				continue
+			}
+			level, err := levelsDAO.GetByName(gl.Level)
+			if err != nil {
+				return 0, false, fmt.Errorf("level %q of group %q: %w", gl.Level, gl.Group, err)
+			}
+			return level.ID, true, nil
+		}
+	}
+	if oid.cfg.DefaultLevel == "" {
+		level, err := levelsDAO.GetSignupDefault()
+		if err != nil {
+			return 0, false, fmt.Errorf("signup level: %w", err)
+		}
+		return level.ID, false, nil
+	}
+	level, err := levelsDAO.GetByName(oid.cfg.DefaultLevel)
+	if err != nil {
+		return 0, false, fmt.Errorf("default level %q: %w", oid.cfg.DefaultLevel, err)
+	}
+	return level.ID, false, nil
+}
+
+// truncate keeps the first n characters of s, the way VARCHAR(n) counts them
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
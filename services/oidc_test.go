@@ -0,0 +1,420 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/tools/oidc"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type fakeUsersDAO struct {
+	DAO.UsersDAO
+	users map[int64]*model.Users
+}
+
+func (d fakeUsersDAO) GetOne(id int64, db qrm.Queryable) (*model.Users, error) {
+	if u, ok := d.users[id]; ok {
+		return u, nil
+	}
+	return nil, qrm.ErrNoRows
+}
+
+func (d fakeUsersDAO) GetByEmail(email string) (*model.Users, error) {
+	for _, u := range d.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, qrm.ErrNoRows
+}
+
+type fakeIdentitiesDAO struct {
+	DAO.UserIdentitiesDAO
+	identities map[authModels.UserIdentitiesPrimaryKey]*model.UserIdentities
+}
+
+func (d fakeIdentitiesDAO) GetOne(pk authModels.UserIdentitiesPrimaryKey, db qrm.Queryable) (*model.UserIdentities, error) {
+	if i, ok := d.identities[pk]; ok {
+		return i, nil
+	}
+	return nil, qrm.ErrNoRows
+}
+
+func (d fakeIdentitiesDAO) Insert(row *model.UserIdentities, db qrm.Queryable) error {
+	d.identities[authModels.UserIdentitiesPrimaryKey{Issuer: row.Issuer, Subject: row.Subject}] = row
+	return nil
+}
+
+type fakeLevelsDAO struct {
+	DAO.PrivilegeLevelsDAO
+	levels []*model.PrivilegeLevels
+}
+
+func (d fakeLevelsDAO) GetByName(name string) (*model.PrivilegeLevels, error) {
+	for _, l := range d.levels {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return nil, qrm.ErrNoRows
+}
+
+func (d fakeLevelsDAO) GetOne(id int64, db qrm.Queryable) (*model.PrivilegeLevels, error) {
+	for _, l := range d.levels {
+		if l.ID == id {
+			copied := *l
+			return &copied, nil
+		}
+	}
+	return nil, qrm.ErrNoRows
+}
+
+func (d fakeLevelsDAO) LockAll(db qrm.Queryable) ([]*model.PrivilegeLevels, error) {
+	copied := make([]*model.PrivilegeLevels, len(d.levels))
+	for i, l := range d.levels {
+		row := *l
+		copied[i] = &row
+	}
+	return copied, nil
+}
+
+func (d fakeLevelsDAO) Update(row *model.PrivilegeLevels, id int64, db qrm.Queryable) error {
+	for _, l := range d.levels {
+		if l.ID == id {
+			*l = *row
+			return nil
+		}
+	}
+	return qrm.ErrNoRows
+}
+
+func (d fakeLevelsDAO) GetSignupDefault() (*model.PrivilegeLevels, error) {
+	for _, l := range d.levels {
+		if l.SignupDefault {
+			return l, nil
+		}
+	}
+	return nil, qrm.ErrNoRows
+}
+
+// fakeLoginsDAO stands in for the table the replicas share
+type fakeLoginsDAO struct {
+	DAO.OIDCLoginsDAO
+	logins map[string]*model.OidcLogins
+}
+
+func (d fakeLoginsDAO) Insert(row *model.OidcLogins, db qrm.Queryable) error {
+	d.logins[row.State] = row
+	return nil
+}
+
+func (d fakeLoginsDAO) Take(state string, now time.Time) (*model.OidcLogins, error) {
+	l, ok := d.logins[state]
+	if !ok || !now.Before(l.ExpiresAt) {
+		return nil, qrm.ErrNoRows
+	}
+	delete(d.logins, state)
+	return l, nil
+}
+
+func (d fakeLoginsDAO) CountPending(now time.Time) (int64, error) {
+	var n int64
+	for _, l := range d.logins {
+		if now.Before(l.ExpiresAt) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (d fakeLoginsDAO) DeleteExpired(now time.Time) error {
+	for state, l := range d.logins {
+		if !now.Before(l.ExpiresAt) {
+			delete(d.logins, state)
+		}
+	}
+	return nil
+}
+
+type fakeOIDCDAOs struct {
+	DAO.DAOManager
+	users      fakeUsersDAO
+	identities fakeIdentitiesDAO
+	levels     fakeLevelsDAO
+	logins     fakeLoginsDAO
+}
+
+func (dm fakeOIDCDAOs) OIDCLoginsDAO() DAO.OIDCLoginsDAO {
+	return dm.logins
+}
+
+func (dm fakeOIDCDAOs) UsersDAO() DAO.UsersDAO {
+	return dm.users
+}
+
+func (dm fakeOIDCDAOs) UserIdentitiesDAO() DAO.UserIdentitiesDAO {
+	return dm.identities
+}
+
+func (dm fakeOIDCDAOs) PrivilegeLevelsDAO() DAO.PrivilegeLevelsDAO {
+	return dm.levels
+}
+
+// userLevels applies level changes to the fake users
+type userLevels struct {
+	context.PrivilegesService
+	users map[int64]*model.Users
+}
+
+func (p userLevels) SetUserPrivilegeLevel(ctx gctx.Context, levelID int64, userID int64) error {
+	p.users[userID].PrivilegeLevelID = levelID
+	return nil
+}
+
+const (
+	basicLevel   int64 = 1000
+	adminLevel   int64 = 1001
+	supportLevel int64 = 1002
+	testIssuer         = "https://idp.example.com"
+)
+
+// newTestOIDC knows one user, 1, whose identity at the issuer is not linked yet
+func newTestOIDC(config cfg.OIDCConfig) (*oidcService, fakeOIDCDAOs) {
+	users := map[int64]*model.Users{
+		1: {ID: 1, Email: "ada@example.com", PrivilegeLevelID: basicLevel},
+	}
+	dm := fakeOIDCDAOs{
+		users:      fakeUsersDAO{users: users},
+		identities: fakeIdentitiesDAO{identities: make(map[authModels.UserIdentitiesPrimaryKey]*model.UserIdentities)},
+		levels: fakeLevelsDAO{levels: []*model.PrivilegeLevels{
+			{ID: basicLevel, Name: "basic", SignupDefault: true},
+			{ID: adminLevel, Name: "admin"},
+			{ID: supportLevel, Name: "support"},
+		}},
+		logins: fakeLoginsDAO{logins: make(map[string]*model.OidcLogins)},
+	}
+	sm := fakeServiceManager{privileges: userLevels{users: users}}
+	config.IssuerURL = testIssuer
+	config.ClientID = "app"
+	return NewOIDCService(testContext{sm: sm, dm: dm}, config, nil), dm
+}
+
+func verified(v bool) *bool {
+	return &v
+}
+
+func TestProvisionLinksVerifiedEmail(t *testing.T) {
+	oid, dm := newTestOIDC(cfg.OIDCConfig{})
+	claims := &oidc.Claims{Issuer: testIssuer, Subject: "sub-1", Email: "ada@example.com", EmailVerified: verified(true)}
+
+	user, err := oid.provision(gctx.Background(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 {
+		t.Errorf("got user %d, want the existing user 1", user.ID)
+	}
+	identity, ok := dm.identities.identities[authModels.UserIdentitiesPrimaryKey{Issuer: testIssuer, Subject: "sub-1"}]
+	if !ok || identity.UserID != 1 {
+		t.Errorf("got identity %+v, want it linked to user 1", identity)
+	}
+
+	// the linked identity signs in even once the email changes at the provider
+	claims.Email = "ada@elsewhere.example.com"
+	if user, err := oid.provision(gctx.Background(), claims); err != nil || user.ID != 1 {
+		t.Errorf("got user %+v, %v, want user 1", user, err)
+	}
+}
+
+func TestProvisionRejectsUnverifiedEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		emailVerified *bool
+	}{
+		{name: "unverified", email: "ada@example.com", emailVerified: verified(false)},
+		{name: "verification not shared", email: "ada@example.com", emailVerified: nil},
+		{name: "no email", email: "", emailVerified: verified(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oid, dm := newTestOIDC(cfg.OIDCConfig{})
+			claims := &oidc.Claims{Issuer: testIssuer, Subject: "sub-1", Email: tt.email, EmailVerified: tt.emailVerified}
+			if _, err := oid.provision(gctx.Background(), claims); !errors.Is(err, ErrOIDCEmail) {
+				t.Errorf("got %v, want ErrOIDCEmail", err)
+			}
+			if len(dm.identities.identities) != 0 {
+				t.Errorf("got identities %+v, want none linked", dm.identities.identities)
+			}
+		})
+	}
+}
+
+func TestProvisionGroupLevels(t *testing.T) {
+	groupLevels := []cfg.GroupLevel{
+		{Group: "ops", Level: "admin"},
+		{Group: "helpdesk", Level: "support"},
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		want   int64
+	}{
+		{name: "mapped group", groups: []string{"helpdesk"}, want: supportLevel},
+		{name: "first configured group wins", groups: []string{"helpdesk", "ops"}, want: adminLevel},
+		// an unmapped login leaves the level an admin may have given by hand
+		{name: "no mapped group", groups: []string{"sales"}, want: basicLevel},
+		{name: "no groups", groups: nil, want: basicLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oid, _ := newTestOIDC(cfg.OIDCConfig{GroupLevels: groupLevels, DefaultLevel: "support"})
+			claims := &oidc.Claims{Issuer: testIssuer, Subject: "sub-1", Email: "ada@example.com", EmailVerified: verified(true), Groups: tt.groups}
+			user, err := oid.provision(gctx.Background(), claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.PrivilegeLevelID != tt.want {
+				t.Errorf("got level %d, want %d", user.PrivilegeLevelID, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCLevelFor(t *testing.T) {
+	groupLevels := []cfg.GroupLevel{{Group: "ops", Level: "admin"}}
+	tests := []struct {
+		name         string
+		defaultLevel string
+		groups       []string
+		want         int64
+		wantMapped   bool
+		wantErr      bool
+	}{
+		{name: "mapped group", groups: []string{"ops"}, want: adminLevel, wantMapped: true},
+		{name: "default level", defaultLevel: "support", groups: []string{"sales"}, want: supportLevel},
+		{name: "signup level", groups: []string{"sales"}, want: basicLevel},
+		{name: "missing default level", defaultLevel: "gone", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oid, _ := newTestOIDC(cfg.OIDCConfig{GroupLevels: groupLevels, DefaultLevel: tt.defaultLevel})
+			level, mapped, err := oid.levelFor(tt.groups)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level != tt.want || mapped != tt.wantMapped {
+				t.Errorf("got level %d mapped %v, want %d mapped %v", level, mapped, tt.want, tt.wantMapped)
+			}
+		})
+	}
+}
+
+func TestOIDCFinishStateMismatch(t *testing.T) {
+	oid, dm := newTestOIDC(cfg.OIDCConfig{})
+	dm.logins.logins["state-1"] = &model.OidcLogins{State: "state-1", ExpiresAt: time.Now().Add(-time.Second)}
+
+	tests := []struct {
+		name         string
+		state        string
+		browserState string
+	}{
+		{name: "other browser", state: "state-1", browserState: "state-2"},
+		{name: "no browser state", state: "state-1", browserState: ""},
+		{name: "no state", state: "", browserState: ""},
+		{name: "unknown state", state: "state-3", browserState: "state-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := oid.Finish(gctx.Background(), tt.state, tt.browserState, "code"); !errors.Is(err, ErrOIDCState) {
+				t.Errorf("got %v, want ErrOIDCState", err)
+			}
+		})
+	}
+	// expired, even with the right browser state
+	if _, err := oid.Finish(gctx.Background(), "state-1", "state-1", "code"); !errors.Is(err, ErrOIDCState) {
+		t.Errorf("got %v for an expired login, want ErrOIDCState", err)
+	}
+}
+
+// useTestProvider points oid at an issuer that refuses every code
+func useTestProvider(t *testing.T, oid *oidcService) {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(oidc.Discovery{
+			Issuer:                srv.URL,
+			AuthorizationEndpoint: srv.URL + "/authorize",
+			TokenEndpoint:         srv.URL + "/token",
+			JWKSURI:               srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/token", func(res http.ResponseWriter, req *http.Request) {
+		http.Error(res, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	})
+	oid.cfg.IssuerURL = srv.URL
+	oid.client = srv.Client()
+}
+
+func TestOIDCLoginOnAnotherReplica(t *testing.T) {
+	first, dm := newTestOIDC(cfg.OIDCConfig{})
+	useTestProvider(t, first)
+	second := NewOIDCService(first.ServiceContext, first.cfg, first.client)
+
+	_, state, err := first.Begin(gctx.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the provider refuses every code, so getting that far means the login was found
+	if _, err := second.Finish(gctx.Background(), state, state, "code"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("got %v finishing on another replica, want the code exchanged", err)
+	}
+	if len(dm.logins.logins) != 0 {
+		t.Errorf("got logins %+v, want the finished one taken", dm.logins.logins)
+	}
+	if _, err := first.Finish(gctx.Background(), state, state, "code"); !errors.Is(err, ErrOIDCState) {
+		t.Errorf("got %v finishing twice, want ErrOIDCState", err)
+	}
+}
+
+func TestOIDCBeginCapsPendingLogins(t *testing.T) {
+	oid, dm := newTestOIDC(cfg.OIDCConfig{})
+	useTestProvider(t, oid)
+	now := time.Now()
+	for i := range maxPendingOIDCLogins {
+		state := fmt.Sprintf("state-%d", i)
+		dm.logins.logins[state] = &model.OidcLogins{State: state, ExpiresAt: now.Add(time.Minute)}
+	}
+	if _, _, err := oid.Begin(gctx.Background()); !errors.Is(err, ErrOIDCBusy) {
+		t.Errorf("got %v with the pending logins full, want ErrOIDCBusy", err)
+	}
+
+	// expired logins make room again
+	dm.logins.logins["state-0"].ExpiresAt = now.Add(-time.Second)
+	if _, _, err := oid.Begin(gctx.Background()); err != nil {
+		t.Errorf("got %v once a login expired, want a new one", err)
+	}
+	if n := len(dm.logins.logins); n != maxPendingOIDCLogins {
+		t.Errorf("got %d logins, want %d", n, maxPendingOIDCLogins)
+	}
+}
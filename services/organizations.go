@@ -0,0 +1,464 @@
+package services
+
+import (
+	gctx "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/carsonkrueger/main/context"
+	agentModel "github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/templates/datainput"
+	"github.com/carsonkrueger/main/templates/partials"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotOrgMember       = errors.New("not a member of the organization")
+	ErrOrgForbidden       = errors.New("only organization owners and admins can do that")
+	ErrInvalidOrgRole     = errors.New("role must be owner, admin or member")
+	ErrLastOwner          = errors.New("an organization needs at least one owner")
+	ErrInvalidOrgName     = errors.New("organization name is required")
+	ErrAlreadyOrgMember   = errors.New("user is already a member")
+	ErrInvalidPhoneNumber = errors.New("invalid phone number")
+	// ErrNoOrganization is returned for data access outside of a session's organization
+	ErrNoOrganization = errors.New("no organization in context")
+)
+
+var orgRoleOptions = []datainput.SelectOptions{
+	{Value: authModels.OrgRoleOwner, Label: "Owner"},
+	{Value: authModels.OrgRoleAdmin, Label: "Admin"},
+	{Value: authModels.OrgRoleMember, Label: "Member"},
+}
+
+type organizationsService struct {
+	context.ServiceContext
+}
+
+func NewOrganizationsService(ctx context.ServiceContext) *organizationsService {
+	return &organizationsService{ctx}
+}
+
+// Create adds an organization with ownerID as its owner. The slug is made from
+// the name and gets a random suffix when taken. The rows are inserted in tx, so
+// a new user and their first organization are committed together.
+func (ogs *organizationsService) Create(name string, ownerID int64, tx *sql.Tx) (*model.Organizations, error) {
+	lgr := ogs.Lgr("Create")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidOrgName
+	}
+	slug, err := ogs.uniqueSlug(name)
+	if err != nil {
+		return nil, err
+	}
+
+	org := model.Organizations{
+		Name: name,
+		Slug: slug,
+	}
+	if err := ogs.DM().OrganizationsDAO().Insert(&org, tx); err != nil {
+		lgr.Error("Failed to insert organization", zap.Error(err))
+		return nil, err
+	}
+	member := model.OrganizationMembers{
+		OrganizationID: org.ID,
+		UserID:         ownerID,
+		Role:           authModels.OrgRoleOwner,
+	}
+	if err := ogs.DM().OrganizationMembersDAO().Insert(&member, tx); err != nil {
+		lgr.Error("Failed to insert owner", zap.Int64("organization", org.ID), zap.Error(err))
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (ogs *organizationsService) uniqueSlug(name string) (string, error) {
+	base := Slugify(name)
+	slug := base
+	for range 5 {
+		_, err := ogs.DM().OrganizationsDAO().GetBySlug(slug)
+		if errors.Is(err, qrm.ErrNoRows) {
+			return slug, nil
+		} else if err != nil {
+			return "", err
+		}
+		suffix, err := tools.GenerateToken(3)
+		if err != nil {
+			return "", err
+		}
+		slug = base + "-" + strings.ToLower(suffix)
+	}
+	return "", fmt.Errorf("no free slug for %q", name)
+}
+
+// Slugify lower cases name and keeps letters and digits, joining words with dashes
+func Slugify(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(r)
+		default:
+			dash = true
+		}
+		if sb.Len() >= 48 {
+			break
+		}
+	}
+	if sb.Len() == 0 {
+		return "org"
+	}
+	return sb.String()
+}
+
+// SessionOrganization returns the organization a session works in and the
+// user's role in it. Sessions without one, or whose user has since left it, fall
+// back to the first organization the user belongs to.
+func (ogs *organizationsService) SessionOrganization(session *model.Sessions) (int64, string, error) {
+	if session.OrganizationID != nil {
+		member, err := ogs.DM().OrganizationMembersDAO().GetOne(authModels.OrganizationMembersPrimaryKey{
+			OrganizationID: *session.OrganizationID,
+			UserID:         session.UserID,
+		}, ogs.DB())
+		if err == nil {
+			return member.OrganizationID, member.Role, nil
+		} else if !errors.Is(err, qrm.ErrNoRows) {
+			return 0, "", err
+		}
+	}
+	orgs, err := ogs.DM().OrganizationsDAO().GetByUser(session.UserID)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(orgs) == 0 {
+		return 0, "", ErrNotOrgMember
+	}
+	session.OrganizationID = &orgs[0].ID
+	if err := ogs.DM().SessionsDAO().Update(session, authModels.SessionsPrimaryKey{
+		UserID:    session.UserID,
+		AuthToken: session.Token,
+	}, ogs.DB()); err != nil {
+		return 0, "", err
+	}
+	return orgs[0].ID, orgs[0].Role, nil
+}
+
+// Switch moves the session in ctx to another organization of the user
+func (ogs *organizationsService) Switch(ctx gctx.Context, orgID int64) error {
+	if _, ok := context.LookupAPIKeyID(ctx); ok {
+		// api keys are bound to the organization they were made in
+		return ErrOrgForbidden
+	}
+	userID := context.GetUserId(ctx)
+	_, err := ogs.DM().OrganizationMembersDAO().GetOne(authModels.OrganizationMembersPrimaryKey{
+		OrganizationID: orgID,
+		UserID:         userID,
+	}, ogs.DB())
+	if errors.Is(err, qrm.ErrNoRows) {
+		return ErrNotOrgMember
+	} else if err != nil {
+		return err
+	}
+	key := authModels.SessionsPrimaryKey{
+		UserID:    userID,
+		AuthToken: context.GetToken(ctx),
+	}
+	dao := ogs.DM().SessionsDAO()
+	session, err := dao.GetOne(key, ogs.DB())
+	if err != nil {
+		return err
+	}
+	session.OrganizationID = &orgID
+	return dao.Update(session, key, ogs.DB())
+}
+
+// AddMember adds the user with email to the organization in ctx
+func (ogs *organizationsService) AddMember(ctx gctx.Context, email string, role string) error {
+	lgr := ogs.Lgr("AddMember")
+	if err := ogs.canGrant(ctx, role); err != nil {
+		return err
+	}
+	user, err := ogs.DM().UsersDAO().GetByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+	orgID := context.GetOrgID(ctx)
+	dao := ogs.DM().OrganizationMembersDAO()
+	_, err = dao.GetOne(authModels.OrganizationMembersPrimaryKey{OrganizationID: orgID, UserID: user.ID}, ogs.DB())
+	if err == nil {
+		return ErrAlreadyOrgMember
+	} else if !errors.Is(err, qrm.ErrNoRows) {
+		return err
+	}
+	member := model.OrganizationMembers{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Role:           role,
+	}
+	if err := dao.Insert(&member, ogs.DB()); err != nil {
+		lgr.Error("Failed to add member", zap.Int64("organization", orgID), zap.Int64("user", user.ID), zap.Error(err))
+		return err
+	}
+	ogs.SM().AuditService().Record(ctx, authModels.AuditOrgMemberAdded, authModels.AuditTargetOrganization, orgID, nil, map[string]any{"user_id": user.ID, "email": user.Email, "role": role})
+	return nil
+}
+
+// SetMemberRole changes a member's role in the organization in ctx. Only owners
+// can make or unmake owners and the last owner can't be demoted.
+func (ogs *organizationsService) SetMemberRole(ctx gctx.Context, userID int64, role string) error {
+	if err := ogs.canGrant(ctx, role); err != nil {
+		return err
+	}
+	key := authModels.OrganizationMembersPrimaryKey{OrganizationID: context.GetOrgID(ctx), UserID: userID}
+	dao := ogs.DM().OrganizationMembersDAO()
+	tx, err := ogs.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	member, err := dao.GetOne(key, tx)
+	if err != nil {
+		return err
+	}
+	if err := ogs.canChange(ctx, member); err != nil {
+		return err
+	}
+	if member.Role == authModels.OrgRoleOwner && role != authModels.OrgRoleOwner {
+		if err := ogs.keepOwner(key.OrganizationID, tx); err != nil {
+			return err
+		}
+	}
+	before := member.Role
+	member.Role = role
+	if err := dao.Update(member, key, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	ogs.SM().AuditService().Record(ctx, authModels.AuditOrgMemberRoleChanged, authModels.AuditTargetOrganization, key.OrganizationID,
+		map[string]any{"user_id": userID, "role": before},
+		map[string]any{"user_id": userID, "role": role},
+	)
+	return nil
+}
+
+// RemoveMember takes a user out of the organization in ctx
+func (ogs *organizationsService) RemoveMember(ctx gctx.Context, userID int64) error {
+	if !authModels.CanManageOrg(context.GetOrgRole(ctx)) {
+		return ErrOrgForbidden
+	}
+	key := authModels.OrganizationMembersPrimaryKey{OrganizationID: context.GetOrgID(ctx), UserID: userID}
+	dao := ogs.DM().OrganizationMembersDAO()
+	tx, err := ogs.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	member, err := dao.GetOne(key, tx)
+	if err != nil {
+		return err
+	}
+	if err := ogs.canChange(ctx, member); err != nil {
+		return err
+	}
+	if member.Role == authModels.OrgRoleOwner {
+		if err := ogs.keepOwner(key.OrganizationID, tx); err != nil {
+			return err
+		}
+	}
+	if err := dao.Delete(key, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	ogs.SM().AuditService().Record(ctx, authModels.AuditOrgMemberRemoved, authModels.AuditTargetOrganization, key.OrganizationID, map[string]any{"user_id": userID, "role": member.Role}, nil)
+	return nil
+}
+
+func (ogs *organizationsService) canGrant(ctx gctx.Context, role string) error {
+	if !authModels.IsOrgRole(role) {
+		return ErrInvalidOrgRole
+	}
+	current := context.GetOrgRole(ctx)
+	if !authModels.CanManageOrg(current) {
+		return ErrOrgForbidden
+	}
+	if role == authModels.OrgRoleOwner && current != authModels.OrgRoleOwner {
+		return ErrOrgForbidden
+	}
+	return nil
+}
+
+// canChange stops admins from changing owners
+func (ogs *organizationsService) canChange(ctx gctx.Context, member *model.OrganizationMembers) error {
+	if member.Role == authModels.OrgRoleOwner && context.GetOrgRole(ctx) != authModels.OrgRoleOwner {
+		return ErrOrgForbidden
+	}
+	return nil
+}
+
+// keepOwner refuses to take away one of the organization's owners when it's the
+// last. It locks the owner rows in tx, so concurrent demotions wait for it to
+// commit and then count the owners that are left.
+func (ogs *organizationsService) keepOwner(orgID int64, tx qrm.Queryable) error {
+	owners, err := ogs.DM().OrganizationMembersDAO().LockRole(orgID, authModels.OrgRoleOwner, tx)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// AddPhoneNumber assigns a number to the organization in ctx, optionally
+// answered by one of its agent profiles
+func (ogs *organizationsService) AddPhoneNumber(ctx gctx.Context, number string, profileID *int64) (*agentModel.PhoneNumbers, error) {
+	lgr := ogs.Lgr("AddPhoneNumber")
+	if !authModels.CanManageOrg(context.GetOrgRole(ctx)) {
+		return nil, ErrOrgForbidden
+	}
+	orgID := context.GetOrgID(ctx)
+	normalized, ok := NormalizePhone(number)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrInvalidPhoneNumber, number)
+	}
+	if profileID != nil {
+		if _, err := ogs.DM().ProfilesDAO().GetOneInOrg(orgID, *profileID, ogs.DB()); err != nil {
+			return nil, err
+		}
+	}
+	row := agentModel.PhoneNumbers{
+		OrganizationID: orgID,
+		Number:         normalized,
+		ProfileID:      profileID,
+	}
+	if err := ogs.DM().PhoneNumbersDAO().Insert(&row, ogs.DB()); err != nil {
+		lgr.Error("Failed to add phone number", zap.Int64("organization", orgID), zap.Error(err))
+		return nil, err
+	}
+	return &row, nil
+}
+
+// RemovePhoneNumber releases a number of the organization in ctx
+func (ogs *organizationsService) RemovePhoneNumber(ctx gctx.Context, id int64) error {
+	if !authModels.CanManageOrg(context.GetOrgRole(ctx)) {
+		return ErrOrgForbidden
+	}
+	return ogs.DM().PhoneNumbersDAO().DeleteInOrg(context.GetOrgID(ctx), id, ogs.DB())
+}
+
+// MembersAsRowData renders the members, with role and remove controls when canManage
+func (ogs *organizationsService) MembersAsRowData(members []authModels.OrganizationMemberJoin, canManage bool) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(members))
+	for i, m := range members {
+		var role templ.Component = datadisplay.Text(m.Role, datadisplay.SM)
+		var remove templ.Component = datadisplay.Text("", datadisplay.SM)
+		if canManage {
+			selectAttrs := templ.Attributes{
+				"_": "on input trigger submit on closest <form/>",
+			}
+			selectBox := datainput.Select(fmt.Sprintf("%d-role-select", m.UserID), "role", m.Role, orgRoleOptions, selectAttrs)
+			role = partials.FormBasic(selectBox, templ.Attributes{
+				"hx-put":     fmt.Sprintf("/organizations/members/%d", m.UserID),
+				"hx-trigger": "submit",
+				"hx-swap":    "none",
+			})
+			remove = datadisplay.X(templ.Attributes{
+				"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+				"hx-delete":  fmt.Sprintf("/organizations/members/%d", m.UserID),
+				"hx-trigger": "click",
+				"hx-swap":    "none",
+				"hx-confirm": "Remove this member?",
+			})
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "n-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(fmt.Sprintf("%s %s", m.FirstName, m.LastName), datadisplay.SM),
+				},
+				{
+					ID:    "em-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(m.Email, datadisplay.SM),
+				},
+				{
+					ID:    "ro-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  role,
+				},
+				{
+					ID:    "del-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  remove,
+				},
+			},
+		}
+	}
+	return rows
+}
+
+// PhoneNumbersAsRowData renders the numbers with the name of the profile answering them
+func (ogs *organizationsService) PhoneNumbersAsRowData(numbers []*agentModel.PhoneNumbers, profiles []*agentModel.Profiles, canManage bool) []datadisplay.RowData {
+	names := make(map[int64]string, len(profiles))
+	for _, p := range profiles {
+		names[p.ID] = p.Name
+	}
+	rows := make([]datadisplay.RowData, len(numbers))
+	for i, n := range numbers {
+		profile := "-"
+		if n.ProfileID != nil {
+			profile = names[*n.ProfileID]
+		}
+		var remove templ.Component = datadisplay.Text("", datadisplay.SM)
+		if canManage {
+			remove = datadisplay.X(templ.Attributes{
+				"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+				"hx-delete":  fmt.Sprintf("/organizations/phone-numbers/%d", n.ID),
+				"hx-trigger": "click",
+				"hx-swap":    "none",
+				"_":          "on htmx:beforeRequest remove closest <tr/>",
+			})
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "nu-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(n.Number, datadisplay.SM),
+				},
+				{
+					ID:    "pr-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(profile, datadisplay.SM),
+				},
+				{
+					ID:    "del-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  remove,
+				},
+			},
+		}
+	}
+	return rows
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type fakeMembersDAO struct {
+	DAO.OrganizationMembersDAO
+	members map[authModels.OrganizationMembersPrimaryKey]*model.OrganizationMembers
+}
+
+func (d fakeMembersDAO) GetOne(pk authModels.OrganizationMembersPrimaryKey, db qrm.Queryable) (*model.OrganizationMembers, error) {
+	if m, ok := d.members[pk]; ok {
+		copied := *m
+		return &copied, nil
+	}
+	return nil, qrm.ErrNoRows
+}
+
+func (d fakeMembersDAO) Insert(row *model.OrganizationMembers, db qrm.Queryable) error {
+	d.members[authModels.OrganizationMembersPrimaryKey{OrganizationID: row.OrganizationID, UserID: row.UserID}] = row
+	return nil
+}
+
+func (d fakeMembersDAO) Update(row *model.OrganizationMembers, pk authModels.OrganizationMembersPrimaryKey, db qrm.Queryable) error {
+	d.members[pk] = row
+	return nil
+}
+
+func (d fakeMembersDAO) Delete(pk authModels.OrganizationMembersPrimaryKey, db qrm.Executable) error {
+	delete(d.members, pk)
+	return nil
+}
+
+func (d fakeMembersDAO) LockRole(orgID int64, role string, db qrm.Queryable) (int64, error) {
+	var n int64
+	for pk, m := range d.members {
+		if pk.OrganizationID == orgID && m.Role == role {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type fakeOrgDAOs struct {
+	DAO.DAOManager
+	users   fakeUsersDAO
+	members fakeMembersDAO
+}
+
+func (dm fakeOrgDAOs) UsersDAO() DAO.UsersDAO {
+	return dm.users
+}
+
+func (dm fakeOrgDAOs) OrganizationMembersDAO() DAO.OrganizationMembersDAO {
+	return dm.members
+}
+
+type auditEvent struct {
+	action   string
+	targetID int64
+	before   any
+	after    any
+}
+
+type recordedAudit struct {
+	context.AuditService
+	events []auditEvent
+}
+
+func (a *recordedAudit) Record(ctx gctx.Context, action string, targetType string, targetID int64, before any, after any) {
+	if targetType != authModels.AuditTargetOrganization {
+		return
+	}
+	a.events = append(a.events, auditEvent{action, targetID, before, after})
+}
+
+const testOrg int64 = 7
+
+// newTestOrgs has user 1 as the owner of testOrg and user 2 outside of it, with
+// ctx acting as the owner
+func newTestOrgs() (*organizationsService, *recordedAudit, gctx.Context) {
+	dm := fakeOrgDAOs{
+		users: fakeUsersDAO{users: map[int64]*model.Users{
+			1: {ID: 1, Email: "ada@example.com"},
+			2: {ID: 2, Email: "grace@example.com"},
+		}},
+		members: fakeMembersDAO{members: map[authModels.OrganizationMembersPrimaryKey]*model.OrganizationMembers{
+			{OrganizationID: testOrg, UserID: 1}: {OrganizationID: testOrg, UserID: 1, Role: authModels.OrgRoleOwner},
+		}},
+	}
+	audit := &recordedAudit{}
+	ogs := NewOrganizationsService(testContext{sm: fakeServiceManager{audit: audit}, dm: dm})
+	ctx := context.WithUserId(gctx.Background(), 1)
+	ctx = context.WithOrgID(ctx, testOrg)
+	ctx = context.WithOrgRole(ctx, authModels.OrgRoleOwner)
+	return ogs, audit, ctx
+}
+
+func TestMemberChangesAreAudited(t *testing.T) {
+	ogs, audit, ctx := newTestOrgs()
+
+	if err := ogs.AddMember(ctx, "grace@example.com", authModels.OrgRoleMember); err != nil {
+		t.Fatal(err)
+	}
+	if err := ogs.SetMemberRole(ctx, 2, authModels.OrgRoleOwner); err != nil {
+		t.Fatal(err)
+	}
+	if err := ogs.RemoveMember(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []auditEvent{
+		{authModels.AuditOrgMemberAdded, testOrg, nil, map[string]any{"user_id": int64(2), "email": "grace@example.com", "role": authModels.OrgRoleMember}},
+		{authModels.AuditOrgMemberRoleChanged, testOrg, map[string]any{"user_id": int64(2), "role": authModels.OrgRoleMember}, map[string]any{"user_id": int64(2), "role": authModels.OrgRoleOwner}},
+		{authModels.AuditOrgMemberRemoved, testOrg, map[string]any{"user_id": int64(2), "role": authModels.OrgRoleOwner}, nil},
+	}
+	if !reflect.DeepEqual(audit.events, want) {
+		t.Errorf("got audit events %+v, want %+v", audit.events, want)
+	}
+}
+
+func TestRefusedMemberChangesAreNotAudited(t *testing.T) {
+	ogs, audit, ctx := newTestOrgs()
+
+	if err := ogs.SetMemberRole(ctx, 1, authModels.OrgRoleMember); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("got %v demoting the last owner, want ErrLastOwner", err)
+	}
+	admin := context.WithOrgRole(ctx, authModels.OrgRoleAdmin)
+	if err := ogs.AddMember(admin, "grace@example.com", authModels.OrgRoleOwner); !errors.Is(err, ErrOrgForbidden) {
+		t.Errorf("got %v for an admin adding an owner, want ErrOrgForbidden", err)
+	}
+	if err := ogs.RemoveMember(admin, 1); !errors.Is(err, ErrOrgForbidden) {
+		t.Errorf("got %v for an admin removing an owner, want ErrOrgForbidden", err)
+	}
+	if len(audit.events) != 0 {
+		t.Errorf("got audit events %+v, want none", audit.events)
+	}
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"go.uber.org/zap"
+)
+
+const PhoneProviderFake = "fake"
+
+var (
+	ErrNoActiveCall          = errors.New("no active phone call")
+	ErrInvalidKeypadDigit    = errors.New("invalid keypad digit")
+	ErrUnknownTransferTarget = errors.New("unknown transfer target")
+)
+
+type phoneService struct {
+	context.ServiceContext
+	provider context.PhoneProvider
+	// target name to the number or queue it dials
+	targets       map[string]string
+	defaultTarget string
+
+	menu map[rune]models.KeypadAction
+
+	mu       sync.Mutex
+	handlers []func(callID string, outcome models.CallOutcome)
+	// keypad handlers of the calls in progress
+	dtmf map[string]func(digit rune)
+}
+
+func NewPhoneService(ctx context.ServiceContext, provider context.PhoneProvider, targets map[string]string, defaultTarget string, menu map[rune]models.KeypadAction) *phoneService {
+	ps := &phoneService{
+		ServiceContext: ctx,
+		provider:       provider,
+		targets:        targets,
+		defaultTarget:  defaultTarget,
+		menu:           menu,
+		dtmf:           make(map[string]func(digit rune)),
+	}
+	provider.SetOutcomeHandler(ps.reportOutcome)
+	provider.SetDTMFHandler(ps.reportDTMF)
+	return ps
+}
+
+// NewPhoneProvider returns the provider named by the config, it errors for
+// names it does not know
+func NewPhoneProvider(config cfg.PhoneConfig) (context.PhoneProvider, error) {
+	switch config.Provider {
+	case PhoneProviderFake:
+		return NewFakePhoneProvider(models.CallOutcome(config.FakeOutcome)), nil
+	default:
+		return nil, fmt.Errorf("unknown phone provider %q", config.Provider)
+	}
+}
+
+// ParseKeypadMenu parses digit=action pairs, where action is "transfer",
+// "transfer:<target>" or "hangup"
+func ParseKeypadMenu(menu map[string]string) (map[rune]models.KeypadAction, error) {
+	actions := make(map[rune]models.KeypadAction, len(menu))
+	for key, value := range menu {
+		digits := []rune(key)
+		if len(digits) != 1 || !IsKeypadDigit(digits[0]) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidKeypadDigit, key)
+		}
+		kind, target, _ := strings.Cut(value, ":")
+		switch kind {
+		case models.KeypadTransfer, models.KeypadHangUp:
+		default:
+			return nil, fmt.Errorf("unknown keypad action %q for %s", value, key)
+		}
+		actions[digits[0]] = models.KeypadAction{Kind: kind, Target: target}
+	}
+	return actions, nil
+}
+
+// IsKeypadDigit reports whether r is one of the 16 DTMF keys
+func IsKeypadDigit(r rune) bool {
+	return strings.ContainsRune("0123456789*#ABCD", r)
+}
+
+func (ps *phoneService) StartCall(ctx gctx.Context) error {
+	callID, ok := context.LookupCallID(ctx)
+	if !ok {
+		return ErrNoActiveCall
+	}
+	return ps.provider.StartCall(ctx, callID)
+}
+
+func (ps *phoneService) EndCall(ctx gctx.Context) error {
+	callID, ok := context.LookupCallID(ctx)
+	if !ok {
+		return ErrNoActiveCall
+	}
+	return ps.provider.EndCall(ctx, callID)
+}
+
+// TransferCall hands the call in ctx to a configured target. An empty target
+// uses the default target.
+func (ps *phoneService) TransferCall(ctx gctx.Context, target string, reason string) (models.CallTransfer, error) {
+	lgr := ps.Lgr("TransferCall")
+	callID, ok := context.LookupCallID(ctx)
+	if !ok {
+		return models.CallTransfer{}, ErrNoActiveCall
+	}
+	if target == "" {
+		target = ps.defaultTarget
+	}
+	destination, ok := ps.targets[target]
+	if !ok {
+		return models.CallTransfer{}, fmt.Errorf("%w %q", ErrUnknownTransferTarget, target)
+	}
+	transfer := models.CallTransfer{
+		CallID:      callID,
+		Target:      target,
+		Destination: destination,
+		Reason:      reason,
+		RequestedAt: time.Now(),
+	}
+	if err := ps.provider.TransferCall(ctx, transfer); err != nil {
+		lgr.Error("transfer failed", zap.String("call", callID), zap.String("target", target), zap.Error(err))
+		return models.CallTransfer{}, err
+	}
+	lgr.Info("call transferred", zap.String("call", callID), zap.String("target", target), zap.String("provider", ps.provider.Name()))
+	return transfer, nil
+}
+
+// HandOff says message to the caller through the agent in ctx, transfers the
+// call and records the escalation on the conversation in ctx. An empty message
+// transfers without a word.
+func (ps *phoneService) HandOff(ctx gctx.Context, target string, reason string, message string) (models.CallTransfer, error) {
+	lgr := ps.Lgr("HandOff")
+	if _, ok := context.LookupCallID(ctx); !ok {
+		return models.CallTransfer{}, ErrNoActiveCall
+	}
+	if speaker, ok := context.LookupAgentSpeaker(ctx); ok && message != "" {
+		if err := speaker.Say(ctx, message); err != nil {
+			lgr.Warn("failed to speak handoff message", zap.Error(err))
+		}
+	}
+	transfer, err := ps.TransferCall(ctx, target, reason)
+	if err != nil {
+		return models.CallTransfer{}, err
+	}
+	if conversationID, ok := context.LookupConversationID(ctx); ok {
+		if err := ps.SM().ConversationsService().Escalate(conversationID, reason, transfer.Target); err != nil {
+			lgr.Error("failed to record escalation", zap.Int64("conversation", conversationID), zap.Error(err))
+		}
+	}
+	return transfer, nil
+}
+
+// Dial places an outbound call. The caller chooses call.CallID.
+func (ps *phoneService) Dial(ctx gctx.Context, call models.OutboundCall) error {
+	if call.CallID == "" {
+		return errors.New("outbound call has no id")
+	}
+	if err := ps.provider.Dial(ctx, call); err != nil {
+		ps.Lgr("Dial").Error("dial failed", zap.String("call", call.CallID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (ps *phoneService) OnOutcome(handler func(callID string, outcome models.CallOutcome)) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.handlers = append(ps.handlers, handler)
+}
+
+func (ps *phoneService) RecordOutcome(ctx gctx.Context, outcome models.CallOutcome) error {
+	callID, ok := context.LookupCallID(ctx)
+	if !ok {
+		return ErrNoActiveCall
+	}
+	ps.reportOutcome(callID, outcome)
+	return nil
+}
+
+func (ps *phoneService) reportOutcome(callID string, outcome models.CallOutcome) {
+	ps.mu.Lock()
+	handlers := append([]func(string, models.CallOutcome){}, ps.handlers...)
+	ps.mu.Unlock()
+	for _, h := range handlers {
+		h(callID, outcome)
+	}
+}
+
+func (ps *phoneService) OnDTMF(callID string, handler func(digit rune)) func() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.dtmf[callID] = handler
+	return func() {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		delete(ps.dtmf, callID)
+	}
+}
+
+func (ps *phoneService) reportDTMF(callID string, digit rune) {
+	ps.mu.Lock()
+	handler, ok := ps.dtmf[callID]
+	ps.mu.Unlock()
+	if ok {
+		handler(digit)
+	}
+}
+
+func (ps *phoneService) MenuAction(digit rune) (models.KeypadAction, bool) {
+	action, ok := ps.menu[digit]
+	return action, ok
+}
+
+// TransferTargets lists the configured target names
+func (ps *phoneService) TransferTargets() []string {
+	names := make([]string, 0, len(ps.targets))
+	for name := range ps.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fakePhoneProvider records what it is asked to do instead of placing calls.
+// Dialed calls end with autoOutcome, when set, as if the callee had answered.
+type fakePhoneProvider struct {
+	autoOutcome models.CallOutcome
+
+	mu        sync.Mutex
+	active    map[string]bool
+	dials     []models.OutboundCall
+	transfers []models.CallTransfer
+	onOutcome func(callID string, outcome models.CallOutcome)
+	onDTMF    func(callID string, digit rune)
+}
+
+func NewFakePhoneProvider(autoOutcome models.CallOutcome) *fakePhoneProvider {
+	return &fakePhoneProvider{
+		autoOutcome: autoOutcome,
+		active:      make(map[string]bool),
+	}
+}
+
+func (p *fakePhoneProvider) Name() string {
+	return PhoneProviderFake
+}
+
+func (p *fakePhoneProvider) SetOutcomeHandler(handler func(callID string, outcome models.CallOutcome)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onOutcome = handler
+}
+
+func (p *fakePhoneProvider) SetDTMFHandler(handler func(callID string, digit rune)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onDTMF = handler
+}
+
+// PressDigit sends a keypad press on a call, as the telephony stream would
+func (p *fakePhoneProvider) PressDigit(callID string, digit rune) {
+	p.mu.Lock()
+	handler := p.onDTMF
+	p.mu.Unlock()
+	if handler != nil {
+		handler(callID, digit)
+	}
+}
+
+func (p *fakePhoneProvider) StartCall(ctx gctx.Context, callID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[callID] = true
+	return nil
+}
+
+func (p *fakePhoneProvider) EndCall(ctx gctx.Context, callID string) error {
+	p.mu.Lock()
+	wasActive := p.active[callID]
+	delete(p.active, callID)
+	p.mu.Unlock()
+	if wasActive {
+		p.Report(callID, models.CallCompleted)
+	}
+	return nil
+}
+
+func (p *fakePhoneProvider) TransferCall(ctx gctx.Context, transfer models.CallTransfer) error {
+	p.mu.Lock()
+	p.transfers = append(p.transfers, transfer)
+	// the call now belongs to whoever it was handed to
+	delete(p.active, transfer.CallID)
+	p.mu.Unlock()
+	p.Report(transfer.CallID, models.CallTransferred)
+	return nil
+}
+
+func (p *fakePhoneProvider) Dial(ctx gctx.Context, call models.OutboundCall) error {
+	p.mu.Lock()
+	p.dials = append(p.dials, call)
+	p.active[call.CallID] = true
+	p.mu.Unlock()
+	if p.autoOutcome != "" {
+		// real providers report asynchronously, after Dial has returned
+		go func() {
+			p.mu.Lock()
+			delete(p.active, call.CallID)
+			p.mu.Unlock()
+			p.Report(call.CallID, p.autoOutcome)
+		}()
+	}
+	return nil
+}
+
+// Report ends a call with outcome, as a provider webhook would
+func (p *fakePhoneProvider) Report(callID string, outcome models.CallOutcome) {
+	p.mu.Lock()
+	handler := p.onOutcome
+	p.mu.Unlock()
+	if handler != nil {
+		handler(callID, outcome)
+	}
+}
+
+// Dials returns the outbound calls placed so far
+func (p *fakePhoneProvider) Dials() []models.OutboundCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.OutboundCall(nil), p.dials...)
+}
+
+// Transfers returns the transfers requested so far
+func (p *fakePhoneProvider) Transfers() []models.CallTransfer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.CallTransfer(nil), p.transfers...)
+}
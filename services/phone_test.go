@@ -0,0 +1,235 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+)
+
+type reportedOutcome struct {
+	callID  string
+	outcome models.CallOutcome
+}
+
+func newTestPhone(autoOutcome models.CallOutcome) (*phoneService, *fakePhoneProvider, chan reportedOutcome) {
+	provider := NewFakePhoneProvider(autoOutcome)
+	ps := NewPhoneService(testContext{}, provider,
+		map[string]string{"sales": "+15550100", "support": "+15550199"},
+		"support",
+		map[rune]models.KeypadAction{'0': {Kind: models.KeypadTransfer}},
+	)
+	outcomes := make(chan reportedOutcome, 4)
+	ps.OnOutcome(func(callID string, outcome models.CallOutcome) {
+		outcomes <- reportedOutcome{callID, outcome}
+	})
+	return ps, provider, outcomes
+}
+
+func receiveOutcome(t *testing.T, outcomes chan reportedOutcome) reportedOutcome {
+	t.Helper()
+	select {
+	case o := <-outcomes:
+		return o
+	case <-time.After(2 * time.Second):
+		t.Fatal("no call outcome reported")
+		return reportedOutcome{}
+	}
+}
+
+func TestNewPhoneProvider(t *testing.T) {
+	if _, err := NewPhoneProvider(cfg.PhoneConfig{Provider: PhoneProviderFake}); err != nil {
+		t.Errorf("fake provider: %v", err)
+	}
+	if _, err := NewPhoneProvider(cfg.PhoneConfig{Provider: "twilo"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestTransferCall(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		wantTarget      string
+		wantDestination string
+	}{
+		{name: "named target", target: "sales", wantTarget: "sales", wantDestination: "+15550100"},
+		{name: "default target", target: "", wantTarget: "support", wantDestination: "+15550199"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, provider, outcomes := newTestPhone("")
+			ctx := context.WithCallID(gctx.Background(), "call-1")
+			if err := ps.StartCall(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			transfer, err := ps.TransferCall(ctx, tt.target, "wants a person")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transfer.Target != tt.wantTarget || transfer.Destination != tt.wantDestination {
+				t.Errorf("got transfer to %s %s, want %s %s", transfer.Target, transfer.Destination, tt.wantTarget, tt.wantDestination)
+			}
+			sent := provider.Transfers()
+			if len(sent) != 1 || sent[0].CallID != "call-1" || sent[0].Reason != "wants a person" {
+				t.Errorf("got provider transfers %+v, want one for call-1", sent)
+			}
+			if o := receiveOutcome(t, outcomes); o != (reportedOutcome{"call-1", models.CallTransferred}) {
+				t.Errorf("got outcome %+v, want call-1 transferred", o)
+			}
+
+			// the call was handed off so ending it reports nothing more
+			if err := ps.EndCall(ctx); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case o := <-outcomes:
+				t.Errorf("got outcome %+v after the transfer", o)
+			default:
+			}
+		})
+	}
+}
+
+func TestTransferCallErrors(t *testing.T) {
+	ps, provider, _ := newTestPhone("")
+
+	if _, err := ps.TransferCall(gctx.Background(), "sales", ""); !errors.Is(err, ErrNoActiveCall) {
+		t.Errorf("got %v without a call, want ErrNoActiveCall", err)
+	}
+	ctx := context.WithCallID(gctx.Background(), "call-1")
+	if _, err := ps.TransferCall(ctx, "billing", ""); !errors.Is(err, ErrUnknownTransferTarget) {
+		t.Errorf("got %v for an unknown target, want ErrUnknownTransferTarget", err)
+	}
+	if sent := provider.Transfers(); len(sent) != 0 {
+		t.Errorf("got provider transfers %+v, want none", sent)
+	}
+}
+
+func TestCallOutcomes(t *testing.T) {
+	t.Run("dialed call", func(t *testing.T) {
+		ps, provider, outcomes := newTestPhone(models.CallNoAnswer)
+		if err := ps.Dial(gctx.Background(), models.OutboundCall{CallID: "out-1", To: "+15550123"}); err != nil {
+			t.Fatal(err)
+		}
+		if dials := provider.Dials(); len(dials) != 1 || dials[0].To != "+15550123" {
+			t.Errorf("got dials %+v, want one to +15550123", dials)
+		}
+		if o := receiveOutcome(t, outcomes); o != (reportedOutcome{"out-1", models.CallNoAnswer}) {
+			t.Errorf("got outcome %+v, want out-1 no answer", o)
+		}
+	})
+
+	t.Run("dial without id", func(t *testing.T) {
+		ps, provider, _ := newTestPhone("")
+		if err := ps.Dial(gctx.Background(), models.OutboundCall{To: "+15550123"}); err == nil {
+			t.Error("expected an error for a call without an id")
+		}
+		if dials := provider.Dials(); len(dials) != 0 {
+			t.Errorf("got dials %+v, want none", dials)
+		}
+	})
+
+	t.Run("ended call", func(t *testing.T) {
+		ps, _, outcomes := newTestPhone("")
+		ctx := context.WithCallID(gctx.Background(), "call-2")
+		if err := ps.StartCall(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := ps.EndCall(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if o := receiveOutcome(t, outcomes); o != (reportedOutcome{"call-2", models.CallCompleted}) {
+			t.Errorf("got outcome %+v, want call-2 completed", o)
+		}
+	})
+
+	t.Run("recorded by the app", func(t *testing.T) {
+		ps, _, outcomes := newTestPhone("")
+		ctx := context.WithCallID(gctx.Background(), "call-3")
+		if err := ps.RecordOutcome(ctx, models.CallVoicemail); err != nil {
+			t.Fatal(err)
+		}
+		if o := receiveOutcome(t, outcomes); o != (reportedOutcome{"call-3", models.CallVoicemail}) {
+			t.Errorf("got outcome %+v, want call-3 voicemail", o)
+		}
+		if err := ps.RecordOutcome(gctx.Background(), models.CallVoicemail); !errors.Is(err, ErrNoActiveCall) {
+			t.Errorf("got %v without a call, want ErrNoActiveCall", err)
+		}
+	})
+}
+
+func TestKeypadPresses(t *testing.T) {
+	ps, provider, _ := newTestPhone("")
+	digits := make(chan rune, 4)
+	stop := ps.OnDTMF("call-1", func(digit rune) {
+		digits <- digit
+	})
+
+	provider.PressDigit("call-1", '0')
+	provider.PressDigit("call-2", '5')
+	stop()
+	provider.PressDigit("call-1", '9')
+
+	close(digits)
+	var got []rune
+	for d := range digits {
+		got = append(got, d)
+	}
+	if string(got) != "0" {
+		t.Errorf("got digits %q, want only the press on call-1 before stopping", string(got))
+	}
+	if action, ok := ps.MenuAction('0'); !ok || action.Kind != models.KeypadTransfer {
+		t.Errorf("got menu action %+v, want a transfer", action)
+	}
+}
+
+// recordingSpeaker keeps what the agent was asked to say
+type recordingSpeaker struct {
+	said []string
+}
+
+func (s *recordingSpeaker) Say(ctx gctx.Context, text string) error {
+	s.said = append(s.said, text)
+	return nil
+}
+
+func TestHandOff(t *testing.T) {
+	ps, provider, outcomes := newTestPhone("")
+	speaker := &recordingSpeaker{}
+	ctx := context.WithAgentSpeaker(gctx.Background(), speaker)
+
+	if _, err := ps.HandOff(ctx, "", "wants a person", "Transferring you now."); !errors.Is(err, ErrNoActiveCall) {
+		t.Errorf("got %v without a call, want ErrNoActiveCall", err)
+	}
+	if len(speaker.said) != 0 {
+		t.Errorf("got %q said without a call, want nothing", speaker.said)
+	}
+
+	ctx = context.WithCallID(ctx, "call-1")
+	if _, err := ps.HandOff(ctx, "billing", "wants a person", ""); !errors.Is(err, ErrUnknownTransferTarget) {
+		t.Errorf("got %v for an unknown target, want ErrUnknownTransferTarget", err)
+	}
+	if len(speaker.said) != 0 {
+		t.Errorf("got %q said for an empty message, want nothing", speaker.said)
+	}
+
+	transfer, err := ps.HandOff(ctx, "sales", "wants a person", "Transferring you now.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Target != "sales" || len(provider.Transfers()) != 1 {
+		t.Errorf("got transfer %+v and provider transfers %+v, want one to sales", transfer, provider.Transfers())
+	}
+	if len(speaker.said) != 1 || speaker.said[0] != "Transferring you now." {
+		t.Errorf("got %q said, want the handoff message", speaker.said)
+	}
+	if o := receiveOutcome(t, outcomes); o != (reportedOutcome{"call-1", models.CallTransferred}) {
+		t.Errorf("got outcome %+v, want call-1 transferred", o)
+	}
+}
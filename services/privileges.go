@@ -1,29 +1,51 @@
 package services
 
 import (
+	gctx "context"
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/a-h/templ"
+	"github.com/carsonkrueger/main/constant"
 	"github.com/carsonkrueger/main/context"
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/models/authModels"
 	"github.com/carsonkrueger/main/templates/datadisplay"
 	"github.com/carsonkrueger/main/templates/datainput"
 	"github.com/carsonkrueger/main/templates/partials"
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrLevelCycle     = errors.New("a level can't inherit from itself or its descendants")
+	ErrInvalidPattern = errors.New("invalid privilege pattern")
+)
+
 type privilegesService struct {
 	context.ServiceContext
+
+	mu sync.RWMutex
+	// level to its effective privileges, nil until loaded
+	levels map[int64]map[int64]authModels.EffectivePrivilege
+	// bumped on every invalidation so a load racing one isn't kept
+	generation uint64
 }
 
 func NewPrivilegesService(ctx context.ServiceContext) *privilegesService {
-	return &privilegesService{ctx}
+	return &privilegesService{ServiceContext: ctx}
 }
 
-func (ps *privilegesService) CreatePrivilegeAssociation(levelID int64, privID int64) error {
+func (ps *privilegesService) CreatePrivilegeAssociation(ctx gctx.Context, levelID int64, privID int64) error {
 	lgr := ps.Lgr("AddPermission")
 	lgr.Info("Level:Privilege", zap.String(strconv.FormatInt(levelID, 10), strconv.FormatInt(privID, 10)))
 
@@ -38,10 +60,12 @@ func (ps *privilegesService) CreatePrivilegeAssociation(levelID int64, privID in
 		return err
 	}
 
+	ps.InvalidateCache()
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelPrivilegeAdded, authModels.AuditTargetLevel, levelID, nil, ps.privilegeAudit(privID))
 	return nil
 }
 
-func (ps *privilegesService) CreateLevel(name string) error {
+func (ps *privilegesService) CreateLevel(ctx gctx.Context, name string) error {
 	lgr := ps.Lgr("CreateLevel")
 	lgr.Info("Called")
 
@@ -53,19 +77,301 @@ func (ps *privilegesService) CreateLevel(name string) error {
 		lgr.Error("Failed to create level", zap.Error(err))
 		return errors.New("Failed to create level")
 	}
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelCreated, authModels.AuditTargetLevel, row.ID, nil, map[string]any{"name": row.Name})
 	return nil
 }
 
+// HasPermissionByID answers from the cached effective privileges, loading them
+// first if needed. It denies when they can't be loaded.
 func (ps *privilegesService) HasPermissionByID(levelID int64, permissionID int64) bool {
-	pk := authModels.PrivilegeLevelsPrivilegesPrimaryKey{
-		PrivilegeID:      permissionID,
+	levels, err := ps.getLevels()
+	if err != nil {
+		ps.Lgr("HasPermissionByID").Error("Failed to load privileges", zap.Error(err))
+		return false
+	}
+	_, ok := levels[levelID][permissionID]
+	return ok
+}
+
+// EffectivePrivileges lists what every level has after inheritance and
+// patterns, ordered by level and privilege name
+func (ps *privilegesService) EffectivePrivileges() ([]authModels.EffectivePrivilege, error) {
+	levels, err := ps.getLevels()
+	if err != nil {
+		return nil, err
+	}
+	var eps []authModels.EffectivePrivilege
+	for _, privs := range levels {
+		for _, ep := range privs {
+			eps = append(eps, ep)
+		}
+	}
+	sort.Slice(eps, func(i, j int) bool {
+		if eps[i].LevelName != eps[j].LevelName {
+			return eps[i].LevelName < eps[j].LevelName
+		}
+		return eps[i].PrivilegeName < eps[j].PrivilegeName
+	})
+	return eps, nil
+}
+
+func (ps *privilegesService) getLevels() (map[int64]map[int64]authModels.EffectivePrivilege, error) {
+	ps.mu.RLock()
+	levels, generation := ps.levels, ps.generation
+	ps.mu.RUnlock()
+	if levels != nil {
+		return levels, nil
+	}
+
+	levels, err := ps.resolveLevels()
+	if err != nil {
+		return nil, err
+	}
+	ps.mu.Lock()
+	if ps.generation == generation {
+		ps.levels = levels
+	}
+	ps.mu.Unlock()
+	return levels, nil
+}
+
+// resolveLevels works out each level's privileges: the ones linked to it, the
+// ones matching its patterns and then its ancestors' own
+func (ps *privilegesService) resolveLevels() (map[int64]map[int64]authModels.EffectivePrivilege, error) {
+	db := ps.DB()
+	levels, err := ps.DM().PrivilegeLevelsDAO().Index(nil, db)
+	if err != nil {
+		return nil, err
+	}
+	privileges, err := ps.DM().PrivilegeDAO().Index(nil, db)
+	if err != nil {
+		return nil, err
+	}
+	links, err := ps.DM().PrivilegeLevelsPrivilegesDAO().Index(nil, db)
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := ps.DM().PrivilegeLevelsPatternsDAO().Index(nil, db)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*model.PrivilegeLevels, len(levels))
+	own := make(map[int64]map[int64]authModels.EffectivePrivilege, len(levels))
+	for _, l := range levels {
+		byID[l.ID] = l
+		own[l.ID] = make(map[int64]authModels.EffectivePrivilege)
+	}
+	names := make(map[int64]string, len(privileges))
+	for _, p := range privileges {
+		names[p.ID] = p.Name
+	}
+	for _, link := range links {
+		level, ok := byID[link.PrivilegeLevelID]
+		if !ok {
+			continue
+		}
+		own[level.ID][link.PrivilegeID] = authModels.EffectivePrivilege{
+			LevelID:       level.ID,
+			LevelName:     level.Name,
+			PrivilegeID:   link.PrivilegeID,
+			PrivilegeName: names[link.PrivilegeID],
+			Grant:         authModels.GrantDirect,
+		}
+	}
+	for _, pat := range patterns {
+		level, ok := byID[pat.PrivilegeLevelID]
+		if !ok {
+			continue
+		}
+		for _, p := range privileges {
+			if _, ok := own[level.ID][p.ID]; ok || !MatchPrivilege(pat.Pattern, p.Name) {
+				continue
+			}
+			own[level.ID][p.ID] = authModels.EffectivePrivilege{
+				LevelID:       level.ID,
+				LevelName:     level.Name,
+				PrivilegeID:   p.ID,
+				PrivilegeName: p.Name,
+				Grant:         authModels.GrantPattern,
+				From:          pat.Pattern,
+			}
+		}
+	}
+
+	effective := make(map[int64]map[int64]authModels.EffectivePrivilege, len(levels))
+	for _, level := range levels {
+		privs := make(map[int64]authModels.EffectivePrivilege, len(own[level.ID]))
+		for id, ep := range own[level.ID] {
+			privs[id] = ep
+		}
+		// the database stops a level being its own parent but not longer cycles
+		seen := map[int64]bool{level.ID: true}
+		for parentID := level.ParentID; parentID != nil && !seen[*parentID]; {
+			parent, ok := byID[*parentID]
+			if !ok {
+				break
+			}
+			seen[parent.ID] = true
+			for id, ep := range own[parent.ID] {
+				if _, ok := privs[id]; ok {
+					continue
+				}
+				privs[id] = authModels.EffectivePrivilege{
+					LevelID:       level.ID,
+					LevelName:     level.Name,
+					PrivilegeID:   id,
+					PrivilegeName: ep.PrivilegeName,
+					Grant:         authModels.GrantInherited,
+					From:          parent.Name,
+				}
+			}
+			parentID = parent.ParentID
+		}
+		effective[level.ID] = privs
+	}
+	return effective, nil
+}
+
+// MatchPrivilege reports whether a privilege name matches a pattern, where *
+// matches any run of characters, so Speak* covers SpeakGet and SpeakWS
+func MatchPrivilege(pattern string, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
+
+// SetLevelParent makes the level inherit from parentID, nil to inherit nothing.
+// The levels are locked while checking for a cycle, so two changes can't each
+// pass the check and make one together.
+func (ps *privilegesService) SetLevelParent(ctx gctx.Context, levelID int64, parentID *int64) error {
+	lgr := ps.Lgr("SetLevelParent")
+	levelsDAO := ps.DM().PrivilegeLevelsDAO()
+	tx, err := ps.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	levels, err := levelsDAO.LockAll(tx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int64]*model.PrivilegeLevels, len(levels))
+	for _, l := range levels {
+		byID[l.ID] = l
+	}
+	level, ok := byID[levelID]
+	if !ok {
+		return qrm.ErrNoRows
+	}
+	// walk up from the new parent, reaching the level or an existing cycle
+	// would make one
+	seen := map[int64]bool{}
+	for id := parentID; id != nil; {
+		if *id == levelID || seen[*id] {
+			return ErrLevelCycle
+		}
+		seen[*id] = true
+		ancestor, ok := byID[*id]
+		if !ok {
+			return qrm.ErrNoRows
+		}
+		id = ancestor.ParentID
+	}
+
+	before := ps.parentAudit(level.ParentID)
+	level.ParentID = parentID
+	if err := levelsDAO.Update(level, level.ID, tx); err != nil {
+		lgr.Error("Failed to set parent", zap.Int64("level id", levelID), zap.Error(err))
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	ps.InvalidateCache()
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelParentChanged, authModels.AuditTargetLevel, levelID, before, ps.parentAudit(parentID))
+	return nil
+}
+
+func (ps *privilegesService) CreateLevelPattern(ctx gctx.Context, levelID int64, pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if _, err := path.Match(pattern, ""); err != nil || pattern == "" || len(pattern) > 255 {
+		return ErrInvalidPattern
+	}
+	row := model.PrivilegeLevelsPatterns{
 		PrivilegeLevelID: levelID,
+		Pattern:          pattern,
+	}
+	if err := ps.DM().PrivilegeLevelsPatternsDAO().Upsert(&row, ps.DB()); err != nil {
+		ps.Lgr("CreateLevelPattern").Error("Failed to insert pattern", zap.Error(err))
+		return err
 	}
-	row, err := ps.DM().PrivilegeLevelsPrivilegesDAO().GetOne(pk, ps.DB())
-	return row != nil && err == nil
+	ps.InvalidateCache()
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelPatternAdded, authModels.AuditTargetLevel, levelID, nil, map[string]any{"pattern": pattern})
+	return nil
 }
 
-func (ps *privilegesService) DeletePrivilegeAssociation(levelID int64, privID int64) error {
+func (ps *privilegesService) DeleteLevelPattern(ctx gctx.Context, levelID int64, pattern string) error {
+	pk := authModels.PrivilegeLevelsPatternsPrimaryKey{
+		PrivilegeLevelID: levelID,
+		Pattern:          pattern,
+	}
+	if err := ps.DM().PrivilegeLevelsPatternsDAO().Delete(pk, ps.DB()); err != nil {
+		ps.Lgr("DeleteLevelPattern").Error("Failed to delete pattern", zap.Error(err))
+		return err
+	}
+	ps.InvalidateCache()
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelPatternRemoved, authModels.AuditTargetLevel, levelID, map[string]any{"pattern": pattern}, nil)
+	return nil
+}
+
+// InvalidateCache drops the cached matrix here and tells other replicas to
+// drop theirs
+func (ps *privilegesService) InvalidateCache() {
+	ps.clearCache()
+	_, err := postgres.RawStatement("SELECT pg_notify(#channel, '')", postgres.RawArgs{
+		"#channel": constant.PRIVILEGES_CHANGED_CHANNEL,
+	}).Exec(ps.DB())
+	if err != nil {
+		ps.Lgr("InvalidateCache").Warn("Failed to notify replicas", zap.Error(err))
+	}
+}
+
+func (ps *privilegesService) clearCache() {
+	ps.mu.Lock()
+	ps.levels = nil
+	ps.generation++
+	ps.mu.Unlock()
+}
+
+// ListenForChanges drops the cache whenever any replica changes privileges,
+// until ctx is done. The cache is also dropped on reconnecting since
+// notifications sent while disconnected are lost.
+func (ps *privilegesService) ListenForChanges(ctx gctx.Context, dbURL string) {
+	lgr := ps.Lgr("ListenForChanges")
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			lgr.Warn("Privileges listener", zap.Error(err))
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(constant.PRIVILEGES_CHANGED_CHANNEL); err != nil {
+		lgr.Error("Failed to listen for privilege changes", zap.Error(err))
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		// a nil notification means the connection was re-established
+		case <-listener.Notify:
+			ps.clearCache()
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+func (ps *privilegesService) DeletePrivilegeAssociation(ctx gctx.Context, levelID int64, privID int64) error {
 	lgr := ps.Lgr("DeletePrivilegeAssociation")
 	lgr.Info("Called")
 
@@ -74,13 +380,16 @@ func (ps *privilegesService) DeletePrivilegeAssociation(levelID int64, privID in
 		PrivilegeLevelID: levelID,
 	}
 	if err := ps.DM().PrivilegeLevelsPrivilegesDAO().Delete(pk, ps.DB()); err != nil {
-		return nil
+		lgr.Error("Failed to delete privilege level privileges", zap.Error(err))
+		return err
 	}
 
+	ps.InvalidateCache()
+	ps.SM().AuditService().Record(ctx, authModels.AuditLevelPrivilegeRemoved, authModels.AuditTargetLevel, levelID, ps.privilegeAudit(privID), nil)
 	return nil
 }
 
-func (us *privilegesService) SetUserPrivilegeLevel(levelID int64, userID int64) error {
+func (us *privilegesService) SetUserPrivilegeLevel(ctx gctx.Context, levelID int64, userID int64) error {
 	lgr := us.Lgr("SetUserPrivilegeLevel")
 	lgr.Info("Called")
 
@@ -99,15 +408,45 @@ func (us *privilegesService) SetUserPrivilegeLevel(levelID int64, userID int64)
 		return err
 	}
 
+	before := map[string]any{"email": user.Email, "privilege_level_id": user.PrivilegeLevelID}
+	if old, err := levelDAO.GetOne(user.PrivilegeLevelID, db); err == nil {
+		before["privilege_level"] = old.Name
+	}
 	user.PrivilegeLevelID = level.ID
 	if err := userDAO.Update(user, user.ID, db); err != nil {
 		lgr.Error("Error updating user", zap.Error(err))
 		return err
 	}
 
+	us.InvalidateCache()
+	after := map[string]any{"email": user.Email, "privilege_level_id": level.ID, "privilege_level": level.Name}
+	us.SM().AuditService().Record(ctx, authModels.AuditUserLevelChanged, authModels.AuditTargetUser, user.ID, before, after)
 	return nil
 }
 
+// privilegeAudit describes a privilege for the audit log, by name when it
+// still exists
+func (ps *privilegesService) privilegeAudit(privID int64) map[string]any {
+	audit := map[string]any{"privilege_id": privID}
+	if priv, err := ps.DM().PrivilegeDAO().GetOne(privID, ps.DB()); err == nil {
+		audit["privilege"] = priv.Name
+	}
+	return audit
+}
+
+// parentAudit describes a level's parent for the audit log, a null parent_id
+// for none
+func (ps *privilegesService) parentAudit(parentID *int64) map[string]any {
+	if parentID == nil {
+		return map[string]any{"parent_id": nil}
+	}
+	audit := map[string]any{"parent_id": *parentID}
+	if parent, err := ps.DM().PrivilegeLevelsDAO().GetOne(*parentID, ps.DB()); err == nil {
+		audit["parent"] = parent.Name
+	}
+	return audit
+}
+
 func (us *privilegesService) UserPrivilegeLevelJoinAsRowData(upl []authModels.UserPrivilegeLevelJoin, allLevels []*model.PrivilegeLevels) []datadisplay.RowData {
 	levelOptions := make([]datainput.SelectOptions, len(allLevels))
 	for i, level := range allLevels {
@@ -183,20 +522,26 @@ func (us *privilegesService) JoinedPrivilegeLevelAsRowData(jpl []authModels.Join
 	return rows
 }
 
-func (us *privilegesService) JoinedPrivilegesAsRowData(jpl []authModels.JoinedPrivilegesRaw) []datadisplay.RowData {
-	rows := make([]datadisplay.RowData, len(jpl))
-	for i, p := range jpl {
-		ca := p.PrivilegeCreatedAt
-		caStr := "No Created At"
-		if ca != nil {
-			caStr = ca.String()
-		}
-		xAttrs := templ.Attributes{
-			"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
-			"hx-delete":  fmt.Sprintf("/privilege-levels-privileges/level/%d/privilege/%d", p.LevelID, p.PrivilegeID),
-			"hx-trigger": "click",
-			"hx-swap":    "none",
-			"_":          "on htmx:beforeRequest remove closest <tr/>",
+// EffectivePrivilegesAsRowData shows where each privilege comes from. Only
+// direct links can be removed, the others go with their pattern or parent.
+func (us *privilegesService) EffectivePrivilegesAsRowData(eps []authModels.EffectivePrivilege) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(eps))
+	for i, p := range eps {
+		grant := "Direct"
+		switch p.Grant {
+		case authModels.GrantPattern:
+			grant = "Pattern " + p.From
+		case authModels.GrantInherited:
+			grant = "Inherited from " + p.From
+		}
+		var remove templ.Component
+		if p.Grant == authModels.GrantDirect {
+			remove = datadisplay.X(templ.Attributes{
+				"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+				"hx-delete":  fmt.Sprintf("/privilege-levels-privileges/level/%d/privilege/%d", p.LevelID, p.PrivilegeID),
+				"hx-trigger": "click",
+				"hx-swap":    "none",
+			})
 		}
 		rows[i].ID = "row-" + strconv.Itoa(i)
 		rows[i].Data = []datadisplay.CellData{
@@ -211,14 +556,93 @@ func (us *privilegesService) JoinedPrivilegesAsRowData(jpl []authModels.JoinedPr
 				Body:  datadisplay.Text(p.PrivilegeName, datadisplay.SM),
 			},
 			{
-				ID:    "ca-" + strconv.Itoa(i),
+				ID:    "gr-" + strconv.Itoa(i),
 				Width: 1,
-				Body:  datadisplay.Text(caStr, datadisplay.MD),
+				Body:  datadisplay.Text(grant, datadisplay.SM),
 			},
 			{
 				ID:    "del-" + strconv.Itoa(i),
 				Width: 1,
-				Body:  datadisplay.X(xAttrs),
+				Body:  remove,
+			},
+		}
+	}
+	return rows
+}
+
+// LevelsAsRowData renders each level with a select of the level it inherits from
+func (us *privilegesService) LevelsAsRowData(levels []*model.PrivilegeLevels) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(levels))
+	for i, l := range levels {
+		options := []datainput.SelectOptions{{Value: "", Label: "Nothing"}}
+		for _, other := range levels {
+			if other.ID != l.ID {
+				options = append(options, datainput.SelectOptions{Value: strconv.FormatInt(other.ID, 10), Label: other.Name})
+			}
+		}
+		parent := ""
+		if l.ParentID != nil {
+			parent = strconv.FormatInt(*l.ParentID, 10)
+		}
+		selectBox := datainput.Select(fmt.Sprintf("%d-parent-select", l.ID), "parent", parent, options, templ.Attributes{
+			"_": "on input trigger submit on closest <form/>",
+		})
+		form := partials.FormBasic(selectBox, templ.Attributes{
+			"hx-put":     fmt.Sprintf("/privilege-levels/%d/parent", l.ID),
+			"hx-trigger": "submit",
+			"hx-swap":    "none",
+		})
+		rows[i] = datadisplay.RowData{
+			ID: "level-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "n-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(l.Name, datadisplay.MD),
+				},
+				{
+					ID:    "pa-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  form,
+				},
+			},
+		}
+	}
+	return rows
+}
+
+func (us *privilegesService) PatternsAsRowData(patterns []*model.PrivilegeLevelsPatterns, levels []*model.PrivilegeLevels) []datadisplay.RowData {
+	names := make(map[int64]string, len(levels))
+	for _, l := range levels {
+		names[l.ID] = l.Name
+	}
+	rows := make([]datadisplay.RowData, len(patterns))
+	for i, p := range patterns {
+		q := url.Values{}
+		q.Set("pattern", p.Pattern)
+		rows[i] = datadisplay.RowData{
+			ID: "pattern-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "lvl-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(names[p.PrivilegeLevelID], datadisplay.SM),
+				},
+				{
+					ID:    "pt-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(p.Pattern, datadisplay.SM),
+				},
+				{
+					ID:    "del-" + strconv.Itoa(i),
+					Width: 1,
+					Body: datadisplay.X(templ.Attributes{
+						"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+						"hx-delete":  fmt.Sprintf("/privilege-levels/%d/patterns?%s", p.PrivilegeLevelID, q.Encode()),
+						"hx-trigger": "click",
+						"hx-swap":    "none",
+					}),
+				},
 			},
 		}
 	}
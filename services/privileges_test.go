@@ -0,0 +1,61 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"testing"
+
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+)
+
+type fakeLevelDAOs struct {
+	DAO.DAOManager
+	levels fakeLevelsDAO
+}
+
+func (dm fakeLevelDAOs) PrivilegeLevelsDAO() DAO.PrivilegeLevelsDAO {
+	return dm.levels
+}
+
+func TestSetLevelParent(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name     string
+		levelID  int64
+		parentID *int64
+		wantErr  error
+	}{
+		{"inherit from an unrelated level", 4, id(1), nil},
+		{"inherit nothing", 2, nil, nil},
+		{"inherit from itself", 1, id(1), ErrLevelCycle},
+		{"inherit from a descendant", 1, id(3), ErrLevelCycle},
+		{"inherit from a level in an existing cycle", 4, id(5), ErrLevelCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 3 inherits from 2 which inherits from 1, and 5 and 6 inherit from each other
+			levels := fakeLevelsDAO{levels: []*model.PrivilegeLevels{
+				{ID: 1, Name: "admin"},
+				{ID: 2, Name: "manager", ParentID: id(1)},
+				{ID: 3, Name: "agent", ParentID: id(2)},
+				{ID: 4, Name: "guest"},
+				{ID: 5, Name: "left", ParentID: id(6)},
+				{ID: 6, Name: "right", ParentID: id(5)},
+			}}
+			ps := NewPrivilegesService(testContext{sm: fakeServiceManager{audit: &recordedAudit{}}, dm: fakeLevelDAOs{levels: levels}})
+
+			err := ps.SetLevelParent(gctx.Background(), tt.levelID, tt.parentID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			level, _ := levels.GetOne(tt.levelID, nil)
+			if (level.ParentID == nil) != (tt.parentID == nil) || (tt.parentID != nil && *level.ParentID != *tt.parentID) {
+				t.Errorf("got parent %v, want %v", level.ParentID, tt.parentID)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/auth/model"
+	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
+	"go.uber.org/zap"
+)
+
+// the length of auth.security_events.email
+const maxEventEmailLen = 255
+
+var (
+	// ErrLoginLocked is returned for every login of a locked account or ip,
+	// whether or not the password is right
+	ErrLoginLocked     = errors.New("too many failed logins, try again later")
+	ErrUnknownThrottle = errors.New("unknown lockout kind")
+)
+
+type securityService struct {
+	context.ServiceContext
+	cfg cfg.LoginConfig
+}
+
+func NewSecurityService(ctx context.ServiceContext, cfg cfg.LoginConfig) *securityService {
+	return &securityService{
+		ServiceContext: ctx,
+		cfg:            cfg,
+	}
+}
+
+// CheckLogin returns ErrLoginLocked while the email or the request's ip is
+// locked out. The email is throttled whether or not it has an account so
+// lockouts don't reveal which emails do.
+func (ss *securityService) CheckLogin(email string, req *http.Request) error {
+	now := time.Now()
+	dao := ss.DM().LoginThrottlesDAO()
+	for _, pk := range loginThrottleKeys(email, req) {
+		throttle, err := dao.GetOne(pk, ss.DB())
+		if errors.Is(err, qrm.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			detail := fmt.Sprintf("%s locked until %s", pk.Kind, throttle.LockedUntil.Format(time.RFC3339))
+			ss.Record(authModels.SecurityLoginBlocked, nil, email, req, detail)
+			return ErrLoginLocked
+		}
+	}
+	return nil
+}
+
+// LoginFailed counts a failed login against the email and the request's ip,
+// locking either out once it's past its free attempts. userID is nil when the
+// email has no account.
+func (ss *securityService) LoginFailed(email string, userID *int64, req *http.Request) {
+	ss.Record(authModels.SecurityLoginFailed, userID, email, req, "")
+	ss.countFailure(email, userID, req)
+}
+
+// ResetRequested counts a password reset request like a failed login, so reset
+// mail can't be sent to an email or from an ip faster than logins can be tried
+func (ss *securityService) ResetRequested(email string, req *http.Request) {
+	ss.Record(authModels.SecurityResetRequested, nil, email, req, "")
+	ss.countFailure(email, nil, req)
+}
+
+// countFailure adds a failure to the email's and the request's ip's throttles
+func (ss *securityService) countFailure(email string, userID *int64, req *http.Request) {
+	lgr := ss.Lgr("countFailure")
+	now := time.Now()
+	dao := ss.DM().LoginThrottlesDAO()
+	for _, pk := range loginThrottleKeys(email, req) {
+		throttle, err := dao.RecordFailure(pk, now, now.Add(-ss.cfg.FailureWindow))
+		if err != nil {
+			lgr.Error("Failed to record login failure", zap.String("kind", pk.Kind), zap.Error(err))
+			continue
+		}
+		free := ss.cfg.AccountFreeAttempts
+		if pk.Kind == authModels.ThrottleIP {
+			free = ss.cfg.IPFreeAttempts
+		}
+		lockout := ss.lockout(int64(throttle.Failures) - free)
+		if lockout <= 0 {
+			continue
+		}
+		until := now.Add(lockout)
+		if err := dao.Lock(pk, until); err != nil {
+			lgr.Error("Failed to lock out login", zap.String("kind", pk.Kind), zap.Error(err))
+			continue
+		}
+		detail := fmt.Sprintf("%s locked for %s after %d failures", pk.Kind, lockout, throttle.Failures)
+		ss.Record(authModels.SecurityLoginLocked, userID, email, req, detail)
+	}
+}
+
+// LoginSucceeded forgets the account's failures. The ip's are kept so logging
+// into one account doesn't reset guessing at others.
+func (ss *securityService) LoginSucceeded(email string, userID int64, req *http.Request) {
+	pk := loginThrottleKeys(email, req)[0]
+	if err := ss.DM().LoginThrottlesDAO().Delete(pk, ss.DB()); err != nil {
+		ss.Lgr("LoginSucceeded").Error("Failed to clear login failures", zap.Error(err))
+	}
+	ss.Record(authModels.SecurityLoginSucceeded, &userID, email, req, "")
+}
+
+// lockout is how long the nth failure past the free attempts locks out for,
+// doubling from the base lockout each time
+func (ss *securityService) lockout(n int64) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	lockout := ss.cfg.BaseLockout
+	for i := int64(1); i < n && lockout < ss.cfg.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, ss.cfg.MaxLockout)
+}
+
+// Record saves a security event, req may be nil for events outside a request.
+// A failure is logged rather than returned so it never blocks the action.
+func (ss *securityService) Record(kind string, userID *int64, email string, req *http.Request, detail string) {
+	event := model.SecurityEvents{
+		Kind:   kind,
+		UserID: userID,
+		Email:  truncate(normalizeEmail(email), maxEventEmailLen),
+		Detail: detail,
+	}
+	if req != nil {
+		event.IPAddress = truncate(tools.ClientIP(req), 64)
+		event.UserAgent = truncate(req.UserAgent(), maxUserAgentLen)
+	}
+	if err := ss.DM().SecurityEventsDAO().Insert(&event, ss.DB()); err != nil {
+		ss.Lgr("Record").Error("Failed to record security event", zap.String("kind", kind), zap.Error(err))
+	}
+}
+
+// Unlock lifts a lockout and forgets its failures
+func (ss *securityService) Unlock(ctx gctx.Context, kind string, key string) error {
+	if kind != authModels.ThrottleAccount && kind != authModels.ThrottleIP {
+		return ErrUnknownThrottle
+	}
+	pk := authModels.LoginThrottlesPrimaryKey{Kind: kind, Key: key}
+	if err := ss.DM().LoginThrottlesDAO().Delete(pk, ss.DB()); err != nil {
+		return err
+	}
+	adminID := context.GetUserId(ctx)
+	email := ""
+	if kind == authModels.ThrottleAccount {
+		email = key
+	}
+	ss.Record(authModels.SecurityLoginUnlocked, nil, email, nil, fmt.Sprintf("%s %s unlocked by user %d", kind, key, adminID))
+	return nil
+}
+
+// RunThrottlePurge deletes throttles with no recent failures or lockout until
+// ctx is done
+func (ss *securityService) RunThrottlePurge(ctx gctx.Context) {
+	lgr := ss.Lgr("RunThrottlePurge")
+	ticker := time.NewTicker(ss.cfg.FailureWindow)
+	defer ticker.Stop()
+	for {
+		deleted, err := ss.DM().LoginThrottlesDAO().DeleteStale(time.Now().Add(-ss.cfg.FailureWindow))
+		if err != nil {
+			lgr.Error("Failed to purge login throttles", zap.Error(err))
+		} else if deleted > 0 {
+			lgr.Info("Purged login throttles", zap.Int64("throttles", deleted))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ss *securityService) EventsAsRowData(events []*model.SecurityEvents) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(events))
+	for i, e := range events {
+		created, user := "-", "-"
+		if e.CreatedAt != nil {
+			created = e.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+		if e.UserID != nil {
+			user = strconv.FormatInt(*e.UserID, 10)
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(created, datadisplay.SM),
+				},
+				{
+					ID:    "kd-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(e.Kind, datadisplay.SM),
+				},
+				{
+					ID:    "em-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(e.Email, datadisplay.SM),
+				},
+				{
+					ID:    "us-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(user, datadisplay.SM),
+				},
+				{
+					ID:    "ip-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(e.IPAddress, datadisplay.SM),
+				},
+				{
+					ID:    "ua-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(e.UserAgent, datadisplay.XS),
+				},
+				{
+					ID:    "dt-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(e.Detail, datadisplay.SM),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+// ThrottlesAsRowData renders active lockouts with an unlock control
+func (ss *securityService) ThrottlesAsRowData(throttles []*model.LoginThrottles) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(throttles))
+	for i, t := range throttles {
+		until := "-"
+		if t.LockedUntil != nil {
+			until = t.LockedUntil.Format("2006-01-02 15:04:05")
+		}
+		q := url.Values{}
+		q.Set("kind", t.Kind)
+		q.Set("key", t.Key)
+		rows[i] = datadisplay.RowData{
+			ID: "lock-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "kd-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(t.Kind, datadisplay.SM),
+				},
+				{
+					ID:    "ky-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(t.Key, datadisplay.SM),
+				},
+				{
+					ID:    "fl-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(strconv.Itoa(int(t.Failures)), datadisplay.SM),
+				},
+				{
+					ID:    "lu-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(until, datadisplay.SM),
+				},
+				{
+					ID:    "ul-" + strconv.Itoa(i),
+					Width: 1,
+					Body: datadisplay.X(templ.Attributes{
+						"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+						"hx-delete":  "/security-events/lockouts?" + q.Encode(),
+						"hx-trigger": "click",
+						"hx-swap":    "none",
+						"_":          "on htmx:beforeRequest remove closest <tr/>",
+					}),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+func loginThrottleKeys(email string, req *http.Request) []authModels.LoginThrottlesPrimaryKey {
+	return []authModels.LoginThrottlesPrimaryKey{
+		{Kind: authModels.ThrottleAccount, Key: truncate(normalizeEmail(email), 255)},
+		{Kind: authModels.ThrottleIP, Key: truncate(tools.ClientIP(req), 255)},
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
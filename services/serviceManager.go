@@ -1,7 +1,13 @@
 package services
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/carsonkrueger/main/cfg"
 	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
 )
 
 type serviceManager struct {
@@ -12,6 +18,19 @@ type serviceManager struct {
 	webSocketService  context.WebSocketService
 	mcpService        context.AppMCPService
 	elevenLabsService context.ElevenLabsService
+	ttsCache          context.TTSCache
+	sttService        context.STTService
+	conversations     context.ConversationsService
+	toolCalls         context.ToolCallsService
+	knowledgeBase     context.KnowledgeBaseService
+	campaigns         context.CampaignsService
+	organizations     context.OrganizationsService
+	apiKeys           context.APIKeysService
+	mailer            context.Mailer
+	accounts          context.AccountsService
+	oidc              context.OIDCService
+	security          context.SecurityService
+	audit             context.AuditService
 	svcCtx            context.ServiceContext
 	ctx               context.ServiceManagerContext
 }
@@ -29,7 +48,7 @@ func (sm *serviceManager) SetAppContext(svcCtx context.ServiceContext) {
 
 func (sm *serviceManager) UsersService() context.UsersService {
 	if sm.usersService == nil {
-		sm.usersService = NewUsersService(sm.svcCtx)
+		sm.usersService = NewUsersService(sm.svcCtx, sm.ctx.Config().SessionConfig)
 	}
 	return sm.usersService
 }
@@ -50,8 +69,17 @@ func (sm *serviceManager) LLMService() context.LLMService {
 
 func (sm *serviceManager) PhoneService() context.PhoneService {
 	if sm.phoneService == nil {
-		// implement concrete phone service here
-		// sm.phoneService = NewTwilioService(sm.svcCtx)
+		cfg := sm.ctx.Config().PhoneConfig
+		provider, err := NewPhoneProvider(cfg)
+		if err != nil {
+			// calls would silently go nowhere, so refuse to run
+			panic(err)
+		}
+		menu, err := ParseKeypadMenu(cfg.DTMFMenu)
+		if err != nil {
+			sm.svcCtx.Lgr("PhoneService").Error("invalid keypad menu, ignoring it", zap.Error(err))
+		}
+		sm.phoneService = NewPhoneService(sm.svcCtx, provider, cfg.TransferTargets, cfg.DefaultTransferTarget, menu)
 	}
 	return sm.phoneService
 }
@@ -65,14 +93,131 @@ func (sm *serviceManager) WebSocketService() context.WebSocketService {
 
 func (sm *serviceManager) MCPService() context.AppMCPService {
 	if sm.mcpService == nil {
-		sm.mcpService = NewMcpService(sm.svcCtx)
+		upstreams, err := cfg.LoadMCPServers(sm.ctx.Config().MCPServersFile)
+		if err != nil {
+			sm.svcCtx.Lgr("MCPService").Error("failed to load mcp servers", zap.Error(err))
+		}
+		mcpService := NewMcpService(sm.svcCtx, upstreams, sm.ctx.Config().ToolConfirmTimeout)
+		mcpService.RequireConfirmation(sm.ctx.Config().ToolConfirmTools...)
+		sm.mcpService = mcpService
 	}
 	return sm.mcpService
 }
 
 func (sm *serviceManager) ElevenLabsService() context.ElevenLabsService {
 	if sm.elevenLabsService == nil {
-		sm.elevenLabsService = NewElevenLabsService(sm.svcCtx, sm.ctx.ElevenLabsClient())
+		elevenLabs := NewElevenLabsService(sm.svcCtx, sm.ctx.ElevenLabsClient())
+		sm.elevenLabsService = NewCachedTTSService(sm.svcCtx, "elevenlabs", elevenLabs, sm.TTSCache())
 	}
 	return sm.elevenLabsService
 }
+
+func (sm *serviceManager) TTSCache() context.TTSCache {
+	if sm.ttsCache == nil {
+		sm.ttsCache = NewTTSCache(sm.svcCtx, sm.ctx.Config().TTSCacheConfig)
+	}
+	return sm.ttsCache
+}
+
+func (sm *serviceManager) STTService() context.STTService {
+	if sm.sttService == nil {
+		cfg := sm.ctx.Config()
+		providers := map[string]context.STTProvider{
+			"elevenlabs": NewElevenLabsSTT(sm.svcCtx, sm.ctx.ElevenLabsClient()),
+			"whisper":    NewWhisperSTT(sm.svcCtx, cfg.WhisperModelPath),
+		}
+		sm.sttService = NewSTTService(sm.svcCtx, cfg.STTProvider, providers)
+	}
+	return sm.sttService
+}
+
+func (sm *serviceManager) ConversationsService() context.ConversationsService {
+	if sm.conversations == nil {
+		sm.conversations = NewConversationsService(sm.svcCtx)
+	}
+	return sm.conversations
+}
+
+func (sm *serviceManager) ToolCallsService() context.ToolCallsService {
+	if sm.toolCalls == nil {
+		sm.toolCalls = NewToolCallsService(sm.svcCtx, sm.ctx.Config().ToolAuditRedactKeys)
+	}
+	return sm.toolCalls
+}
+
+func (sm *serviceManager) KnowledgeBaseService() context.KnowledgeBaseService {
+	if sm.knowledgeBase == nil {
+		cfg := sm.ctx.Config()
+		embedder := NewEmbedder(sm.svcCtx, cfg.Embedder, cfg.EmbeddingModel, sm.LLMService().OpenaiClient())
+		sm.knowledgeBase = NewKnowledgeBaseService(sm.svcCtx, embedder, tools.DefaultChunkOptions())
+	}
+	return sm.knowledgeBase
+}
+
+func (sm *serviceManager) CampaignsService() context.CampaignsService {
+	if sm.campaigns == nil {
+		sm.campaigns = NewCampaignsService(sm.svcCtx)
+	}
+	return sm.campaigns
+}
+
+func (sm *serviceManager) OrganizationsService() context.OrganizationsService {
+	if sm.organizations == nil {
+		sm.organizations = NewOrganizationsService(sm.svcCtx)
+	}
+	return sm.organizations
+}
+
+func (sm *serviceManager) APIKeysService() context.APIKeysService {
+	if sm.apiKeys == nil {
+		sm.apiKeys = NewAPIKeysService(sm.svcCtx)
+	}
+	return sm.apiKeys
+}
+
+func (sm *serviceManager) Mailer() context.Mailer {
+	if sm.mailer == nil {
+		cfg := sm.ctx.Config().MailConfig
+		switch cfg.Provider {
+		case MailerSMTP:
+			sm.mailer = NewSMTPMailer(cfg)
+		case MailerFile:
+			sm.mailer = NewFileMailer(cfg.From, cfg.Dir)
+		case MailerLog:
+			sm.mailer = NewLogMailer(sm.svcCtx.Lgr("Mailer"))
+		default:
+			sm.svcCtx.Lgr("Mailer").Warn("unknown mail provider, logging mail instead", zap.String("provider", cfg.Provider))
+			sm.mailer = NewLogMailer(sm.svcCtx.Lgr("Mailer"))
+		}
+	}
+	return sm.mailer
+}
+
+func (sm *serviceManager) AccountsService() context.AccountsService {
+	if sm.accounts == nil {
+		sm.accounts = NewAccountsService(sm.svcCtx, sm.ctx.Config().AccountConfig)
+	}
+	return sm.accounts
+}
+
+func (sm *serviceManager) OIDCService() context.OIDCService {
+	if sm.oidc == nil {
+		client := &http.Client{Timeout: 10 * time.Second}
+		sm.oidc = NewOIDCService(sm.svcCtx, sm.ctx.Config().OIDCConfig, client)
+	}
+	return sm.oidc
+}
+
+func (sm *serviceManager) SecurityService() context.SecurityService {
+	if sm.security == nil {
+		sm.security = NewSecurityService(sm.svcCtx, sm.ctx.Config().LoginConfig)
+	}
+	return sm.security
+}
+
+func (sm *serviceManager) AuditService() context.AuditService {
+	if sm.audit == nil {
+		sm.audit = NewAuditService(sm.svcCtx)
+	}
+	return sm.audit
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/tools"
+	"go.uber.org/zap"
+)
+
+const (
+	// audio kept from before speech is detected so the first word isn't clipped
+	sttPreroll = 300 * time.Millisecond
+	// how much new speech is needed before another partial transcript is made
+	sttPartialInterval = time.Second
+	// utterances are cut here since local models only see ~30s at a time
+	sttMaxUtterance = 25 * time.Second
+)
+
+type sttService struct {
+	context.ServiceContext
+	defaultProvider string
+	provider        context.STTProvider
+	providers       map[string]context.STTProvider
+	vadCfg          tools.VADConfig
+}
+
+func NewSTTService(ctx context.ServiceContext, defaultProvider string, providers map[string]context.STTProvider) *sttService {
+	return &sttService{
+		ServiceContext:  ctx,
+		defaultProvider: defaultProvider,
+		provider:        providers[defaultProvider],
+		providers:       providers,
+		vadCfg:          tools.DefaultVADConfig(),
+	}
+}
+
+func (s *sttService) Provider(name string) (context.STTProvider, bool) {
+	p, ok := s.providers[name]
+	return p, ok
+}
+
+func (s *sttService) DefaultProvider() string {
+	return s.defaultProvider
+}
+
+func (s *sttService) ProviderNames() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var ErrUnknownSTTProvider = errors.New("unknown speech-to-text provider")
+
+func (s *sttService) Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error) {
+	if s.provider == nil {
+		return models.Transcript{}, ErrUnknownSTTProvider
+	}
+	return s.provider.Transcribe(ctx, pcm)
+}
+
+func (s *sttService) TranscribeStream(ctx gctx.Context, r models.StreamingReader, emit func(models.Transcript)) error {
+	lgr := s.Lgr("TranscribeStream")
+	if s.provider == nil {
+		return ErrUnknownSTTProvider
+	}
+	vad := tools.NewVAD(s.vadCfg)
+	frameSamples := s.vadCfg.FrameSamples()
+	prerollSamples := s.samples(sttPreroll)
+	partialSamples := s.samples(sttPartialInterval)
+	maxSamples := s.samples(sttMaxUtterance)
+
+	var (
+		pending     []byte
+		preroll     []int16
+		utterance   []int16
+		lastPartial int
+		// sample offset of the utterance within the stream
+		offset   int
+		consumed int
+	)
+
+	transcribe := func(final bool) error {
+		t, err := s.provider.Transcribe(ctx, utterance)
+		if err != nil {
+			return err
+		}
+		t.Final = final
+		t.Start += s.duration(offset)
+		t.End += s.duration(offset)
+		for i := range t.Words {
+			t.Words[i].Start += s.duration(offset)
+			t.Words[i].End += s.duration(offset)
+		}
+		if t.Text != "" || final {
+			emit(t)
+		}
+		return nil
+	}
+
+	finish := func() error {
+		defer func() {
+			utterance = nil
+			lastPartial = 0
+		}()
+		if len(utterance) == 0 {
+			return nil
+		}
+		return transcribe(true)
+	}
+
+	for {
+		var chunk []byte
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok = <-r:
+		}
+		if !ok {
+			return finish()
+		}
+		pending = append(pending, chunk...)
+
+		for len(pending) >= frameSamples*2 {
+			frame := make([]int16, frameSamples)
+			copy(frame, tools.BytesToInt16Slice(pending[:frameSamples*2]))
+			pending = pending[frameSamples*2:]
+			consumed += frameSamples
+
+			event := vad.Process(frame)
+			switch {
+			case event == tools.VADSpeechStart:
+				lgr.Debug("speech started")
+				utterance = append(preroll, frame...)
+				offset = consumed - len(utterance)
+				preroll = nil
+			case vad.Speaking() || event == tools.VADSpeechEnd:
+				utterance = append(utterance, frame...)
+			default:
+				preroll = append(preroll, frame...)
+				if len(preroll) > prerollSamples {
+					preroll = preroll[len(preroll)-prerollSamples:]
+				}
+				continue
+			}
+
+			if event == tools.VADSpeechEnd || len(utterance) >= maxSamples {
+				lgr.Debug("speech ended", zap.Int("samples", len(utterance)))
+				vad.Reset()
+				if err := finish(); err != nil {
+					return err
+				}
+				continue
+			}
+			if len(utterance)-lastPartial >= partialSamples {
+				lastPartial = len(utterance)
+				if err := transcribe(false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *sttService) samples(d time.Duration) int {
+	return int(d.Seconds() * float64(s.vadCfg.SampleRate))
+}
+
+func (s *sttService) duration(samples int) time.Duration {
+	return time.Duration(float64(samples) / float64(s.vadCfg.SampleRate) * float64(time.Second))
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+)
+
+// lengthSTT transcribes audio as the number of samples it was given
+type lengthSTT struct{}
+
+func (lengthSTT) Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error) {
+	return models.Transcript{
+		Text: strconv.Itoa(len(pcm)),
+		End:  time.Duration(float64(len(pcm)) / 16000 * float64(time.Second)),
+	}, nil
+}
+
+func silence(d time.Duration) []int16 {
+	return make([]int16, int(d.Seconds()*16000))
+}
+
+func tone(d time.Duration) []int16 {
+	samples := make([]int16, int(d.Seconds()*16000))
+	for i := range samples {
+		samples[i] = int16(4000 * math.Sin(2*math.Pi*440*float64(i)/16000))
+	}
+	return samples
+}
+
+func streamTranscripts(t *testing.T, audio []int16) []models.Transcript {
+	t.Helper()
+	stt := NewSTTService(testContext{}, "length", map[string]context.STTProvider{"length": lengthSTT{}})
+	r := make(chan []byte)
+	go func() {
+		data := make([]byte, len(audio)*2)
+		for i, sample := range audio {
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+		}
+		// odd sized chunks so frames straddle them
+		for len(data) > 0 {
+			n := min(777, len(data))
+			r <- data[:n]
+			data = data[n:]
+		}
+		close(r)
+	}()
+	var out []models.Transcript
+	err := stt.TranscribeStream(gctx.Background(), r, func(t models.Transcript) {
+		out = append(out, t)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestTranscribeStreamPartialsThenFinal(t *testing.T) {
+	var audio []int16
+	audio = append(audio, silence(time.Second)...)
+	audio = append(audio, tone(2500*time.Millisecond)...)
+	audio = append(audio, silence(time.Second)...)
+
+	out := streamTranscripts(t, audio)
+	if len(out) < 3 {
+		t.Fatalf("want partials then a final, got %+v", out)
+	}
+	last := out[len(out)-1]
+	if !last.Final {
+		t.Fatalf("last transcript should be final: %+v", last)
+	}
+	prev := 0
+	for _, tr := range out[:len(out)-1] {
+		if tr.Final {
+			t.Fatalf("only the last transcript should be final: %+v", out)
+		}
+		n, _ := strconv.Atoi(tr.Text)
+		if n <= prev {
+			t.Fatalf("partials should grow: %+v", out)
+		}
+		prev = n
+	}
+	// the utterance starts at the tone, less the preroll
+	if last.Start < 600*time.Millisecond || last.Start > time.Second {
+		t.Fatalf("final starts at %s, want just before 1s", last.Start)
+	}
+}
+
+func TestTranscribeStreamSeparatesUtterances(t *testing.T) {
+	var audio []int16
+	audio = append(audio, silence(500*time.Millisecond)...)
+	audio = append(audio, tone(500*time.Millisecond)...)
+	audio = append(audio, silence(time.Second)...)
+	audio = append(audio, tone(500*time.Millisecond)...)
+	audio = append(audio, silence(time.Second)...)
+
+	var finals []models.Transcript
+	for _, tr := range streamTranscripts(t, audio) {
+		if tr.Final {
+			finals = append(finals, tr)
+		}
+	}
+	if len(finals) != 2 {
+		t.Fatalf("want 2 utterances, got %+v", finals)
+	}
+	if finals[1].Start < 1500*time.Millisecond || finals[1].Start >= 2*time.Second {
+		t.Fatalf("second utterance starts at %s, want shortly before 2s", finals[1].Start)
+	}
+}
+
+func TestTranscribeStreamSilenceEmitsNothing(t *testing.T) {
+	if out := streamTranscripts(t, silence(2*time.Second)); len(out) != 0 {
+		t.Fatalf("want no transcripts for silence, got %+v", out)
+	}
+}
+
+func TestTranscribeStreamCutsLongUtterances(t *testing.T) {
+	out := streamTranscripts(t, tone(sttMaxUtterance+2*time.Second))
+	finals := 0
+	for _, tr := range out {
+		if tr.Final {
+			finals++
+			if n, _ := strconv.Atoi(tr.Text); n > int(sttMaxUtterance.Seconds()*16000)+480 {
+				t.Fatalf("utterance of %d samples is past the max", n)
+			}
+		}
+	}
+	if finals != 2 {
+		t.Fatalf("want the utterance cut in 2, got %d finals", finals)
+	}
+}
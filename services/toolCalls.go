@@ -0,0 +1,267 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
+	"go.uber.org/zap"
+)
+
+const redactedValue = "[REDACTED]"
+
+type toolCallsService struct {
+	context.ServiceContext
+	// lower cased argument keys whose values are replaced before storing
+	redactKeys map[string]struct{}
+	metricsMu  sync.Mutex
+	// counters by organization, then tool name
+	metrics map[int64]map[string]*agentModels.ToolMetrics
+}
+
+func NewToolCallsService(ctx context.ServiceContext, redactKeys []string) *toolCallsService {
+	keys := make(map[string]struct{}, len(redactKeys))
+	for _, k := range redactKeys {
+		keys[strings.ToLower(k)] = struct{}{}
+	}
+	return &toolCallsService{
+		ServiceContext: ctx,
+		redactKeys:     keys,
+		metrics:        make(map[int64]map[string]*agentModels.ToolMetrics),
+	}
+}
+
+// Record writes the call to the audit log and counts it in the tool's metrics.
+// The conversation, user and organization are taken from ctx when present.
+func (ts *toolCallsService) Record(ctx gctx.Context, call agentModels.ToolCall) error {
+	lgr := ts.Lgr("Record")
+	orgID, _ := context.LookupOrgID(ctx)
+	ts.observe(orgID, call)
+
+	args, err := json.Marshal(ts.Redact(call.Arguments))
+	if err != nil {
+		lgr.Warn("Failed to marshal tool arguments", zap.String("tool", call.ToolName), zap.Error(err))
+		args = []byte("{}")
+	}
+	row := model.ToolCalls{
+		ToolName:   call.ToolName,
+		Arguments:  string(args),
+		ResultSize: int32(call.ResultSize),
+		DurationMs: call.Duration.Milliseconds(),
+	}
+	if id, ok := context.LookupConversationID(ctx); ok {
+		row.ConversationID = &id
+	}
+	if id, ok := context.LookupUserId(ctx); ok {
+		row.UserID = &id
+	}
+	if orgID != 0 {
+		row.OrganizationID = &orgID
+	}
+	if call.Error != nil {
+		msg := call.Error.Error()
+		row.Error = &msg
+	}
+	if err := ts.DM().ToolCallsDAO().Insert(&row, ts.DB()); err != nil {
+		lgr.Error("Failed to insert tool call", zap.String("tool", call.ToolName), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Redact returns a copy of args with the values of configured keys replaced,
+// including keys of nested objects and objects inside arrays.
+func (ts *toolCallsService) Redact(args map[string]any) map[string]any {
+	if args == nil {
+		return map[string]any{}
+	}
+	return ts.redactValue(args).(map[string]any)
+}
+
+func (ts *toolCallsService) redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			if _, ok := ts.redactKeys[strings.ToLower(k)]; ok {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = ts.redactValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = ts.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (ts *toolCallsService) observe(orgID int64, call agentModels.ToolCall) {
+	ts.metricsMu.Lock()
+	defer ts.metricsMu.Unlock()
+	tools, ok := ts.metrics[orgID]
+	if !ok {
+		tools = make(map[string]*agentModels.ToolMetrics)
+		ts.metrics[orgID] = tools
+	}
+	m, ok := tools[call.ToolName]
+	if !ok {
+		m = &agentModels.ToolMetrics{
+			ToolName: call.ToolName,
+			Buckets:  make([]int64, len(agentModels.ToolLatencyBuckets)+1),
+		}
+		tools[call.ToolName] = m
+	}
+	m.Calls++
+	if call.Error != nil {
+		m.Errors++
+	}
+	m.TotalDuration += call.Duration
+	m.MaxDuration = max(m.MaxDuration, call.Duration)
+	bucket := sort.Search(len(agentModels.ToolLatencyBuckets), func(i int) bool {
+		return call.Duration <= agentModels.ToolLatencyBuckets[i]
+	})
+	m.Buckets[bucket]++
+}
+
+// Metrics returns a snapshot of the per tool counters of the organization in ctx
+// since startup, sorted by tool name
+func (ts *toolCallsService) Metrics(ctx gctx.Context) []agentModels.ToolMetrics {
+	ts.metricsMu.Lock()
+	defer ts.metricsMu.Unlock()
+	tools := ts.metrics[context.GetOrgID(ctx)]
+	list := make([]agentModels.ToolMetrics, 0, len(tools))
+	for _, m := range tools {
+		cp := *m
+		cp.Buckets = append([]int64(nil), m.Buckets...)
+		list = append(list, cp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ToolName < list[j].ToolName
+	})
+	return list
+}
+
+func (ts *toolCallsService) ToolCallsAsRowData(calls []*model.ToolCalls) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(calls))
+	for i, c := range calls {
+		ca := "No Created At"
+		if c.CreatedAt != nil {
+			ca = c.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+		errStr := ""
+		if c.Error != nil {
+			errStr = *c.Error
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(ca, datadisplay.SM),
+				},
+				{
+					ID:    "tn-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(c.ToolName, datadisplay.SM),
+				},
+				{
+					ID:    "cv-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(optionalID(c.ConversationID), datadisplay.SM),
+				},
+				{
+					ID:    "us-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(optionalID(c.UserID), datadisplay.SM),
+				},
+				{
+					ID:    "ar-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(c.Arguments, datadisplay.XS),
+				},
+				{
+					ID:    "rs-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(strconv.Itoa(int(c.ResultSize)), datadisplay.SM),
+				},
+				{
+					ID:    "du-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(strconv.FormatInt(c.DurationMs, 10)+"ms", datadisplay.SM),
+				},
+				{
+					ID:    "er-" + strconv.Itoa(i),
+					Width: 2,
+					Body:  datadisplay.Text(errStr, datadisplay.XS),
+				},
+			},
+		}
+	}
+	return rows
+}
+
+func (ts *toolCallsService) ToolMetricsAsRowData(metrics []agentModels.ToolMetrics) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(metrics))
+	for i, m := range metrics {
+		cells := []datadisplay.CellData{
+			{
+				ID:    "tn-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(m.ToolName, datadisplay.SM),
+			},
+			{
+				ID:    "cl-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(strconv.FormatInt(m.Calls, 10), datadisplay.SM),
+			},
+			{
+				ID:    "er-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(strconv.FormatInt(m.Errors, 10), datadisplay.SM),
+			},
+			{
+				ID:    "av-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(strconv.FormatInt(m.AvgDuration().Milliseconds(), 10)+"ms", datadisplay.SM),
+			},
+			{
+				ID:    "mx-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(strconv.FormatInt(m.MaxDuration.Milliseconds(), 10)+"ms", datadisplay.SM),
+			},
+		}
+		for b, count := range m.Buckets {
+			cells = append(cells, datadisplay.CellData{
+				ID:    "b" + strconv.Itoa(b) + "-" + strconv.Itoa(i),
+				Width: 1,
+				Body:  datadisplay.Text(strconv.FormatInt(count, 10), datadisplay.SM),
+			})
+		}
+		rows[i] = datadisplay.RowData{
+			ID:   "row-" + strconv.Itoa(i),
+			Data: cells,
+		}
+	}
+	return rows
+}
+
+func optionalID(id *int64) string {
+	if id == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*id, 10)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	gctx "context"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/database/DAO"
+	"github.com/carsonkrueger/main/gen/go_db/agent/model"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+type insertedToolCalls struct {
+	DAO.ToolCallsDAO
+}
+
+func (d insertedToolCalls) Insert(row *model.ToolCalls, db qrm.Queryable) error {
+	return nil
+}
+
+type fakeToolCallDAOs struct {
+	DAO.DAOManager
+}
+
+func (dm fakeToolCallDAOs) ToolCallsDAO() DAO.ToolCallsDAO {
+	return insertedToolCalls{}
+}
+
+func TestToolMetricsByOrganization(t *testing.T) {
+	ts := NewToolCallsService(testContext{dm: fakeToolCallDAOs{}}, nil)
+	orgA := context.WithOrgID(gctx.Background(), 1)
+	orgB := context.WithOrgID(gctx.Background(), 2)
+
+	for _, ctx := range []gctx.Context{orgA, orgA, orgB} {
+		if err := ts.Record(ctx, agentModels.ToolCall{ToolName: "lookup", Duration: time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		ctx   gctx.Context
+		calls int64
+	}{
+		{"first organization", orgA, 2},
+		{"second organization", orgB, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := ts.Metrics(tt.ctx)
+			if len(metrics) != 1 || metrics[0].Calls != tt.calls {
+				t.Errorf("got metrics %+v, want %d lookup calls", metrics, tt.calls)
+			}
+		})
+	}
+	if metrics := ts.Metrics(context.WithOrgID(gctx.Background(), 3)); len(metrics) != 0 {
+		t.Errorf("got metrics %+v for an organization without calls, want none", metrics)
+	}
+}
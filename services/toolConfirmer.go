@@ -0,0 +1,140 @@
+package services
+
+import (
+	gctx "context"
+	"encoding/json"
+	"regexp"
+	"sync"
+
+	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	declinePattern = regexp.MustCompile(`(?i)\b(no|nope|nah|don'?t|do not|cancel|stop|decline|negative)\b`)
+	approvePattern = regexp.MustCompile(`(?i)\b(yes|yeah|yep|yup|sure|ok|okay|confirm|confirmed|correct|approve|go ahead|do it)\b`)
+)
+
+// parseSpokenConfirmation reports whether text approves or declines, and false
+// for ok when it does neither. Declines win so "no, don't do it" is not approval.
+func parseSpokenConfirmation(text string) (approved bool, ok bool) {
+	if declinePattern.MatchString(text) {
+		return false, true
+	}
+	if approvePattern.MatchString(text) {
+		return true, true
+	}
+	return false, false
+}
+
+type agentJSONWriter interface {
+	WriteJSON(payload any) error
+}
+
+// voiceToolConfirmer asks the caller of a voice conversation to approve tool calls,
+// both out loud through the agent and with a confirmation event to the web UI.
+// Whichever answer arrives first is used.
+type voiceToolConfirmer struct {
+	agent agentJSONWriter
+
+	mu      sync.Mutex
+	w       models.StreamingWriter[models.StreamingResponseBody]
+	pending map[string]chan bool
+	// ids in the order they were asked, spoken answers resolve the oldest
+	order []string
+}
+
+func newVoiceToolConfirmer(agent agentJSONWriter) *voiceToolConfirmer {
+	return &voiceToolConfirmer{
+		agent:   agent,
+		pending: make(map[string]chan bool),
+	}
+}
+
+// attach sets the socket writer used to send confirmation events to the web UI
+func (c *voiceToolConfirmer) attach(w models.StreamingWriter[models.StreamingResponseBody]) {
+	c.mu.Lock()
+	c.w = w
+	c.mu.Unlock()
+}
+
+func (c *voiceToolConfirmer) ConfirmTool(ctx gctx.Context, req agentModels.ToolConfirmationRequest) (bool, error) {
+	answer := make(chan bool, 1)
+	c.mu.Lock()
+	c.pending[req.ID] = answer
+	c.order = append(c.order, req.ID)
+	w := c.w
+	c.mu.Unlock()
+	defer c.forget(req.ID)
+
+	if w != nil {
+		event, err := json.Marshal(req)
+		if err != nil {
+			return false, err
+		}
+		select {
+		case w <- models.StreamingResponse[models.StreamingResponseBody]{
+			Type: websocket.BinaryMessage,
+			Data: models.StreamingResponseBody{Type: models.SR_TOOL_CONFIRM, Data: event},
+		}:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	if err := (agentSpeaker{c.agent}).Say(ctx, req.Prompt); err != nil {
+		return false, err
+	}
+
+	select {
+	case approved := <-answer:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Resolve answers a pending confirmation by id, as sent from the web UI
+func (c *voiceToolConfirmer) Resolve(id string, approved bool) bool {
+	c.mu.Lock()
+	answer, ok := c.pending[id]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case answer <- approved:
+	default:
+	}
+	return true
+}
+
+// ResolveSpoken answers the oldest pending confirmation from what the caller said.
+// It returns false when nothing is pending or the text is not a yes or no.
+func (c *voiceToolConfirmer) ResolveSpoken(text string) bool {
+	c.mu.Lock()
+	if len(c.order) == 0 {
+		c.mu.Unlock()
+		return false
+	}
+	id := c.order[0]
+	c.mu.Unlock()
+
+	approved, ok := parseSpokenConfirmation(text)
+	if !ok {
+		return false
+	}
+	return c.Resolve(id, approved)
+}
+
+func (c *voiceToolConfirmer) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+	for i, pendingID := range c.order {
+		if pendingID == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carsonkrueger/main/cfg"
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"go.uber.org/zap"
+)
+
+type ttsCache struct {
+	context.ServiceContext
+	mem     *models.LRU[string, []byte]
+	dir     string
+	offline bool
+}
+
+func NewTTSCache(ctx context.ServiceContext, config cfg.TTSCacheConfig) *ttsCache {
+	return &ttsCache{
+		ServiceContext: ctx,
+		mem:            models.NewLRU[string](config.MaxBytes, func(b []byte) int64 { return int64(len(b)) }),
+		dir:            config.Dir,
+		offline:        config.Offline,
+	}
+}
+
+// Key addresses synthesized audio by everything that changes the output. Text is
+// normalized so whitespace differences in prompts share an entry.
+func (c *ttsCache) Key(provider string, req models.TTSRequest) string {
+	text := strings.Join(strings.Fields(req.Text), " ")
+	h := sha256.New()
+	for _, part := range []string{provider, req.Voice, req.Model, req.Format, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ttsCache) Get(key string) ([]byte, bool) {
+	if audio, ok := c.mem.Get(key); ok {
+		return audio, true
+	}
+	if c.dir == "" {
+		return nil, false
+	}
+	audio, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	c.mem.Add(key, audio)
+	return audio, true
+}
+
+func (c *ttsCache) Put(key string, audio []byte) error {
+	c.mem.Add(key, audio)
+	if c.dir == "" {
+		return nil
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// write to a temp file first so readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(audio); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		c.Lgr("ttsCache.Put").Warn("failed to persist tts audio", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c *ttsCache) Offline() bool {
+	return c.offline
+}
+
+func (c *ttsCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
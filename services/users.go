@@ -1,26 +1,44 @@
 package services
 
 import (
+	gctx "context"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
+	"github.com/a-h/templ"
+	"github.com/carsonkrueger/main/cfg"
 	"github.com/carsonkrueger/main/constant"
 	"github.com/carsonkrueger/main/context"
 	"github.com/carsonkrueger/main/gen/go_db/auth/model"
 	"github.com/carsonkrueger/main/models/authModels"
+	"github.com/carsonkrueger/main/templates/datadisplay"
 	"github.com/carsonkrueger/main/tools"
+	"github.com/go-jet/jet/v2/qrm"
 	"go.uber.org/zap"
 )
 
+// the length of auth.sessions.user_agent
+const maxUserAgentLen = 512
+
+var (
+	ErrSessionExpired  = errors.New("session expired")
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidLogin is returned for unknown emails and wrong passwords alike
+	ErrInvalidLogin = errors.New("invalid email or password")
+)
+
 type usersService struct {
 	context.ServiceContext
+	sessions cfg.SessionConfig
 }
 
-func NewUsersService(ctx context.ServiceContext) *usersService {
+func NewUsersService(ctx context.ServiceContext, sessions cfg.SessionConfig) *usersService {
 	return &usersService{
-		ctx,
+		ServiceContext: ctx,
+		sessions:       sessions,
 	}
 }
 
@@ -28,27 +46,77 @@ func (us *usersService) Login(email string, password string, req *http.Request)
 	lgr := us.Lgr("Login")
 	lgr.Info("Called")
 	dao := us.DM().UsersDAO()
+	security := us.SM().SecurityService()
+
+	if err := security.CheckLogin(email, req); err != nil {
+		return nil, err
+	}
 
 	user, err := dao.GetByEmail(email)
-	if err != nil {
+	if errors.Is(err, qrm.ErrNoRows) {
+		// hash anyway so unknown emails take as long as wrong passwords
+		tools.HashPassword(password)
+		security.LoginFailed(email, nil, req)
+		return nil, ErrInvalidLogin
+	} else if err != nil {
 		return nil, err
 	}
 
+	match, rehash := tools.VerifyPassword(password, user.Password)
+	if !match {
+		security.LoginFailed(email, &user.ID, req)
+		return nil, ErrInvalidLogin
+	}
+	if rehash {
+		us.rehashPassword(user.ID, password)
+	}
+
 	go us.LogoutRequest(req)
+	security.LoginSucceeded(email, user.ID, req)
 
-	parts := strings.Split(user.Password, "$")
-	hash := tools.HashPassword(password, parts[0])
+	return us.StartSession(user.ID, nil, req)
+}
 
-	if user.Password != hash {
-		return nil, errors.New("Invalid password")
+// rehashPassword stores the password hashed with the current params. The login
+// goes ahead if it fails, the old hash still works.
+func (us *usersService) rehashPassword(userID int64, password string) {
+	lgr := us.Lgr("rehashPassword")
+	hash, err := tools.HashPassword(password)
+	if err != nil {
+		lgr.Error("Failed to hash password", zap.Error(err))
+		return
+	}
+	if err := us.DM().UsersDAO().SetPassword(userID, hash); err != nil {
+		lgr.Error("Failed to store rehashed password", zap.Int64("user id", userID), zap.Error(err))
+		return
 	}
+	lgr.Info("Rehashed password", zap.Int64("user id", userID))
+}
 
-	token, _ := tools.GenerateToken(32)
-	fullToken := fmt.Sprintf("%s$%d", token, user.ID)
+// StartSession creates a session for the user and returns the token for the auth
+// cookie. A nil orgID starts in the user's first organization.
+func (us *usersService) StartSession(userID int64, orgID *int64, req *http.Request) (*string, error) {
+	token, err := tools.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	fullToken := fmt.Sprintf("%s$%d", token, userID)
 
+	now := time.Now()
+	expiresAt := us.sessionExpiry(now, now)
 	row := &model.Sessions{
-		UserID: user.ID,
-		Token:  token,
+		UserID:         userID,
+		Token:          token,
+		OrganizationID: orgID,
+		ExpiresAt:      &expiresAt,
+		LastSeenAt:     &now,
+	}
+	if req != nil {
+		row.UserAgent = req.UserAgent()
+		if len(row.UserAgent) > maxUserAgentLen {
+			row.UserAgent = row.UserAgent[:maxUserAgentLen]
+		}
+		row.IPAddress = tools.ClientIP(req)
 	}
 	sesDAO := us.DM().SessionsDAO()
 	if err = sesDAO.Insert(row, us.DB()); err != nil {
@@ -58,6 +126,160 @@ func (us *usersService) Login(email string, password string, req *http.Request)
 	return &fullToken, nil
 }
 
+// sessionExpiry is the sooner of the idle and absolute expiry of a session
+// created at created and last used at now
+func (us *usersService) sessionExpiry(created time.Time, now time.Time) time.Time {
+	idle := now.Add(us.sessions.IdleTimeout)
+	absolute := created.Add(us.sessions.AbsoluteTimeout)
+	if absolute.Before(idle) {
+		return absolute
+	}
+	return idle
+}
+
+// CheckSession deletes an expired session and returns ErrSessionExpired, otherwise
+// it pushes the idle expiry back at most once per renew interval
+func (us *usersService) CheckSession(session *model.Sessions) error {
+	lgr := us.Lgr("CheckSession")
+	now := time.Now()
+	key := authModels.SessionsPrimaryKey{
+		UserID:    session.UserID,
+		AuthToken: session.Token,
+	}
+	sesDAO := us.DM().SessionsDAO()
+	if session.ExpiresAt != nil && !now.Before(*session.ExpiresAt) {
+		if err := sesDAO.Delete(key, us.DB()); err != nil {
+			lgr.Warn("Failed to delete expired session", zap.Int64("user id", session.UserID), zap.Error(err))
+		}
+		return ErrSessionExpired
+	}
+	if session.LastSeenAt != nil && now.Sub(*session.LastSeenAt) < us.sessions.RenewInterval {
+		return nil
+	}
+	created := now
+	if session.CreatedAt != nil {
+		created = *session.CreatedAt
+	}
+	expiresAt := us.sessionExpiry(created, now)
+	session.LastSeenAt = &now
+	session.ExpiresAt = &expiresAt
+	if err := sesDAO.Update(session, key, us.DB()); err != nil {
+		lgr.Error("Failed to renew session", zap.Int64("user id", session.UserID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ActiveSessions lists the user's sessions that have not expired
+func (us *usersService) ActiveSessions(userID int64) ([]*model.Sessions, error) {
+	return us.DM().SessionsDAO().GetActiveByUser(userID, time.Now())
+}
+
+// RevokeSession signs one of the user's sessions out
+func (us *usersService) RevokeSession(ctx gctx.Context, userID int64, sessionID int64) error {
+	lgr := us.Lgr("RevokeSession")
+	lgr.Info("Revoking session", zap.Int64("user id", userID), zap.Int64("session id", sessionID))
+	deleted, err := us.DM().SessionsDAO().DeleteByID(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
+	us.SM().AuditService().Record(ctx, authModels.AuditSessionRevoked, authModels.AuditTargetUser, userID, map[string]any{"session_id": sessionID}, nil)
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere, including the current session
+func (us *usersService) RevokeAllSessions(ctx gctx.Context, userID int64) error {
+	lgr := us.Lgr("RevokeAllSessions")
+	deleted, err := us.DM().SessionsDAO().DeleteByUser(userID)
+	if err != nil {
+		return err
+	}
+	lgr.Info("Signed out everywhere", zap.Int64("user id", userID), zap.Int64("sessions", deleted))
+	if deleted > 0 {
+		us.SM().AuditService().Record(ctx, authModels.AuditSessionsRevoked, authModels.AuditTargetUser, userID, map[string]any{"sessions": deleted}, nil)
+	}
+	return nil
+}
+
+// RunSessionPurge deletes expired sessions every purge interval until ctx is done
+func (us *usersService) RunSessionPurge(ctx gctx.Context) {
+	lgr := us.Lgr("RunSessionPurge")
+	ticker := time.NewTicker(us.sessions.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := us.DM().SessionsDAO().DeleteExpired(time.Now())
+		if err != nil {
+			lgr.Error("Failed to purge expired sessions", zap.Error(err))
+		} else if deleted > 0 {
+			lgr.Info("Purged expired sessions", zap.Int64("sessions", deleted))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SessionsAsRowData renders sessions with a revoke control, marking the one
+// with currentToken
+func (us *usersService) SessionsAsRowData(sessions []*model.Sessions, currentToken string) []datadisplay.RowData {
+	rows := make([]datadisplay.RowData, len(sessions))
+	for i, s := range sessions {
+		created, lastSeen := "-", "-"
+		if s.CreatedAt != nil {
+			created = s.CreatedAt.Format("2006-01-02 15:04")
+		}
+		if s.LastSeenAt != nil {
+			lastSeen = s.LastSeenAt.Format("2006-01-02 15:04")
+		}
+		var revoke templ.Component = datadisplay.Text("This session", datadisplay.SM)
+		if s.Token != currentToken {
+			revoke = datadisplay.X(templ.Attributes{
+				"class":      "fill-red-400 size-6 p-1 rounded-xs mx-auto cursor-pointer hover:bg-[#FFFFFF44]",
+				"hx-delete":  fmt.Sprintf("/sessions/%d", s.ID),
+				"hx-trigger": "click",
+				"hx-swap":    "none",
+				"_":          "on htmx:beforeRequest remove closest <tr/>",
+			})
+		}
+		rows[i] = datadisplay.RowData{
+			ID: "row-" + strconv.Itoa(i),
+			Data: []datadisplay.CellData{
+				{
+					ID:    "ca-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(created, datadisplay.SM),
+				},
+				{
+					ID:    "ls-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(lastSeen, datadisplay.SM),
+				},
+				{
+					ID:    "ua-" + strconv.Itoa(i),
+					Width: 3,
+					Body:  datadisplay.Text(s.UserAgent, datadisplay.XS),
+				},
+				{
+					ID:    "ip-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  datadisplay.Text(s.IPAddress, datadisplay.SM),
+				},
+				{
+					ID:    "rv-" + strconv.Itoa(i),
+					Width: 1,
+					Body:  revoke,
+				},
+			},
+		}
+	}
+	return rows
+}
+
 func (us *usersService) Logout(id int64, token string) error {
 	lgr := us.Lgr("Logout")
 	lgr.Info("Logging out", zap.Int64("user id", id))
@@ -7,22 +7,28 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/carsonkrueger/main/context"
 	"github.com/carsonkrueger/main/models"
+	"github.com/carsonkrueger/main/models/agentModels"
+	"github.com/carsonkrueger/main/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
 
 	msginterfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/agent/v1/websocket/interfaces"
 	client "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/agent"
 	"github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces/v1"
 )
 
-func NewDeepgramHandler(log io.Writer) DeepgramHandler {
+func NewDeepgramHandler(log io.Writer, mcp *server.MCPServer) DeepgramHandler {
 	return DeepgramHandler{
+		mcp:                          mcp,
 		binaryChan:                   make(chan *[]byte),
 		openChan:                     make(chan *msginterfaces.OpenResponse),
 		welcomeResponse:              make(chan *msginterfaces.WelcomeResponse),
@@ -38,7 +44,9 @@ func NewDeepgramHandler(log io.Writer) DeepgramHandler {
 		injectionRefusedResponse:     make(chan *msginterfaces.InjectionRefusedResponse),
 		keepAliveResponse:            make(chan *msginterfaces.KeepAlive),
 		settingsAppliedResponse:      make(chan *msginterfaces.SettingsAppliedResponse),
+		agentAudioDone:               make(chan struct{}, 1),
 		log:                          log,
+		lgr:                          zap.NewNop(),
 	}
 }
 
@@ -60,6 +68,12 @@ type DeepgramHandler struct {
 	keepAliveResponse            chan *msginterfaces.KeepAlive
 	settingsAppliedResponse      chan *msginterfaces.SettingsAppliedResponse
 	log                          io.Writer
+	lgr                          *zap.Logger
+	// signalled whenever the agent finishes speaking
+	agentAudioDone chan struct{}
+	// set once the agent connection exists
+	agent     agentJSONWriter
+	confirmer *voiceToolConfirmer
 }
 
 func (dch DeepgramHandler) GetBinary() []*chan *[]byte {
@@ -123,10 +137,57 @@ func (dch DeepgramHandler) GetSettingsApplied() []*chan *msginterfaces.SettingsA
 	return []*chan *msginterfaces.SettingsAppliedResponse{&dch.settingsAppliedResponse}
 }
 
+// AgentFunctions describes tools to the agent's think provider. The sdk's
+// Parameters only model a single property, so each tool's arguments are spelled
+// out in its description instead.
+func AgentFunctions(tools []mcp.Tool) []interfacesv1.Functions {
+	functions := make([]interfacesv1.Functions, len(tools))
+	for i, t := range tools {
+		description := t.Description
+		if args := describeArguments(t.InputSchema); args != "" {
+			description = strings.TrimSpace(description + " Arguments: " + args)
+		}
+		functions[i] = interfacesv1.Functions{
+			Name:        t.Name,
+			Description: description,
+			Parameters: interfacesv1.Parameters{
+				Type:     "object",
+				Required: t.InputSchema.Required,
+			},
+		}
+	}
+	return functions
+}
+
+// describeArguments lists each property as "name (type, required): description"
+func describeArguments(schema mcp.ToolInputSchema) string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		prop, _ := schema.Properties[name].(map[string]any)
+		kind, _ := prop["type"].(string)
+		if slices.Contains(schema.Required, name) {
+			kind += ", required"
+		}
+		parts[i] = fmt.Sprintf("%s (%s)", name, strings.TrimPrefix(kind, ", "))
+		if d, _ := prop["description"].(string); d != "" {
+			parts[i] += ": " + d
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
 type voiceV2 struct {
 	dgWS     *client.WSChannel
 	callback DeepgramHandler
 	context.ServiceContext
+	// set for outbound calls, holds caller audio back until a person answers
+	answering *answeringMachine
+	keypad    *keypad
 }
 
 func NewVoiceV2(ctx gctx.Context, svcCtx context.ServiceContext, dgApiKey string, clientOptions *interfaces.ClientOptions, settings *interfaces.SettingsOptions, handler DeepgramHandler) (*voiceV2, error) {
@@ -136,22 +197,65 @@ func NewVoiceV2(ctx gctx.Context, svcCtx context.ServiceContext, dgApiKey string
 	if err != nil {
 		return nil, err
 	}
+	handler.agent = dgWS
+	handler.lgr = svcCtx.Lgr("DeepgramHandler")
+	handler.confirmer = newVoiceToolConfirmer(dgWS)
 	return &voiceV2{
-		dgWS,
-		handler,
-		svcCtx,
+		dgWS:           dgWS,
+		callback:       handler,
+		ServiceContext: svcCtx,
+		keypad:         newKeypad(svcCtx, dgWS),
 	}, nil
 }
 
+// DetectAnsweringMachine treats the session as an outbound call. The agent stays
+// quiet until answering machine detection decides who picked up, then greets a
+// person or leaves the voicemail script for a machine.
+func (v *voiceV2) DetectAnsweringMachine(opts models.AnswerOptions) {
+	v.answering = newAnsweringMachine(v.ServiceContext, tools.DefaultAMDConfig(), opts, agentSpeaker{v.dgWS}, v.callback.agentAudioDone)
+}
+
 func (g *voiceV2) Options() models.WebSocketOptions {
 	return models.WebSocketOptions{}
 }
 
+// HandleText receives the web UI's keypad presses and answers to tool
+// confirmation events
+func (v *voiceV2) HandleText(ctx gctx.Context, msg []byte) {
+	lgr := v.Lgr("HandleText")
+	var envelope models.TextMessage
+	if err := json.Unmarshal(msg, &envelope); err != nil {
+		lgr.Warn("Invalid text message", zap.Error(err))
+		return
+	}
+	if envelope.Type == models.SR_DTMF {
+		var press models.DTMFMessage
+		if err := json.Unmarshal(msg, &press); err != nil || len([]rune(press.Digit)) != 1 {
+			lgr.Warn("Invalid keypad message", zap.ByteString("message", msg))
+			return
+		}
+		v.keypad.Press(ctx, []rune(press.Digit)[0], KeypadSourceWeb)
+		return
+	}
+	var res agentModels.ToolConfirmationResponse
+	if err := json.Unmarshal(msg, &res); err != nil {
+		lgr.Warn("Invalid text message", zap.Error(err))
+		return
+	}
+	if !v.callback.confirmer.Resolve(res.ID, res.Approved) {
+		lgr.Warn("No pending tool confirmation", zap.String("id", res.ID))
+	}
+}
+
 func (v *voiceV2) HandleRequestWithStreaming(ctx gctx.Context, r models.StreamingReader, w models.StreamingWriter[models.StreamingResponseBody]) {
 	lgr := v.Lgr("HandleRequestWithStreaming")
 	pr, pw := io.Pipe()
 	defer pw.Close()
 
+	v.callback.confirmer.attach(w)
+	ctx = context.WithToolConfirmer(ctx, v.callback.confirmer)
+	ctx = context.WithAgentSpeaker(ctx, agentSpeaker{v.dgWS})
+
 	lgr.Info("Starting streaming: user <- agent")
 	go v.callback.Run(ctx, w) // user <- agent
 	if !v.dgWS.Connect() {
@@ -161,6 +265,16 @@ func (v *voiceV2) HandleRequestWithStreaming(ctx gctx.Context, r models.Streamin
 	defer v.dgWS.Stop()
 	lgr.Info("Starting streaming: user -> agent")
 	go v.dgWS.Stream(pr) // user => agent
+	if v.answering != nil {
+		go v.answering.Run(ctx)
+	}
+	defer v.keypad.Stop()
+	if callID, ok := context.LookupCallID(ctx); ok {
+		stop := v.SM().PhoneService().OnDTMF(callID, func(digit rune) {
+			v.keypad.Press(ctx, digit, KeypadSourceTelephony)
+		})
+		defer stop()
+	}
 
 	// handle streaming data from our websocket to the deepgram websocket
 	for {
@@ -168,6 +282,12 @@ func (v *voiceV2) HandleRequestWithStreaming(ctx gctx.Context, r models.Streamin
 		case <-ctx.Done():
 			return
 		case res := <-r:
+			if v.answering != nil && !v.answering.Connected() {
+				// the agent must not answer a voicemail greeting
+				v.answering.Write(res)
+				continue
+			}
+			v.keypad.Write(ctx, res)
 			if _, err := pw.Write(res); err != nil {
 				lgr.Error("Failed to write data to pipe writer")
 				return
@@ -228,6 +348,9 @@ func (dch DeepgramHandler) Run(ctx gctx.Context, w models.StreamingWriter[models
 			// Track conversation flow
 			switch ctr.Role {
 			case "user":
+				if dch.confirmer != nil && dch.confirmer.ResolveSpoken(ctr.Content) {
+					dch.lgr.Info("Tool confirmation answered", zap.String("answer", ctr.Content))
+				}
 				fmt.Printf("Received user message: %s\n", ctr.Content)
 				fmt.Printf("Waiting for agent to process...\n")
 			case "assistant":
@@ -308,6 +431,10 @@ func (dch DeepgramHandler) Run(ctx gctx.Context, w models.StreamingWriter[models
 		for range dch.agentAudioDoneResponse {
 			fmt.Printf("[AgentAudioDoneResponse]\n")
 			fmt.Printf("Agent finished speaking, waiting for next user input...")
+			select {
+			case dch.agentAudioDone <- struct{}{}:
+			default:
+			}
 
 			// Write to chat log
 			if err := dch.writeToChatLog("system", "Agent finished speaking"); err != nil {
@@ -399,8 +526,9 @@ func (dch DeepgramHandler) Run(ctx gctx.Context, w models.StreamingWriter[models
 	go func() {
 		defer wgReceivers.Done()
 		for call := range dch.functionCallRequestResponse {
-			dch.HandleToolCall(ctx, call)
 			fmt.Printf("[FunctionCallRequestResponse]")
+			// calls waiting on confirmation must not hold up the rest
+			go dch.respondToToolCall(ctx, call)
 		}
 	}()
 
@@ -426,6 +554,28 @@ func (dch *DeepgramHandler) writeToChatLog(role, content string) error {
 	return nil
 }
 
+// respondToToolCall runs the call and returns its output to the agent
+func (dch *DeepgramHandler) respondToToolCall(ctx gctx.Context, call *msginterfaces.FunctionCallRequestResponse) {
+	output, err := dch.HandleToolCall(ctx, call)
+	var text string
+	if err != nil {
+		text = fmt.Sprintf("The tool failed: %v", err)
+	} else {
+		text = *output
+	}
+	if dch.agent == nil {
+		return
+	}
+	res := msginterfaces.FunctionCallResponse{
+		Type:           "FunctionCallResponse",
+		FunctionCallID: call.FunctionCallID,
+		Output:         text,
+	}
+	if err := dch.agent.WriteJSON(res); err != nil {
+		dch.lgr.Error("Failed to send function call response", zap.String("call", call.FunctionCallID), zap.Error(err))
+	}
+}
+
 func (dch *DeepgramHandler) HandleToolCall(ctx gctx.Context, call *msginterfaces.FunctionCallRequestResponse) (*string, error) {
 	var msg struct {
 		JSONRPC string        `json:"jsonrpc"`
@@ -436,6 +586,8 @@ func (dch *DeepgramHandler) HandleToolCall(ctx gctx.Context, call *msginterfaces
 	}
 
 	msg.JSONRPC = mcp.JSONRPC_VERSION
+	// without an id the server treats the call as a notification and returns nothing
+	msg.ID = call.FunctionCallID
 	msg.Method = mcp.MethodToolsCall
 	msg.Params.Name = call.FunctionName
 	msg.Params.Arguments = call.Input
@@ -446,21 +598,27 @@ func (dch *DeepgramHandler) HandleToolCall(ctx gctx.Context, call *msginterfaces
 		return nil, fmt.Errorf("failed to marshal tool call message: %v", err)
 	}
 	res := dch.mcp.HandleMessage(ctx, bts)
+	if rpcErr, ok := res.(mcp.JSONRPCError); ok {
+		return nil, fmt.Errorf("tool call failed: %s", rpcErr.Error.Message)
+	}
 	result, ok := res.(mcp.JSONRPCResponse)
 	if !ok {
 		return nil, fmt.Errorf("unexpected response type")
 	}
 	toolRes, ok := result.Result.(mcp.CallToolResult)
-	if !ok || toolRes.IsError {
+	if !ok || len(toolRes.Content) == 0 {
 		return nil, fmt.Errorf("unexpected response type")
 	}
-	content := toolRes.Content[0]
-
-	switch content := content.(type) {
-	case mcp.TextContent:
-		str := content.Text
-		return &str, nil
-	default:
-		return nil, fmt.Errorf("unexpected content type")
-	}
+	// error results still explain to the model what happened, e.g. a declined confirmation
+	str := toolResultText(&toolRes)
+	return &str, nil
+}
+
+// agentSpeaker has the deepgram agent say text as its next message
+type agentSpeaker struct {
+	agent agentJSONWriter
+}
+
+func (s agentSpeaker) Say(ctx gctx.Context, text string) error {
+	return s.agent.WriteJSON(msginterfaces.InjectAgentMessage{Type: "InjectAgentMessage", Content: text})
 }
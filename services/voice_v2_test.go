@@ -0,0 +1,267 @@
+package services
+
+import (
+	gctx "context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models/agentModels"
+	msginterfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/agent/v1/websocket/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type fakeServiceManager struct {
+	context.ServiceManager
+	privileges context.PrivilegesService
+	toolCalls  context.ToolCallsService
+	audit      context.AuditService
+	security   context.SecurityService
+	mailer     context.Mailer
+}
+
+func (sm fakeServiceManager) PrivilegesService() context.PrivilegesService {
+	return sm.privileges
+}
+
+func (sm fakeServiceManager) ToolCallsService() context.ToolCallsService {
+	return sm.toolCalls
+}
+
+func (sm fakeServiceManager) AuditService() context.AuditService {
+	return sm.audit
+}
+
+func (sm fakeServiceManager) SecurityService() context.SecurityService {
+	return sm.security
+}
+
+func (sm fakeServiceManager) Mailer() context.Mailer {
+	return sm.mailer
+}
+
+// levelPrivileges grants each privilege level the privilege ids it maps to
+type levelPrivileges struct {
+	context.PrivilegesService
+	granted map[int64][]int64
+}
+
+func (p levelPrivileges) HasPermissionByID(levelID int64, permissionID int64) bool {
+	for _, id := range p.granted[levelID] {
+		if id == permissionID {
+			return true
+		}
+	}
+	return false
+}
+
+type recordedToolCalls struct {
+	context.ToolCallsService
+	mu    sync.Mutex
+	calls []agentModels.ToolCall
+}
+
+func (r *recordedToolCalls) Record(ctx gctx.Context, call agentModels.ToolCall) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *recordedToolCalls) Redact(args map[string]any) map[string]any {
+	return args
+}
+
+// fakeAgent collects what would be sent to the deepgram agent
+type fakeAgent struct {
+	sent chan any
+}
+
+func (a fakeAgent) WriteJSON(payload any) error {
+	a.sent <- payload
+	return nil
+}
+
+const (
+	testLevel     int64 = 1
+	testToolPriv  int64 = 10
+	testOtherPriv int64 = 11
+)
+
+// newTestVoiceTools serves an echo tool the test level may use, a lookup tool it
+// may not, and a delete tool that needs confirmation
+func newTestVoiceTools(t *testing.T) (*DeepgramHandler, *recordedToolCalls, fakeAgent) {
+	t.Helper()
+	calls := &recordedToolCalls{}
+	sm := fakeServiceManager{
+		privileges: levelPrivileges{granted: map[int64][]int64{testLevel: {testToolPriv}}},
+		toolCalls:  calls,
+	}
+	m := newAppMCP(testContext{sm: sm}, time.Second)
+	echo := func(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + request.GetString("text", "")), nil
+	}
+	m.server.AddTools(
+		server.ServerTool{Tool: mcp.NewTool("echo", mcp.WithString("text")), Handler: echo},
+		server.ServerTool{Tool: mcp.NewTool("lookup", mcp.WithString("text")), Handler: echo},
+		server.ServerTool{Tool: mcp.NewTool("delete", mcp.WithString("text")), Handler: echo},
+	)
+	m.toolPrivileges["echo"] = testToolPriv
+	m.toolPrivileges["lookup"] = testOtherPriv
+	m.toolPrivileges["delete"] = testToolPriv
+	m.RequireConfirmation("delete")
+
+	agent := fakeAgent{sent: make(chan any, 4)}
+	handler := NewDeepgramHandler(nil, m.Server())
+	handler.agent = agent
+	handler.confirmer = newVoiceToolConfirmer(agent)
+	return &handler, calls, agent
+}
+
+func voiceToolCall(id string, name string, text string) *msginterfaces.FunctionCallRequestResponse {
+	return &msginterfaces.FunctionCallRequestResponse{
+		Type:           "FunctionCallRequest",
+		FunctionName:   name,
+		FunctionCallID: id,
+		Input:          map[string]string{"text": text},
+	}
+}
+
+func receiveSent(t *testing.T, agent fakeAgent) any {
+	t.Helper()
+	select {
+	case payload := <-agent.sent:
+		return payload
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing sent to the agent")
+		return nil
+	}
+}
+
+func TestHandleToolCall(t *testing.T) {
+	handler, calls, _ := newTestVoiceTools(t)
+	ctx := context.WithPrivilegeLevelID(gctx.Background(), testLevel)
+
+	output, err := handler.HandleToolCall(ctx, voiceToolCall("call-1", "echo", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *output != "echo: hello" {
+		t.Errorf("got output %q, want %q", *output, "echo: hello")
+	}
+	if len(calls.calls) != 1 || calls.calls[0].ToolName != "echo" || calls.calls[0].Error != nil {
+		t.Errorf("got recorded calls %+v, want one successful echo call", calls.calls)
+	}
+}
+
+func TestHandleToolCallWithoutPrivilege(t *testing.T) {
+	handler, calls, _ := newTestVoiceTools(t)
+	ctx := context.WithPrivilegeLevelID(gctx.Background(), testLevel)
+
+	output, err := handler.HandleToolCall(ctx, voiceToolCall("call-1", "lookup", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(*output, "permission") {
+		t.Errorf("got output %q, want a permission error", *output)
+	}
+	if len(calls.calls) != 1 || calls.calls[0].Error == nil {
+		t.Errorf("got recorded calls %+v, want one failed call", calls.calls)
+	}
+}
+
+func TestHandleToolCallUnknownTool(t *testing.T) {
+	handler, _, _ := newTestVoiceTools(t)
+	ctx := context.WithPrivilegeLevelID(gctx.Background(), testLevel)
+
+	if _, err := handler.HandleToolCall(ctx, voiceToolCall("call-1", "missing", "hello")); err == nil {
+		t.Error("expected an error for a tool that does not exist")
+	}
+}
+
+func TestRespondToToolCallConfirmation(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{name: "approved", answer: "yes please", want: "echo: drop it"},
+		{name: "declined", answer: "no, don't do that", want: "did not approve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, agent := newTestVoiceTools(t)
+			ctx := context.WithPrivilegeLevelID(gctx.Background(), testLevel)
+			ctx = context.WithToolConfirmer(ctx, handler.confirmer)
+
+			go handler.respondToToolCall(ctx, voiceToolCall("call-7", "delete", "drop it"))
+
+			prompt, ok := receiveSent(t, agent).(msginterfaces.InjectAgentMessage)
+			if !ok || !strings.Contains(prompt.Content, "confirm") {
+				t.Fatalf("got %+v, want the agent to ask for confirmation", prompt)
+			}
+			if !handler.confirmer.ResolveSpoken(tt.answer) {
+				t.Fatal("spoken answer did not resolve the confirmation")
+			}
+
+			res, ok := receiveSent(t, agent).(msginterfaces.FunctionCallResponse)
+			if !ok {
+				t.Fatalf("got %+v, want a function call response", res)
+			}
+			if res.FunctionCallID != "call-7" {
+				t.Errorf("got function call id %q, want %q", res.FunctionCallID, "call-7")
+			}
+			if !strings.Contains(res.Output, tt.want) {
+				t.Errorf("got output %q, want it to contain %q", res.Output, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgentFunctionsListPermittedTools(t *testing.T) {
+	sm := fakeServiceManager{privileges: levelPrivileges{granted: map[int64][]int64{testLevel: {testToolPriv}}}}
+	m := newAppMCP(testContext{sm: sm}, time.Second)
+	noop := func(ctx gctx.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(""), nil
+	}
+	m.server.AddTools(
+		server.ServerTool{Tool: mcp.NewTool("transfer_call",
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Why")),
+			mcp.WithString("target"),
+			mcp.WithDescription("Transfer the call."),
+		), Handler: noop},
+		server.ServerTool{Tool: mcp.NewTool("lookup", mcp.WithDescription("Look it up.")), Handler: noop},
+	)
+	m.toolPrivileges["transfer_call"] = testToolPriv
+	m.toolPrivileges["lookup"] = testOtherPriv
+
+	ctx := context.WithPrivilegeLevelID(gctx.Background(), testLevel)
+	listed, err := m.Client().ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions := AgentFunctions(listed.Tools)
+	if len(functions) != 1 {
+		t.Fatalf("got functions %+v, want only transfer_call", functions)
+	}
+	f := functions[0]
+	wantDescription := "Transfer the call. Arguments: reason (string, required): Why; target (string)"
+	if f.Name != "transfer_call" || f.Description != wantDescription {
+		t.Errorf("got function %q described %q, want transfer_call described %q", f.Name, f.Description, wantDescription)
+	}
+	if f.Parameters.Type != "object" || len(f.Parameters.Required) != 1 || f.Parameters.Required[0] != "reason" {
+		t.Errorf("got parameters %+v, want an object requiring reason", f.Parameters)
+	}
+
+	// a session without a level is told about no tools at all
+	listed, err = m.Client().ListTools(gctx.Background(), mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Tools) != 0 {
+		t.Errorf("got tools %+v without a level, want none", listed.Tools)
+	}
+}
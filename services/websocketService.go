@@ -20,24 +20,31 @@ func NewWebSocketService(ctx context.ServiceContext) *webSocketService {
 	}
 }
 
-func (ws *webSocketService) StartStreamingResponseSocket(conn *websocket.Conn, handler context.StreamingSocketHandler) {
+// StartStreamingResponseSocket runs handler until the socket closes or ctx is done.
+// ctx should come from the upgraded request so handlers see the session user.
+func (ws *webSocketService) StartStreamingResponseSocket(ctx gctx.Context, conn *websocket.Conn, handler context.StreamingSocketHandler) {
 	lgr := ws.Lgr("StartStreamingResponseSocket")
-	ctx, cancel := gctx.WithCancel(gctx.Background())
+	ctx, cancel := gctx.WithCancel(ctx)
 	ctx = context.WithCancel(ctx, cancel)
 	incoming := make(chan []byte)
 	outgoing := make(chan models.StreamingResponse[models.StreamingResponseBody])
+	textHandler, handlesText := handler.(context.TextMessageHandler)
 
 	// Reader goroutine
 	go func() {
 		for {
 			select {
 			default:
-				_, msg, err := conn.ReadMessage()
+				msgType, msg, err := conn.ReadMessage()
 				if err != nil {
 					cancel()
 					lgr.Warn("Closing connection - failed read")
 					return
 				}
+				if handlesText && msgType == websocket.TextMessage {
+					textHandler.HandleText(ctx, msg)
+					continue
+				}
 				lgr.Debug("Received msg", zap.Int("size", len(msg)))
 				incoming <- msg
 			case <-ctx.Done():
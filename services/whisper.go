@@ -0,0 +1,108 @@
+//go:build whisper
+
+package services
+
+import (
+	gctx "context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"go.uber.org/zap"
+)
+
+// whisperSTT runs a local whisper.cpp model. The model is loaded on first use and
+// shared, and transcriptions are serialized since whisper.cpp isn't safe to run
+// concurrently on one model.
+type whisperSTT struct {
+	context.ServiceContext
+	modelPath string
+	load      sync.Once
+	loadErr   error
+	model     whisper.Model
+	mu        sync.Mutex
+}
+
+func NewWhisperSTT(ctx context.ServiceContext, modelPath string) *whisperSTT {
+	return &whisperSTT{
+		ServiceContext: ctx,
+		modelPath:      modelPath,
+	}
+}
+
+func (w *whisperSTT) Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error) {
+	lgr := w.Lgr("whisper.Transcribe")
+	w.load.Do(func() {
+		if w.modelPath == "" {
+			w.loadErr = errors.New("whisper model path not configured")
+			return
+		}
+		w.model, w.loadErr = whisper.New(w.modelPath)
+	})
+	if w.loadErr != nil {
+		return models.Transcript{}, w.loadErr
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return models.Transcript{}, err
+	}
+
+	wctx, err := w.model.NewContext()
+	if err != nil {
+		return models.Transcript{}, err
+	}
+	if err := wctx.SetLanguage("en"); err != nil {
+		lgr.Warn("failed to set language", zap.Error(err))
+	}
+	wctx.SetTokenTimestamps(true)
+
+	samples := make([]float32, len(pcm))
+	for i, s := range pcm {
+		samples[i] = float32(s) / 32768
+	}
+	if err := wctx.Process(samples, nil, nil, nil); err != nil {
+		return models.Transcript{}, err
+	}
+
+	var t models.Transcript
+	var text strings.Builder
+	for i := 0; ; i++ {
+		seg, err := wctx.NextSegment()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return models.Transcript{}, err
+		}
+		if i == 0 {
+			t.Start = seg.Start
+		}
+		t.End = seg.End
+		text.WriteString(seg.Text)
+		for _, tok := range seg.Tokens {
+			// special tokens such as [_BEG_] or <|endoftext|>
+			if strings.HasPrefix(tok.Text, "[_") || strings.HasPrefix(tok.Text, "<|") {
+				continue
+			}
+			// sub word tokens continue the previous word unless they start with a space
+			if len(t.Words) > 0 && !strings.HasPrefix(tok.Text, " ") {
+				last := &t.Words[len(t.Words)-1]
+				last.Text += tok.Text
+				last.End = tok.End
+				continue
+			}
+			t.Words = append(t.Words, models.TranscriptWord{
+				Text:  strings.TrimSpace(tok.Text),
+				Start: tok.Start,
+				End:   tok.End,
+			})
+		}
+	}
+	t.Text = strings.TrimSpace(text.String())
+	return t, nil
+}
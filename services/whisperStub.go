@@ -0,0 +1,25 @@
+//go:build !whisper
+
+package services
+
+import (
+	gctx "context"
+	"errors"
+
+	"github.com/carsonkrueger/main/context"
+	"github.com/carsonkrueger/main/models"
+)
+
+var ErrWhisperUnavailable = errors.New("built without whisper support, rebuild with -tags whisper")
+
+type whisperSTT struct {
+	context.ServiceContext
+}
+
+func NewWhisperSTT(ctx context.ServiceContext, modelPath string) *whisperSTT {
+	return &whisperSTT{ctx}
+}
+
+func (w *whisperSTT) Transcribe(ctx gctx.Context, pcm []int16) (models.Transcript, error) {
+	return models.Transcript{}, ErrWhisperUnavailable
+}
@@ -0,0 +1,159 @@
+package tools
+
+import "time"
+
+type AMDConfig struct {
+	VAD VADConfig
+	// no speech for this long after answering is an answering machine
+	InitialSilence time.Duration
+	// a greeting longer than this is an answering machine, people say "hello?"
+	MaxGreeting time.Duration
+	// silence after a short greeting is a person waiting for a reply
+	AfterGreetingSilence time.Duration
+	// undecided after this long is treated as a person
+	MaxAnalysis time.Duration
+	// beeps are searched for between these frequencies
+	BeepMinFreq  float64
+	BeepMaxFreq  float64
+	BeepFreqStep float64
+	// share of a frame's energy in one frequency for it to count as tonal
+	BeepToneRatio   float64
+	BeepMinDuration time.Duration
+}
+
+func DefaultAMDConfig() AMDConfig {
+	vad := DefaultVADConfig()
+	vad.Hangover = 800 * time.Millisecond
+	return AMDConfig{
+		VAD:                  vad,
+		InitialSilence:       2500 * time.Millisecond,
+		MaxGreeting:          1500 * time.Millisecond,
+		AfterGreetingSilence: vad.Hangover,
+		MaxAnalysis:          5 * time.Second,
+		BeepMinFreq:          400,
+		BeepMaxFreq:          2500,
+		BeepFreqStep:         25,
+		BeepToneRatio:        0.6,
+		BeepMinDuration:      150 * time.Millisecond,
+	}
+}
+
+type AMDDecision int
+
+const (
+	AMDPending AMDDecision = iota
+	AMDHuman
+	AMDMachine
+	// analysis ran out of time, callers usually treat this as a person
+	AMDUnsure
+)
+
+func (d AMDDecision) String() string {
+	switch d {
+	case AMDHuman:
+		return "human"
+	case AMDMachine:
+		return "machine"
+	case AMDUnsure:
+		return "unsure"
+	default:
+		return "pending"
+	}
+}
+
+type AMDResult struct {
+	Decision AMDDecision
+	Reason   string
+	// audio analyzed before deciding
+	After time.Duration
+}
+
+// AMD classifies who answered a call from the first seconds of audio, using the
+// length of the greeting, the silences around it and answering machine beeps.
+// Frames keep being accepted after the decision so the beep can be waited for.
+type AMD struct {
+	cfg     AMDConfig
+	vad     *VAD
+	elapsed time.Duration
+	// speech heard so far, including pauses shorter than the hangover
+	greeting  time.Duration
+	spoke     bool
+	tone      time.Duration
+	beeped    bool
+	decided   AMDResult
+	hasResult bool
+}
+
+func NewAMD(cfg AMDConfig) *AMD {
+	// silence after a greeting is measured by the vad hangover
+	cfg.VAD.Hangover = cfg.AfterGreetingSilence
+	return &AMD{cfg: cfg, vad: NewVAD(cfg.VAD)}
+}
+
+// Process analyzes the next frame, returning a result with AMDPending until a
+// decision is made and the same result after that
+func (a *AMD) Process(frame []int16) AMDResult {
+	dur := time.Duration(float64(len(frame)) / float64(a.cfg.VAD.SampleRate) * float64(time.Second))
+	a.elapsed += dur
+	a.detectBeep(frame, dur)
+	event := a.vad.Process(frame)
+	if a.vad.Speaking() || event == VADSpeechEnd {
+		a.spoke = true
+		a.greeting += dur
+	}
+	if a.hasResult {
+		return a.decided
+	}
+
+	switch {
+	case a.beeped:
+		a.decide(AMDMachine, "beep")
+	case a.greeting >= a.cfg.MaxGreeting:
+		a.decide(AMDMachine, "long greeting")
+	case event == VADSpeechEnd:
+		a.decide(AMDHuman, "short greeting then silence")
+	case !a.spoke && a.elapsed >= a.cfg.InitialSilence:
+		a.decide(AMDMachine, "silence after answering")
+	case a.elapsed >= a.cfg.MaxAnalysis:
+		a.decide(AMDUnsure, "no decision in time")
+	default:
+		return AMDResult{Decision: AMDPending, After: a.elapsed}
+	}
+	return a.decided
+}
+
+// Beeped reports whether a beep has been heard, answering machines record after it
+func (a *AMD) Beeped() bool {
+	return a.beeped
+}
+
+// Silent reports whether a greeting was heard and has ended, machines without a
+// beep are recording by then
+func (a *AMD) Silent() bool {
+	return a.spoke && !a.vad.Speaking()
+}
+
+func (a *AMD) decide(d AMDDecision, reason string) {
+	a.decided = AMDResult{Decision: d, Reason: reason, After: a.elapsed}
+	a.hasResult = true
+}
+
+func (a *AMD) detectBeep(frame []int16, dur time.Duration) {
+	if a.beeped {
+		return
+	}
+	if RMS(frame) < a.cfg.VAD.Threshold {
+		a.tone = 0
+		return
+	}
+	for f := a.cfg.BeepMinFreq; f <= a.cfg.BeepMaxFreq; f += a.cfg.BeepFreqStep {
+		if ToneRatio(frame, a.cfg.VAD.SampleRate, f) >= a.cfg.BeepToneRatio {
+			a.tone += dur
+			if a.tone >= a.cfg.BeepMinDuration {
+				a.beeped = true
+			}
+			return
+		}
+	}
+	a.tone = 0
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+// runAMD feeds pcm to a in 30ms frames and returns the last result, which is
+// the first decision since decisions stick
+func runAMD(a *AMD, pcm []int16) AMDResult {
+	frame := a.cfg.VAD.FrameSamples()
+	res := AMDResult{Decision: AMDPending}
+	for start := 0; start+frame <= len(pcm); start += frame {
+		res = a.Process(pcm[start : start+frame])
+	}
+	return res
+}
+
+func beep(freq float64, dur time.Duration) []int16 {
+	return sine(freq, 16000, int(dur.Seconds()*16000), 8000)
+}
+
+func TestAMD(t *testing.T) {
+	join := func(parts ...[]int16) []int16 {
+		var pcm []int16
+		for _, p := range parts {
+			pcm = append(pcm, p...)
+		}
+		return pcm
+	}
+	timeout := DefaultAMDConfig()
+	timeout.MaxGreeting = time.Minute
+	timeout.MaxAnalysis = 2 * time.Second
+
+	tests := []struct {
+		name       string
+		cfg        AMDConfig
+		pcm        []int16
+		want       AMDDecision
+		wantReason string
+		// when the decision should be made, give or take a frame
+		wantAfter time.Duration
+		wantBeep  bool
+	}{
+		{
+			name:       "long greeting",
+			cfg:        DefaultAMDConfig(),
+			pcm:        speechLike(3 * time.Second),
+			want:       AMDMachine,
+			wantReason: "long greeting",
+			// the vad needs 90ms of speech before it counts
+			wantAfter: 1500*time.Millisecond + 90*time.Millisecond,
+		},
+		{
+			name:       "short greeting then silence",
+			cfg:        DefaultAMDConfig(),
+			pcm:        join(silence(300*time.Millisecond), speechLike(600*time.Millisecond), silence(2*time.Second)),
+			want:       AMDHuman,
+			wantReason: "short greeting then silence",
+			wantAfter:  900*time.Millisecond + 800*time.Millisecond,
+		},
+		{
+			name:       "initial silence",
+			cfg:        DefaultAMDConfig(),
+			pcm:        silence(4 * time.Second),
+			want:       AMDMachine,
+			wantReason: "silence after answering",
+			wantAfter:  2500 * time.Millisecond,
+		},
+		{
+			name:       "beep",
+			cfg:        DefaultAMDConfig(),
+			pcm:        join(silence(300*time.Millisecond), beep(1000, 300*time.Millisecond)),
+			want:       AMDMachine,
+			wantReason: "beep",
+			wantAfter:  300*time.Millisecond + 150*time.Millisecond,
+			wantBeep:   true,
+		},
+		{
+			name:       "beep after the decision",
+			cfg:        DefaultAMDConfig(),
+			pcm:        join(speechLike(2*time.Second), beep(850, 300*time.Millisecond)),
+			want:       AMDMachine,
+			wantReason: "long greeting",
+			wantAfter:  1500*time.Millisecond + 90*time.Millisecond,
+			wantBeep:   true,
+		},
+		{
+			name:       "timeout",
+			cfg:        timeout,
+			pcm:        speechLike(3 * time.Second),
+			want:       AMDUnsure,
+			wantReason: "no decision in time",
+			wantAfter:  2 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAMD(tt.cfg)
+			got := runAMD(a, tt.pcm)
+			if got.Decision != tt.want || got.Reason != tt.wantReason {
+				t.Fatalf("got %s (%s), want %s (%s)", got.Decision, got.Reason, tt.want, tt.wantReason)
+			}
+			frame := tt.cfg.VAD.FrameDuration
+			if got.After < tt.wantAfter-frame || got.After > tt.wantAfter+frame {
+				t.Errorf("decided after %s, want about %s", got.After, tt.wantAfter)
+			}
+			if a.Beeped() != tt.wantBeep {
+				t.Errorf("got beeped %t, want %t", a.Beeped(), tt.wantBeep)
+			}
+		})
+	}
+}
+
+func TestAMDPendingUntilDecided(t *testing.T) {
+	a := NewAMD(DefaultAMDConfig())
+	if got := runAMD(a, speechLike(500*time.Millisecond)); got.Decision != AMDPending {
+		t.Errorf("got %s (%s) after half a second of speech, want pending", got.Decision, got.Reason)
+	}
+	if a.Silent() {
+		t.Error("got silent while the greeting goes on")
+	}
+	runAMD(a, silence(time.Second))
+	if !a.Silent() {
+		t.Error("got not silent after the greeting ended")
+	}
+}
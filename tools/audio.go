@@ -1,6 +1,15 @@
 package tools
 
-import "time"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
 func Int16ToWAV(data []int16, sampleRate int) []byte {
 	const numChannels = 1
@@ -73,3 +82,236 @@ func BasicWAVHeader() []byte {
 		0x00, 0x00, 0x00, 0x00, // Placeholder for data size
 	}
 }
+
+// Sample rate of a provider output format such as "pcm_16000"
+func PCMSampleRate(format string) (int, bool) {
+	parts := strings.Split(format, "_")
+	if len(parts) != 2 || parts[0] != "pcm" {
+		return 0, false
+	}
+	rate, err := strconv.Atoi(parts[1])
+	if err != nil || rate <= 0 {
+		return 0, false
+	}
+	return rate, true
+}
+
+// WritePCMPaced writes 16 bit mono PCM to w in chunks, waiting for each chunk's
+// playback duration before writing the next so the audio arrives in real time.
+func WritePCMPaced(w io.Writer, pcm []byte, sampleRate int, chunk time.Duration) error {
+	chunkSize := int(float64(sampleRate*2) * chunk.Seconds())
+	chunkSize -= chunkSize % 2
+	if chunkSize <= 0 {
+		chunkSize = len(pcm)
+	}
+	for start := 0; start < len(pcm); start += chunkSize {
+		end := min(start+chunkSize, len(pcm))
+		sent := time.Now()
+		if _, err := w.Write(pcm[start:end]); err != nil {
+			return err
+		}
+		if end < len(pcm) {
+			time.Sleep(PCMDuration(end-start, sampleRate, 1, 16) - time.Since(sent))
+		}
+	}
+	return nil
+}
+
+// DecodeWAV reads 8, 16, 24 or 32 bit integer PCM and 32 bit float WAV data and
+// returns it as 16 bit samples downmixed to mono along with the sample rate.
+func DecodeWAV(b []byte) ([]int16, int, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, 0, errors.New("not a wav file")
+	}
+	var (
+		format, channels, bitsPerSample int
+		sampleRate                      int
+		data                            []byte
+	)
+	for pos := 12; pos+8 <= len(b); {
+		id := string(b[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(b[pos+4 : pos+8]))
+		body := b[pos+8 : min(pos+8+size, len(b))]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, 0, errors.New("invalid wav fmt chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(body[0:2]))
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE keeps the real format in the sub format guid
+			if format == 0xFFFE && len(body) >= 26 {
+				format = int(binary.LittleEndian.Uint16(body[24:26]))
+			}
+		case "data":
+			data = body
+		}
+		// chunks are padded to an even size
+		pos += 8 + size + size%2
+	}
+	if channels == 0 || sampleRate == 0 {
+		return nil, 0, errors.New("wav file missing fmt chunk")
+	}
+
+	var decode func(s []byte) float64
+	switch {
+	case format == 1 && bitsPerSample == 8:
+		decode = func(s []byte) float64 { return (float64(s[0]) - 128) / 128 }
+	case format == 1 && bitsPerSample == 16:
+		decode = func(s []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(s))) / 32768 }
+	case format == 1 && bitsPerSample == 24:
+		decode = func(s []byte) float64 {
+			return float64(int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24)>>8) / (1 << 23)
+		}
+	case format == 1 && bitsPerSample == 32:
+		decode = func(s []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(s))) / (1 << 31) }
+	case format == 3 && bitsPerSample == 32:
+		decode = func(s []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(s))) }
+	default:
+		return nil, 0, fmt.Errorf("unsupported wav format %d with %d bits per sample", format, bitsPerSample)
+	}
+
+	sampleSize := bitsPerSample / 8
+	frameSize := sampleSize * channels
+	samples := make([]int16, len(data)/frameSize)
+	for i := range samples {
+		frame := data[i*frameSize : (i+1)*frameSize]
+		var sum float64
+		for c := range channels {
+			sum += decode(frame[c*sampleSize : (c+1)*sampleSize])
+		}
+		samples[i] = int16(Clamp(sum/float64(channels)*32767, -32768, 32767))
+	}
+	return samples, sampleRate, nil
+}
+
+// Resample converts mono samples between sample rates using linear interpolation
+func Resample(samples []int16, from int, to int) []int16 {
+	if from == to || len(samples) == 0 {
+		return samples
+	}
+	out := make([]int16, int(int64(len(samples))*int64(to)/int64(from)))
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(idx)
+		out[i] = int16(float64(samples[idx])*(1-frac) + float64(samples[idx+1])*frac)
+	}
+	return out
+}
+
+var (
+	dtmfRows = [4]float64{697, 770, 852, 941}
+	dtmfCols = [4]float64{1209, 1336, 1477, 1633}
+	dtmfKeys = [4][4]rune{
+		{'1', '2', '3', 'A'},
+		{'4', '5', '6', 'B'},
+		{'7', '8', '9', 'C'},
+		{'*', '0', '#', 'D'},
+	}
+)
+
+type DTMFConfig struct {
+	SampleRate int
+	// RMS level, in 16 bit sample units, below which frames are ignored
+	Threshold float64
+	// share of the frame's energy the row and column tones must hold together
+	MinToneRatio float64
+	// share of the frame's energy each of the two tones must hold at least,
+	// limits the twist between them
+	MinSingleRatio float64
+	// a digit must be heard this long before it is reported
+	MinDuration time.Duration
+}
+
+func DefaultDTMFConfig() DTMFConfig {
+	return DTMFConfig{
+		SampleRate:     16000,
+		Threshold:      300,
+		MinToneRatio:   0.8,
+		MinSingleRatio: 0.15,
+		MinDuration:    40 * time.Millisecond,
+	}
+}
+
+// DTMFDetector finds in-band keypad tones in 16 bit PCM. Frames are fed in order
+// and each key press is reported once, when it has lasted MinDuration.
+type DTMFDetector struct {
+	cfg      DTMFConfig
+	current  rune
+	held     time.Duration
+	reported bool
+}
+
+func NewDTMFDetector(cfg DTMFConfig) *DTMFDetector {
+	return &DTMFDetector{cfg: cfg}
+}
+
+// Process returns the pressed key when a press is first confirmed
+func (d *DTMFDetector) Process(frame []int16) (rune, bool) {
+	dur := time.Duration(float64(len(frame)) / float64(d.cfg.SampleRate) * float64(time.Second))
+	key := d.key(frame)
+	if key == 0 || key != d.current {
+		d.current = key
+		d.held = 0
+		d.reported = false
+		if key == 0 {
+			return 0, false
+		}
+	}
+	d.held += dur
+	if !d.reported && d.held >= d.cfg.MinDuration {
+		d.reported = true
+		return key, true
+	}
+	return 0, false
+}
+
+// key returns the key whose tones dominate the frame, or 0
+func (d *DTMFDetector) key(frame []int16) rune {
+	if RMS(frame) < d.cfg.Threshold {
+		return 0
+	}
+	row, rowRatio := strongestTone(frame, d.cfg.SampleRate, dtmfRows[:])
+	col, colRatio := strongestTone(frame, d.cfg.SampleRate, dtmfCols[:])
+	if rowRatio < d.cfg.MinSingleRatio || colRatio < d.cfg.MinSingleRatio || rowRatio+colRatio < d.cfg.MinToneRatio {
+		return 0
+	}
+	return dtmfKeys[row][col]
+}
+
+func strongestTone(frame []int16, sampleRate int, freqs []float64) (int, float64) {
+	best, bestRatio := 0, 0.0
+	for i, f := range freqs {
+		if r := ToneRatio(frame, sampleRate, f); r > bestRatio {
+			best, bestRatio = i, r
+		}
+	}
+	return best, bestRatio
+}
+
+// DTMFTone synthesizes the two tones of key, for tests and playing keys back
+func DTMFTone(key rune, sampleRate int, dur time.Duration, amplitude float64) ([]int16, bool) {
+	for r, row := range dtmfKeys {
+		for c, k := range row {
+			if k != key {
+				continue
+			}
+			samples := make([]int16, int(dur.Seconds()*float64(sampleRate)))
+			for i := range samples {
+				t := float64(i) / float64(sampleRate)
+				v := amplitude * (math.Sin(2*math.Pi*dtmfRows[r]*t) + math.Sin(2*math.Pi*dtmfCols[c]*t)) / 2
+				samples[i] = int16(Clamp(v, -32768, 32767))
+			}
+			return samples, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+const dtmfFrame = 320 // 20ms at 16kHz
+
+// feedDTMF runs pcm through a new detector in 20ms frames and returns the keys
+// it reported
+func feedDTMF(pcm []int16) string {
+	d := NewDTMFDetector(DefaultDTMFConfig())
+	var keys []rune
+	for start := 0; start+dtmfFrame <= len(pcm); start += dtmfFrame {
+		if key, ok := d.Process(pcm[start : start+dtmfFrame]); ok {
+			keys = append(keys, key)
+		}
+	}
+	return string(keys)
+}
+
+func mustDTMFTone(t *testing.T, key rune, dur time.Duration) []int16 {
+	t.Helper()
+	tone, ok := DTMFTone(key, 16000, dur, 12000)
+	if !ok {
+		t.Fatalf("no tone for key %q", key)
+	}
+	return tone
+}
+
+func silence(dur time.Duration) []int16 {
+	return make([]int16, int(dur.Seconds()*16000))
+}
+
+// dualTone mixes a keypad row and column tone at their own amplitudes
+func dualTone(rowHz, colHz float64, rowAmp, colAmp float64, dur time.Duration) []int16 {
+	samples := make([]int16, int(dur.Seconds()*16000))
+	for i := range samples {
+		ts := float64(i) / 16000
+		samples[i] = int16(rowAmp*math.Sin(2*math.Pi*rowHz*ts) + colAmp*math.Sin(2*math.Pi*colHz*ts))
+	}
+	return samples
+}
+
+// speechLike is a voiced 120Hz harmonic series under a little noise, roughly
+// what a vowel looks like to the detector
+func speechLike(dur time.Duration) []int16 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]int16, int(dur.Seconds()*16000))
+	for i := range samples {
+		ts := float64(i) / 16000
+		var v float64
+		for h := 1; h <= 30; h++ {
+			v += 3000 / float64(h) * math.Sin(2*math.Pi*120*float64(h)*ts)
+		}
+		v += rng.NormFloat64() * 800
+		samples[i] = int16(Clamp(v, -32768, 32767))
+	}
+	return samples
+}
+
+func TestDTMFDetectsEveryKey(t *testing.T) {
+	for _, key := range "123A456B789C*0#D" {
+		t.Run(string(key), func(t *testing.T) {
+			if got := feedDTMF(mustDTMFTone(t, key, 100*time.Millisecond)); got != string(key) {
+				t.Errorf("got keys %q, want %q", got, string(key))
+			}
+		})
+	}
+}
+
+func TestDTMFDetector(t *testing.T) {
+	join := func(parts ...[]int16) []int16 {
+		var pcm []int16
+		for _, p := range parts {
+			pcm = append(pcm, p...)
+		}
+		return pcm
+	}
+	tests := []struct {
+		name string
+		pcm  func(t *testing.T) []int16
+		want string
+	}{
+		{"one report for a long press", func(t *testing.T) []int16 {
+			return mustDTMFTone(t, '5', time.Second)
+		}, "5"},
+		{"same key pressed twice", func(t *testing.T) []int16 {
+			return join(mustDTMFTone(t, '7', 100*time.Millisecond), silence(60*time.Millisecond), mustDTMFTone(t, '7', 100*time.Millisecond))
+		}, "77"},
+		{"different keys back to back", func(t *testing.T) []int16 {
+			return join(mustDTMFTone(t, '1', 100*time.Millisecond), mustDTMFTone(t, '#', 100*time.Millisecond))
+		}, "1#"},
+		{"tone shorter than the minimum", func(t *testing.T) []int16 {
+			return join(mustDTMFTone(t, '3', 20*time.Millisecond), silence(100*time.Millisecond))
+		}, ""},
+		{"too quiet", func(t *testing.T) []int16 {
+			tone, _ := DTMFTone('9', 16000, 100*time.Millisecond, 200)
+			return tone
+		}, ""},
+		{"row louder than column within twist", func(t *testing.T) []int16 {
+			return dualTone(852, 1336, 8000, 5000, 100*time.Millisecond)
+		}, "8"},
+		{"column louder than row within twist", func(t *testing.T) []int16 {
+			return dualTone(852, 1336, 5000, 8000, 100*time.Millisecond)
+		}, "8"},
+		{"too much twist", func(t *testing.T) []int16 {
+			return dualTone(852, 1336, 8000, 1500, 100*time.Millisecond)
+		}, ""},
+		{"single tone", func(t *testing.T) []int16 {
+			return dualTone(852, 1336, 8000, 0, 100*time.Millisecond)
+		}, ""},
+		{"speech", func(t *testing.T) []int16 {
+			return speechLike(time.Second)
+		}, ""},
+		{"white noise", func(t *testing.T) []int16 {
+			rng := rand.New(rand.NewSource(2))
+			pcm := silence(time.Second)
+			for i := range pcm {
+				pcm[i] = int16(Clamp(rng.NormFloat64()*4000, -32768, 32767))
+			}
+			return pcm
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feedDTMF(tt.pcm(t)); got != tt.want {
+				t.Errorf("got keys %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDTMFToneUnknownKey(t *testing.T) {
+	if _, ok := DTMFTone('x', 16000, time.Second, 1000); ok {
+		t.Error("got a tone for x, want none")
+	}
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+type ChunkOptions struct {
+	// upper bound on the length of a chunk
+	MaxChars int
+	// characters from the end of a chunk repeated at the start of the next,
+	// so text cut at a boundary can still be found
+	Overlap int
+}
+
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		MaxChars: 1200,
+		Overlap:  200,
+	}
+}
+
+var (
+	paragraphBreak  = regexp.MustCompile(`\n\s*\n`)
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s`)
+)
+
+// ChunkText splits text at paragraphs into chunks of at most MaxChars, splitting
+// longer paragraphs at words. Markdown headings always start a new chunk.
+func ChunkText(text string, opts ChunkOptions) []string {
+	if opts.MaxChars <= 0 {
+		opts = DefaultChunkOptions()
+	}
+	opts.Overlap = Clamp(opts.Overlap, 0, opts.MaxChars/4)
+
+	var chunks []string
+	var cur strings.Builder
+	flush := func(overlap bool) {
+		chunk := strings.TrimSpace(cur.String())
+		cur.Reset()
+		if chunk == "" {
+			return
+		}
+		chunks = append(chunks, chunk)
+		if overlap && opts.Overlap > 0 {
+			cur.WriteString(overlapTail(chunk, opts.Overlap))
+		}
+	}
+
+	// long paragraphs are split small enough to follow an overlap or heading
+	pieceChars := opts.MaxChars - opts.Overlap - 2
+	for _, para := range splitParagraphs(text) {
+		for _, piece := range splitLong(para, pieceChars) {
+			if markdownHeading.MatchString(piece) {
+				flush(false)
+			}
+			if cur.Len() > 0 && cur.Len()+2+len(piece) > opts.MaxChars {
+				flush(true)
+				// the overlap is dropped when it leaves no room for the piece
+				if cur.Len()+2+len(piece) > opts.MaxChars {
+					cur.Reset()
+				}
+			}
+			if cur.Len() > 0 {
+				cur.WriteString("\n\n")
+			}
+			cur.WriteString(piece)
+		}
+	}
+	flush(false)
+	return chunks
+}
+
+// splitParagraphs splits at blank lines and before markdown headings
+func splitParagraphs(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var paras []string
+	for _, block := range paragraphBreak.Split(text, -1) {
+		var cur []string
+		for _, line := range strings.Split(block, "\n") {
+			if markdownHeading.MatchString(strings.TrimSpace(line)) && len(cur) > 0 {
+				paras = append(paras, strings.TrimSpace(strings.Join(cur, "\n")))
+				cur = nil
+			}
+			cur = append(cur, line)
+		}
+		if p := strings.TrimSpace(strings.Join(cur, "\n")); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return paras
+}
+
+func splitLong(para string, maxChars int) []string {
+	if len(para) <= maxChars {
+		return []string{para}
+	}
+	var pieces []string
+	var cur strings.Builder
+	for _, word := range strings.Fields(para) {
+		for len(word) > maxChars {
+			pieces = append(pieces, word[:maxChars])
+			word = word[maxChars:]
+		}
+		if cur.Len() > 0 && cur.Len()+1+len(word) > maxChars {
+			pieces = append(pieces, cur.String())
+			cur.Reset()
+		}
+		if cur.Len() > 0 {
+			cur.WriteByte(' ')
+		}
+		cur.WriteString(word)
+	}
+	if cur.Len() > 0 {
+		pieces = append(pieces, cur.String())
+	}
+	return pieces
+}
+
+// overlapTail returns at most n characters from the end of s, starting at a word
+func overlapTail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	tail := s[len(s)-n:]
+	if i := strings.IndexAny(tail, " \n"); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return strings.TrimSpace(tail)
+}
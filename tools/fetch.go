@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	ErrBlockedAddress      = errors.New("address is not publicly routable")
+	ErrUnsupportedScheme   = errors.New("only http and https urls can be fetched")
+	ErrTooManyRedirects    = errors.New("too many redirects")
+	ErrUnsupportedContent  = errors.New("unsupported content type")
+	ErrUnsuccessfulRequest = errors.New("request was unsuccessful")
+)
+
+// ranges that aren't covered by the net/netip helpers
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+type FetchOptions struct {
+	Timeout      time.Duration
+	MaxRedirects int
+	// max bytes of the response body read
+	MaxBodyBytes int64
+	// media types that may be fetched, e.g. text/html
+	AllowedContentTypes []string
+	// disables the public address check, only for local development and tests
+	AllowPrivateNetworks bool
+}
+
+func DefaultFetchOptions() FetchOptions {
+	return FetchOptions{
+		Timeout:             10 * time.Second,
+		MaxRedirects:        3,
+		MaxBodyBytes:        2 << 20,
+		AllowedContentTypes: []string{"text/html", "application/xhtml+xml", "text/plain"},
+	}
+}
+
+type FetchResult struct {
+	// final url after redirects
+	URL         string
+	ContentType string
+	Title       string
+	// readable text of the page
+	Text string
+}
+
+// Excerpt returns up to maxChars of the text, cut at a word boundary
+func (r FetchResult) Excerpt(maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(r.Text) <= maxChars {
+		return r.Text
+	}
+	runes := []rune(r.Text)[:maxChars]
+	excerpt := string(runes)
+	if i := strings.LastIndexAny(excerpt, " \n"); i > maxChars/2 {
+		excerpt = excerpt[:i]
+	}
+	return strings.TrimSpace(excerpt) + "…"
+}
+
+// Fetcher retrieves untrusted urls. Addresses are checked when dialing, after DNS
+// resolution, so hostnames that resolve to internal addresses are blocked too.
+type Fetcher struct {
+	opts   FetchOptions
+	client *http.Client
+}
+
+func NewFetcher(opts FetchOptions) *Fetcher {
+	return newFetcher(opts, IsPublicAddr)
+}
+
+// newFetcher dials only addresses allowed reports true for, unless private
+// networks are allowed
+func newFetcher(opts FetchOptions, allowed func(netip.Addr) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: opts.Timeout,
+	}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allowed(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		// a proxy would dial on our behalf and bypass the address check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return checkScheme(req.URL)
+		},
+	}
+	return &Fetcher{opts: opts, client: client}
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (FetchResult, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return FetchResult{}, err
+	}
+	if err := checkScheme(u); err != nil {
+		return FetchResult{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	req.Header.Set("Accept", strings.Join(f.opts.AllowedContentTypes, ", "))
+	res, err := f.client.Do(req)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return FetchResult{}, fmt.Errorf("%w: %s", ErrUnsuccessfulRequest, res.Status)
+	}
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !slices.Contains(f.opts.AllowedContentTypes, mediaType) {
+		return FetchResult{}, fmt.Errorf("%w: %q", ErrUnsupportedContent, res.Header.Get("Content-Type"))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, f.opts.MaxBodyBytes))
+	if err != nil {
+		return FetchResult{}, err
+	}
+
+	result := FetchResult{
+		URL:         res.Request.URL.String(),
+		ContentType: mediaType,
+	}
+	if mediaType == "text/plain" {
+		result.Text = CollapseWhitespace(string(body))
+		return result, nil
+	}
+	result.Title, result.Text, err = ExtractReadableText(string(body))
+	return result, err
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+	return nil
+}
+
+// IsPublicAddr reports whether addr is globally routable
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// elements that never hold readable content
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+}
+
+// elements that start a new line of text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Blockquote: true, atom.Pre: true, atom.Table: true,
+}
+
+// ExtractReadableText returns the title and visible text of an html document,
+// preferring the <article> or <main> element when there is one
+func ExtractReadableText(doc string) (string, string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", "", err
+	}
+
+	var title string
+	var content *html.Node
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if title == "" && n.FirstChild != nil {
+					title = CollapseWhitespace(n.FirstChild.Data)
+				}
+			case atom.Article, atom.Main:
+				if content == nil {
+					content = n
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(root)
+	if content == nil {
+		content = root
+	}
+
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] || n.DataAtom == atom.Title || n.DataAtom == atom.Head {
+				return
+			}
+			if blockElements[n.DataAtom] {
+				defer sb.WriteByte('\n')
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(content)
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = CollapseWhitespace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return title, strings.Join(lines, "\n"), nil
+}
+
+func CollapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// loopbackFetcher may reach httptest servers on 127.0.0.1 but no other private
+// address
+func loopbackFetcher(opts FetchOptions) *Fetcher {
+	return newFetcher(opts, func(addr netip.Addr) bool {
+		return addr == netip.MustParseAddr("127.0.0.1") || IsPublicAddr(addr)
+	})
+}
+
+func serve(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchBlocksLoopbackAfterResolving(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked address was reached")
+	})
+	f := NewFetcher(DefaultFetchOptions())
+	for _, u := range []string{
+		srv.URL,
+		// resolves to a loopback address, so it's only caught after DNS
+		strings.Replace(srv.URL, "127.0.0.1", "localhost", 1),
+	} {
+		_, err := f.Fetch(context.Background(), u)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("%s: want ErrBlockedAddress, got %v", u, err)
+		}
+	}
+}
+
+func TestFetchRejectsOtherSchemes(t *testing.T) {
+	_, err := NewFetcher(DefaultFetchOptions()).Fetch(context.Background(), "file:///etc/passwd")
+	if !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("want ErrUnsupportedScheme, got %v", err)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Query().Get("n"), "%d", &n)
+		if n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/?n=%d", n-1), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("landed"))
+	})
+	opts := DefaultFetchOptions()
+	opts.MaxRedirects = 2
+	f := loopbackFetcher(opts)
+
+	res, err := f.Fetch(context.Background(), srv.URL+"/?n=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "landed" || !strings.HasSuffix(res.URL, "/?n=0") {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/?n=3"); !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("want ErrTooManyRedirects, got %v", err)
+	}
+}
+
+func TestFetchBlocksRedirectToPrivateAddress(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://10.0.0.1/admin", http.StatusFound)
+	})
+	_, err := loopbackFetcher(DefaultFetchOptions()).Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("want ErrBlockedAddress, got %v", err)
+	}
+}
+
+func TestFetchContentTypeAllowlist(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		w.Write([]byte("body"))
+	})
+	f := loopbackFetcher(DefaultFetchOptions())
+	for contentType, allowed := range map[string]bool{
+		"text/plain; charset=utf-8": true,
+		"text/html":                 true,
+		"application/json":          false,
+		"application/octet-stream":  false,
+		"":                          false,
+	} {
+		_, err := f.Fetch(context.Background(), srv.URL+"/?type="+url.QueryEscape(contentType))
+		if allowed && err != nil {
+			t.Errorf("%q: %v", contentType, err)
+		} else if !allowed && !errors.Is(err, ErrUnsupportedContent) {
+			t.Errorf("%q: want ErrUnsupportedContent, got %v", contentType, err)
+		}
+	}
+}
+
+func TestFetchUnsuccessfulStatus(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError} {
+		srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(status)
+		})
+		_, err := loopbackFetcher(DefaultFetchOptions()).Fetch(context.Background(), srv.URL)
+		if !errors.Is(err, ErrUnsuccessfulRequest) {
+			t.Errorf("%d: want ErrUnsuccessfulRequest, got %v", status, err)
+		}
+	}
+}
+
+func TestFetchSizeCap(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("word ", 1000)))
+	})
+	opts := DefaultFetchOptions()
+	opts.MaxBodyBytes = 100
+	res, err := loopbackFetcher(opts).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Text) > 100 || len(res.Text) < 90 {
+		t.Fatalf("want about 100 bytes of text, got %d", len(res.Text))
+	}
+}
+
+func TestFetchExtractsHTMLText(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title> Opening   Hours </title><style>p { color: red }</style></head>
+<body>
+	<nav>Home | About</nav>
+	<script>alert("hi")</script>
+	<article>
+		<h1>Hours</h1>
+		<p>We are open   9 to 5.</p>
+		<p>Closed on <b>Sundays</b>.</p>
+	</article>
+	<footer>Copyright</footer>
+</body>
+</html>`))
+	})
+	res, err := loopbackFetcher(DefaultFetchOptions()).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Title != "Opening Hours" {
+		t.Errorf("title %q", res.Title)
+	}
+	want := "Hours\nWe are open 9 to 5.\nClosed on Sundays ."
+	if res.Text != want {
+		t.Errorf("text %q, want %q", res.Text, want)
+	}
+}
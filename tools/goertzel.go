@@ -0,0 +1,30 @@
+package tools
+
+import "math"
+
+// Goertzel returns the power of freq in 16 bit samples, cheaper than an FFT when
+// only a few frequencies are of interest
+func Goertzel(samples []int16, sampleRate int, freq float64) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*freq/float64(sampleRate))
+	var s1, s2 float64
+	for _, x := range samples {
+		s0 := float64(x) + coeff*s1 - s2
+		s2 = s1
+		s1 = s0
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
+
+// ToneRatio returns how much of the frame's energy is at freq, near 1 for a pure
+// tone and near 0 for speech or noise
+func ToneRatio(samples []int16, sampleRate int, freq float64) float64 {
+	var energy float64
+	for _, x := range samples {
+		f := float64(x)
+		energy += f * f
+	}
+	if energy == 0 {
+		return 0
+	}
+	return 2 * Goertzel(samples, sampleRate, freq) / (float64(len(samples)) * energy)
+}
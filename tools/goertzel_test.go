@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(freq float64, sampleRate int, n int, amplitude float64) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+func TestToneRatio(t *testing.T) {
+	const rate = 16000
+	tests := []struct {
+		name    string
+		samples []int16
+		freq    float64
+		min     float64
+		max     float64
+	}{
+		{"pure tone", sine(1000, rate, 320, 8000), 1000, 0.9, 1.1},
+		{"tone off by a keypad step", sine(1000, rate, 320, 8000), 1209, 0, 0.1},
+		{"silence", make([]int16, 320), 1000, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToneRatio(tt.samples, rate, tt.freq); got < tt.min || got > tt.max {
+				t.Errorf("got ratio %.3f, want between %.1f and %.1f", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestGoertzelScalesWithAmplitude(t *testing.T) {
+	quiet := Goertzel(sine(697, 8000, 205, 1000), 8000, 697)
+	loud := Goertzel(sine(697, 8000, 205, 2000), 8000, 697)
+	if ratio := loud / quiet; math.Abs(ratio-4) > 0.01 {
+		t.Errorf("got power ratio %.3f for twice the amplitude, want 4", ratio)
+	}
+}
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
@@ -10,33 +12,127 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
-func GenerateSalt() (string, error) {
-	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
-	if err != nil {
+// Argon2Params are the argon2id costs a password hash was made with
+type Argon2Params struct {
+	// KiB
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// PasswordParams hash new passwords. Raising them rehashes each user's
+// password the next time they log in.
+var PasswordParams = Argon2Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 4,
+	KeyLen:  32,
+}
+
+// params of the salt$hash format passwords were hashed in before PHC strings
+var legacyPasswordParams = Argon2Params{
+	Memory:  32 * 1024,
+	Time:    1,
+	Threads: 4,
+	KeyLen:  32,
+}
+
+const passwordSaltLen = 16
+
+// upper bound on the memory of a stored hash, 4 GiB, so a corrupt hash can't
+// exhaust memory verifying it
+const maxArgon2Memory = 4 * 1024 * 1024
+
+// HashPassword hashes a password with argon2id and a random salt into a PHC
+// string, $argon2id$v=19$m=65536,t=3,p=4$salt$hash
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return base64.StdEncoding.EncodeToString(salt), nil
+	p := PasswordParams
+	hash := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
 }
 
-// HashPassword hashes a password using Argon2
-func HashPassword(password, salt string) string {
-	saltBytes, _ := base64.StdEncoding.DecodeString(salt)
-	hash := argon2.IDKey([]byte(password), saltBytes, 1, 32*1024, 4, 32)
-	return fmt.Sprintf("%s$%s", salt, base64.StdEncoding.EncodeToString(hash))
+// VerifyPassword compares a password with a stored PHC or legacy salt$hash in
+// constant time. rehash is true when the password matched but was hashed in the
+// legacy format or with other params than PasswordParams.
+func VerifyPassword(password, storedHash string) (match bool, rehash bool) {
+	var (
+		p          Argon2Params
+		salt, hash []byte
+		err        error
+	)
+	if strings.HasPrefix(storedHash, "$") {
+		p, salt, hash, err = decodePHC(storedHash)
+	} else {
+		p = legacyPasswordParams
+		salt, hash, err = decodeLegacy(storedHash)
+	}
+	if err != nil {
+		return false, false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	if subtle.ConstantTimeCompare(hash, other) != 1 {
+		return false, false
+	}
+	return true, p != PasswordParams || len(salt) < passwordSaltLen || !strings.HasPrefix(storedHash, "$")
 }
 
-// VerifyPassword compares a provided password with the stored hash
-func VerifyPassword(password, storedHash string) bool {
-	parts := strings.Split(storedHash, "$")
-	if len(parts) != 2 {
-		return false
+func decodePHC(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return p, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 params %q", parts[3])
 	}
-	salt, _ := parts[0], parts[1]
+	if p.Time == 0 || p.Threads == 0 || p.Memory > maxArgon2Memory {
+		return p, nil, nil, fmt.Errorf("invalid argon2 params %q", parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	if len(hash) == 0 {
+		return p, nil, nil, fmt.Errorf("empty argon2 hash")
+	}
+	p.KeyLen = uint32(len(hash))
+	return p, salt, hash, nil
+}
 
-	// Hash the provided password with the stored salt
-	newHash := HashPassword(password, salt)
-	return newHash == storedHash
+func decodeLegacy(encoded string) ([]byte, []byte, error) {
+	salt64, hash64, ok := strings.Cut(encoded, "$")
+	if !ok {
+		return nil, nil, fmt.Errorf("not a password hash")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := base64.StdEncoding.DecodeString(hash64)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hash) != int(legacyPasswordParams.KeyLen) {
+		return nil, nil, fmt.Errorf("invalid legacy hash length")
+	}
+	return salt, hash, nil
 }
 
 func GenerateToken(length int) (string, error) {
@@ -46,3 +142,10 @@ func GenerateToken(length int) (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// HashToken hashes a random token for storage. It is unsalted so tokens can be
+// looked up by their hash, random tokens don't need a slow hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
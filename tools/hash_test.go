@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// hashed by the baseline HashPassword, argon2id with t=1 and 32 MiB, before
+// passwords were stored as PHC strings
+const baselineHash = "YmFzZWxpbmUtc2FsdC0xNg==$w09XL5E94pI6ioVQalWTqqzSMeBH2bbTQUo+WSWBl7I="
+
+// phcWith hashes password into a PHC string with params other than PasswordParams
+func phcWith(password string, p Argon2Params) string {
+	salt := []byte("0123456789abcdef")
+	hash := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	)
+}
+
+func TestVerifyPassword(t *testing.T) {
+	current, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		password   string
+		stored     string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{"round trip", "correct horse", current, true, false},
+		{"wrong password", "battery staple", current, false, false},
+		{"baseline salt$hash", "correct horse", baselineHash, true, true},
+		{"baseline salt$hash with a wrong password", "battery staple", baselineHash, false, false},
+		{"outdated params", "correct horse", phcWith("correct horse", legacyPasswordParams), true, true},
+		{"outdated params with a wrong password", "battery staple", phcWith("correct horse", legacyPasswordParams), false, false},
+		{"empty stored hash", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash := VerifyPassword(tt.password, tt.stored)
+			if match != tt.wantMatch || rehash != tt.wantRehash {
+				t.Errorf("got match %t rehash %t, want match %t rehash %t", match, rehash, tt.wantMatch, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestDecodePHC(t *testing.T) {
+	p, salt, hash, err := decodePHC(phcWith("pw", Argon2Params{Memory: 1024, Time: 2, Threads: 1, KeyLen: 16}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Argon2Params{Memory: 1024, Time: 2, Threads: 1, KeyLen: 16}); p != want {
+		t.Errorf("got params %+v, want %+v", p, want)
+	}
+	if string(salt) != "0123456789abcdef" || len(hash) != 16 {
+		t.Errorf("got salt %q and %d byte hash, want the encoded ones", salt, len(hash))
+	}
+}
+
+func TestDecodePHCRejects(t *testing.T) {
+	const salt, hash = "MDEyMzQ1Njc4OWFiY2RlZg", "c29tZSBoYXNoIGJ5dGVz"
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"other algorithm", "$argon2i$v=19$m=1024,t=1,p=1$" + salt + "$" + hash},
+		{"other version", "$argon2id$v=16$m=1024,t=1,p=1$" + salt + "$" + hash},
+		{"missing part", "$argon2id$v=19$m=1024,t=1,p=1$" + salt},
+		{"extra part", "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$" + hash + "$x"},
+		{"garbled params", "$argon2id$v=19$memory=1024$" + salt + "$" + hash},
+		{"zero time", "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + hash},
+		{"zero threads", "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + hash},
+		{"oversized memory", fmt.Sprintf("$argon2id$v=19$m=%d,t=1,p=1$%s$%s", maxArgon2Memory+1, salt, hash)},
+		{"memory overflowing uint32", "$argon2id$v=19$m=99999999999,t=1,p=1$" + salt + "$" + hash},
+		{"bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!$" + hash},
+		{"bad hash", "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$!!"},
+		{"empty hash", "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := decodePHC(tt.encoded); err == nil {
+				t.Errorf("decoded %q, want an error", tt.encoded)
+			}
+			if match, _ := VerifyPassword("pw", tt.encoded); match {
+				t.Errorf("verified against %q, want no match", tt.encoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// fakeIssuer is an OpenID provider on httptest.
+// Its authorize endpoint logs in as User straight away and redirects back with a
+// code, the token endpoint checks the PKCE verifier before issuing an ID token.
+type fakeIssuer struct {
+	Server   *httptest.Server
+	ClientID string
+	// claims of the user logging in, iss, aud, exp, iat and nonce are set by the issuer
+	User map[string]any
+
+	key *rsa.PrivateKey
+	mu  sync.Mutex
+	// code to the login it was issued for
+	codes map[string]fakeLogin
+}
+
+type fakeLogin struct {
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+func newFakeIssuer(clientID string) (*fakeIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	f := &fakeIssuer{
+		ClientID: clientID,
+		User: map[string]any{
+			"sub":            "fake-user",
+			"email":          "fake.user@example.com",
+			"email_verified": true,
+			"given_name":     "Fake",
+			"family_name":    "User",
+			"groups":         []string{},
+		},
+		key:   key,
+		codes: make(map[string]fakeLogin),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("/authorize", f.authorize)
+	mux.HandleFunc("/token", f.token)
+	mux.HandleFunc("/jwks", f.jwks)
+	f.Server = httptest.NewServer(mux)
+	return f, nil
+}
+
+func (f *fakeIssuer) URL() string {
+	return f.Server.URL
+}
+
+func (f *fakeIssuer) Close() {
+	f.Server.Close()
+}
+
+// Sign makes an ID token with claims, for checking how tokens are validated
+func (f *fakeIssuer) Sign(claims map[string]any) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "fake", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (f *fakeIssuer) discovery(res http.ResponseWriter, req *http.Request) {
+	writeJSON(res, Discovery{
+		Issuer:                f.URL(),
+		AuthorizationEndpoint: f.URL() + "/authorize",
+		TokenEndpoint:         f.URL() + "/token",
+		JWKSURI:               f.URL() + "/jwks",
+	})
+}
+
+func (f *fakeIssuer) authorize(res http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	if q.Get("client_id") != f.ClientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(res, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	code, err := randomString(16)
+	if err != nil {
+		http.Error(res, "server_error", http.StatusInternalServerError)
+		return
+	}
+	f.mu.Lock()
+	f.codes[code] = fakeLogin{
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		redirectURI: q.Get("redirect_uri"),
+	}
+	f.mu.Unlock()
+	back, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(res, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	bq := back.Query()
+	bq.Set("code", code)
+	bq.Set("state", q.Get("state"))
+	back.RawQuery = bq.Encode()
+	http.Redirect(res, req, back.String(), http.StatusFound)
+}
+
+func (f *fakeIssuer) token(res http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(res, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	login, ok := f.codes[req.FormValue("code")]
+	delete(f.codes, req.FormValue("code"))
+	f.mu.Unlock()
+	if !ok || login.redirectURI != req.FormValue("redirect_uri") || PKCEChallenge(req.FormValue("code_verifier")) != login.challenge {
+		http.Error(res, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+	claims := make(map[string]any, len(f.User)+5)
+	for k, v := range f.User {
+		claims[k] = v
+	}
+	now := time.Now()
+	claims["iss"] = f.URL()
+	claims["aud"] = f.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(5 * time.Minute).Unix()
+	claims["nonce"] = login.nonce
+	idToken, err := f.Sign(claims)
+	if err != nil {
+		http.Error(res, "server_error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(res, map[string]any{
+		"access_token": "fake-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (f *fakeIssuer) jwks(res http.ResponseWriter, req *http.Request) {
+	pub := f.key.PublicKey
+	writeJSON(res, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "fake",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(res http.ResponseWriter, v any) {
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(v)
+}
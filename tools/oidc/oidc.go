@@ -0,0 +1,346 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrIssuerMismatch = errors.New("issuer does not match")
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrUnknownKey     = errors.New("id token signed with an unknown key")
+	ErrTokenExchange  = errors.New("token exchange failed")
+)
+
+// accepted difference between our clock and the issuer's
+const clockSkew = time.Minute
+
+// max bytes read from any provider response
+const maxResponseBytes = 1 << 20
+
+// Discovery is the part of /.well-known/openid-configuration the login flow uses
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the ID token claims the app reads. Groups is filled from the
+// claim named by the provider's groups claim.
+type Claims struct {
+	Issuer        string    `json:"iss"`
+	Subject       string    `json:"sub"`
+	Audience      audience  `json:"aud"`
+	AuthorizedBy  string    `json:"azp"`
+	Expiry        int64     `json:"exp"`
+	IssuedAt      int64     `json:"iat"`
+	Nonce         string    `json:"nonce"`
+	Email         string    `json:"email"`
+	EmailVerified *bool     `json:"email_verified"`
+	Name          string    `json:"name"`
+	GivenName     string    `json:"given_name"`
+	FamilyName    string    `json:"family_name"`
+	Groups        []string  `json:"-"`
+	raw           rawClaims `json:"-"`
+}
+
+type rawClaims map[string]json.RawMessage
+
+// audience is a single string or a list of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// claim holding the user's groups, usually "groups"
+	GroupsClaim string
+}
+
+// Provider is an OpenID provider found by discovery
+type Provider struct {
+	cfg       Config
+	client    *http.Client
+	discovery Discovery
+
+	mu   sync.Mutex
+	keys map[string]any
+}
+
+// Discover reads the issuer's configuration. client is used for every request
+// to the provider, pass the client of an httptest server to test against a fake.
+func Discover(ctx context.Context, client *http.Client, issuer string, cfg Config) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	var d Discovery
+	if err := getJSON(ctx, client, issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: %q, expected %q", ErrIssuerMismatch, d.Issuer, issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("incomplete provider configuration")
+	}
+	return &Provider{
+		cfg:       cfg,
+		client:    client,
+		discovery: d,
+	}, nil
+}
+
+func (p *Provider) Discovery() Discovery {
+	return p.discovery
+}
+
+// AuthCode is what a login keeps between sending the user to the provider and
+// the callback
+type AuthCode struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewAuthCode makes the random state, nonce and PKCE verifier of a login
+func NewAuthCode() (AuthCode, error) {
+	var ac AuthCode
+	for _, v := range []*string{&ac.State, &ac.Nonce, &ac.Verifier} {
+		s, err := randomString(32)
+		if err != nil {
+			return ac, err
+		}
+		*v = s
+	}
+	return ac, nil
+}
+
+// AuthCodeURL is where the user is sent to log in
+func (p *Provider) AuthCodeURL(ac AuthCode) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", ac.State)
+	q.Set("nonce", ac.Nonce)
+	q.Set("code_challenge", PKCEChallenge(ac.Verifier))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.discovery.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// PKCEChallenge is the S256 challenge of verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Exchange redeems the code from the callback and returns its verified ID token claims
+func (p *Provider) Exchange(ctx context.Context, code string, ac AuthCode) (*Claims, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", ac.Verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrTokenExchange, res.Status)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token", ErrTokenExchange)
+	}
+	return p.VerifyIDToken(ctx, tokens.IDToken, ac.Nonce, time.Now())
+}
+
+// VerifyIDToken checks the signature and claims of an ID token issued to us for nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, token string, nonce string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidIDToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header", ErrInvalidIDToken)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature", ErrInvalidIDToken)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims", ErrInvalidIDToken)
+	}
+	if err := decodeSegment(parts[1], &claims.raw); err != nil {
+		return nil, fmt.Errorf("%w: claims", ErrInvalidIDToken)
+	}
+	if strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(p.discovery.Issuer, "/") {
+		return nil, fmt.Errorf("%w: issuer", ErrInvalidIDToken)
+	}
+	if !claims.Audience.contains(p.cfg.ClientID) {
+		return nil, fmt.Errorf("%w: audience", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party", ErrInvalidIDToken)
+	}
+	if claims.Expiry == 0 || now.Add(-clockSkew).Unix() >= claims.Expiry {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	}
+	if claims.IssuedAt > now.Add(clockSkew).Unix() {
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: subject", ErrInvalidIDToken)
+	}
+	claims.Groups = claims.stringList(p.cfg.GroupsClaim)
+	return &claims, nil
+}
+
+// stringList reads a claim that is a string or a list of strings
+func (c *Claims) stringList(name string) []string {
+	raw, ok := c.raw[name]
+	if name == "" || !ok {
+		return nil
+	}
+	var list audience
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil
+	}
+	return list
+}
+
+// key returns the verification key with kid, refetching the key set once when
+// it's unknown in case the provider rotated keys
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	keys, err := fetchKeys(ctx, p.client, p.discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// lookupKey finds kid, a token without one may use the only key of the set
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, res.Status)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseBytes)).Decode(v)
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,237 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testClientID = "test-client"
+
+func newTestProvider(t *testing.T) (*fakeIssuer, *Provider) {
+	t.Helper()
+	f, err := newFakeIssuer(testClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(f.Close)
+	p, err := Discover(context.Background(), f.Server.Client(), f.URL(), Config{
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.test/sso/callback",
+		Scopes:       []string{"openid", "email"},
+		GroupsClaim:  "groups",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, p
+}
+
+// authorize follows the login url to the issuer and returns the callback's query
+func authorize(t *testing.T, f *fakeIssuer, loginURL string) url.Values {
+	t.Helper()
+	client := f.Server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	res, err := client.Get(loginURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %s", res.Status)
+	}
+	back, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return back.Query()
+}
+
+// claims of a token the test provider accepts for nonce, before any changes
+func validClaims(f *fakeIssuer, nonce string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":            f.URL(),
+		"sub":            "user-1",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	f, p := newTestProvider(t)
+	d := p.Discovery()
+	if d.Issuer != f.URL() || d.TokenEndpoint != f.URL()+"/token" || d.JWKSURI != f.URL()+"/jwks" {
+		t.Errorf("got discovery %+v", d)
+	}
+
+	tests := []struct {
+		name      string
+		discovery Discovery
+		wantErr   error
+	}{
+		{
+			name:      "other issuer",
+			discovery: Discovery{Issuer: "https://evil.example.com", AuthorizationEndpoint: "a", TokenEndpoint: "t", JWKSURI: "j"},
+			wantErr:   ErrIssuerMismatch,
+		},
+		{
+			name:      "missing endpoints",
+			discovery: Discovery{AuthorizationEndpoint: "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				d := tt.discovery
+				if d.Issuer == "" {
+					d.Issuer = "http://" + req.Host
+				}
+				writeJSON(res, d)
+			}))
+			defer srv.Close()
+			_, err := Discover(context.Background(), srv.Client(), srv.URL, Config{ClientID: testClientID})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	f, p := newTestProvider(t)
+	f.User["groups"] = []string{"staff", "admins"}
+	ac, err := NewAuthCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loginURL, err := url.Parse(p.AuthCodeURL(ac))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := loginURL.Query()
+	if q.Get("state") != ac.State || q.Get("nonce") != ac.Nonce || q.Get("code_challenge") != PKCEChallenge(ac.Verifier) {
+		t.Errorf("login url %s does not carry the auth code", loginURL)
+	}
+	if q.Get("code_challenge") == ac.Verifier {
+		t.Error("login url leaks the PKCE verifier")
+	}
+
+	back := authorize(t, f, loginURL.String())
+	if back.Get("state") != ac.State {
+		t.Errorf("got state %q back, want %q", back.Get("state"), ac.State)
+	}
+	claims, err := p.Exchange(context.Background(), back.Get("code"), ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "fake-user" || claims.Email != "fake.user@example.com" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		t.Errorf("got claims %+v", claims)
+	}
+	if len(claims.Groups) != 2 || claims.Groups[0] != "staff" || claims.Groups[1] != "admins" {
+		t.Errorf("got groups %q, want staff and admins", claims.Groups)
+	}
+
+	// codes are single use
+	if _, err := p.Exchange(context.Background(), back.Get("code"), ac); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("got %v redeeming a code twice, want ErrTokenExchange", err)
+	}
+}
+
+func TestExchangePKCEMismatch(t *testing.T) {
+	f, p := newTestProvider(t)
+	ac, err := NewAuthCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := authorize(t, f, p.AuthCodeURL(ac))
+
+	stolen := ac
+	stolen.Verifier = "not-the-verifier"
+	if _, err := p.Exchange(context.Background(), back.Get("code"), stolen); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("got %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	f, p := newTestProvider(t)
+	now := time.Now()
+	tests := []struct {
+		name   string
+		change func(claims map[string]any)
+		nonce  string
+		ok     bool
+	}{
+		{name: "valid", change: func(map[string]any) {}, nonce: "n-1", ok: true},
+		{name: "other nonce", change: func(map[string]any) {}, nonce: "n-2"},
+		{name: "missing nonce", change: func(c map[string]any) { delete(c, "nonce") }, nonce: "n-1"},
+		{name: "other audience", change: func(c map[string]any) { c["aud"] = "other-client" }, nonce: "n-1"},
+		{name: "audience list", change: func(c map[string]any) { c["aud"] = []string{"other-client", testClientID}; c["azp"] = testClientID }, nonce: "n-1", ok: true},
+		{name: "audience list for another party", change: func(c map[string]any) { c["aud"] = []string{"other-client", testClientID} }, nonce: "n-1"},
+		{name: "expired", change: func(c map[string]any) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, nonce: "n-1"},
+		{name: "expired within skew", change: func(c map[string]any) { c["exp"] = now.Add(-30 * time.Second).Unix() }, nonce: "n-1", ok: true},
+		{name: "no expiry", change: func(c map[string]any) { delete(c, "exp") }, nonce: "n-1"},
+		{name: "issued in the future", change: func(c map[string]any) { c["iat"] = now.Add(time.Hour).Unix() }, nonce: "n-1"},
+		{name: "other issuer", change: func(c map[string]any) { c["iss"] = "https://evil.example.com" }, nonce: "n-1"},
+		{name: "no subject", change: func(c map[string]any) { delete(c, "sub") }, nonce: "n-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims(f, "n-1")
+			tt.change(claims)
+			token, err := f.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.VerifyIDToken(context.Background(), token, tt.nonce, now)
+			if tt.ok && err != nil {
+				t.Errorf("got %v, want the token accepted", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("got %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenSignature(t *testing.T) {
+	f, p := newTestProvider(t)
+	other, err := newFakeIssuer(testClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	// same kid, but signed by a key the provider never published
+	token, err := other.Sign(validClaims(f, "n-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.VerifyIDToken(context.Background(), token, "n-1", time.Now()); err == nil {
+		t.Error("expected a token signed by another key to be rejected")
+	}
+
+	token, err = f.Sign(validClaims(f, "n-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := token[:len(token)-4] + "AAAA"
+	if _, err := p.VerifyIDToken(context.Background(), tampered, "n-1", time.Now()); err == nil {
+		t.Error("expected a tampered token to be rejected")
+	}
+}
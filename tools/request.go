@@ -2,7 +2,9 @@ package tools
 
 import (
 	"errors"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
 
@@ -44,6 +46,64 @@ func GetAuthParts(cookie *http.Cookie) (string, int64, error) {
 	return token, int64(id), err
 }
 
+// GetBearerToken returns the token of an `Authorization: Bearer` header
+func GetBearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func IsHxRequest(req *http.Request) bool {
 	return req.Header.Get("HX-Request") == "true"
 }
+
+// ClientIP returns the address of the client. Behind a proxy the ForwardedFor
+// middleware sets the request's RemoteAddr to the client the proxy forwarded.
+func ClientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// ForwardedClientIP returns the client a trusted proxy forwarded the request for.
+// X-Forwarded-For is only believed when the request comes from one of trusted,
+// and is read from the right past any further trusted proxies, since a client
+// can put anything at the start of the header. Otherwise it returns ClientIP.
+func ForwardedClientIP(req *http.Request, trusted []netip.Prefix) string {
+	client := ClientIP(req)
+	if !isTrustedProxy(client, trusted) {
+		return client
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			// a trusted proxy would not have written this, stop at the last good hop
+			break
+		}
+		client = hop
+		if !isTrustedProxy(hop, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
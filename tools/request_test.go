@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestForwardedClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5123", want: "203.0.113.7"},
+		{name: "direct client spoofing the header", remoteAddr: "203.0.113.7:5123", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:443", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "client prepending a fake hop", remoteAddr: "10.0.0.2:443", forwarded: []string{"1.1.1.1, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.2:443", forwarded: []string{"198.51.100.1, 10.0.0.9", "10.0.0.3"}, want: "198.51.100.1"},
+		{name: "only trusted hops", remoteAddr: "10.0.0.2:443", forwarded: []string{"10.0.0.9"}, want: "10.0.0.9"},
+		{name: "garbage hop", remoteAddr: "10.0.0.2:443", forwarded: []string{"not-an-ip, 10.0.0.9"}, want: "10.0.0.9"},
+		{name: "trusted proxy without the header", remoteAddr: "10.0.0.2:443", want: "10.0.0.2"},
+		{name: "ipv6 proxy", remoteAddr: "[2001:db8::1]:443", forwarded: []string{"2001:db8::42"}, want: "2001:db8::42"},
+		{name: "ipv4 mapped proxy", remoteAddr: "[::ffff:10.0.0.2]:443", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := ForwardedClientIP(req, trusted); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPIgnoresForwardedFor(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5123"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := ClientIP(req); got != "203.0.113.7" {
+		t.Errorf("got %q, want the remote address", got)
+	}
+	if got := ForwardedClientIP(req, nil); got != "203.0.113.7" {
+		t.Errorf("got %q without trusted proxies, want the remote address", got)
+	}
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"math"
+	"time"
+)
+
+type VADConfig struct {
+	SampleRate    int
+	FrameDuration time.Duration
+	// RMS level, in 16 bit sample units, above which a frame counts as voiced
+	Threshold float64
+	// voiced audio needed before speech is considered started
+	MinSpeech time.Duration
+	// silence needed before speech is considered ended
+	Hangover time.Duration
+}
+
+func DefaultVADConfig() VADConfig {
+	return VADConfig{
+		SampleRate:    16000,
+		FrameDuration: 30 * time.Millisecond,
+		Threshold:     500,
+		MinSpeech:     90 * time.Millisecond,
+		Hangover:      600 * time.Millisecond,
+	}
+}
+
+// Samples in a single frame
+func (c VADConfig) FrameSamples() int {
+	return int(float64(c.SampleRate) * c.FrameDuration.Seconds())
+}
+
+type VADEvent int
+
+const (
+	VADNone VADEvent = iota
+	VADSpeechStart
+	VADSpeechEnd
+)
+
+// VAD is a simple energy based voice activity detector. Frames are fed in order
+// and it reports when speech starts and ends.
+type VAD struct {
+	cfg      VADConfig
+	speaking bool
+	voiced   time.Duration
+	silence  time.Duration
+}
+
+func NewVAD(cfg VADConfig) *VAD {
+	return &VAD{cfg: cfg}
+}
+
+func (v *VAD) Speaking() bool {
+	return v.speaking
+}
+
+func (v *VAD) Process(frame []int16) VADEvent {
+	dur := time.Duration(float64(len(frame)) / float64(v.cfg.SampleRate) * float64(time.Second))
+	voiced := RMS(frame) >= v.cfg.Threshold
+
+	if !v.speaking {
+		if !voiced {
+			v.voiced = 0
+			return VADNone
+		}
+		v.voiced += dur
+		if v.voiced >= v.cfg.MinSpeech {
+			v.speaking = true
+			v.silence = 0
+			return VADSpeechStart
+		}
+		return VADNone
+	}
+
+	if voiced {
+		v.silence = 0
+		return VADNone
+	}
+	v.silence += dur
+	if v.silence >= v.cfg.Hangover {
+		v.Reset()
+		return VADSpeechEnd
+	}
+	return VADNone
+}
+
+func (v *VAD) Reset() {
+	v.speaking = false
+	v.voiced = 0
+	v.silence = 0
+}
+
+// Root mean square of 16 bit samples
+func RMS(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		f := float64(s)
+		sum += f * f
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
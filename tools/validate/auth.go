@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"errors"
 	"net/url"
 	"regexp"
 )
@@ -45,3 +46,11 @@ func ValidateSignup(form url.Values) []error {
 func ValidateLogin(form url.Values) []error {
 	return validateForm(signupValidatorMap, form, "email", "password")
 }
+
+func ValidatePasswordReset(form url.Values) []error {
+	errs := validateForm(signupValidatorMap, form, "password")
+	if form.Get("password") != form.Get("confirm_password") {
+		errs = append(errs, errors.New("Passwords do not match"))
+	}
+	return errs
+}